@@ -0,0 +1,123 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/radiantrfid/iris"
+
+	"github.com/radiantrfid/iris/sessions"
+	"github.com/radiantrfid/iris/sessions/sessiondb/sqldb"
+
+	// any database/sql driver, i.e "github.com/lib/pq" with the sqldb.Postgres() dialect
+	// or "github.com/go-sql-driver/mysql" with the sqldb.MySQL() one.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	service, err := sql.Open("sqlite3", "./sessions.db")
+	if err != nil {
+		panic(err)
+	}
+
+	// creates the "iris_sessions", "iris_sessions_values" and "iris_sessions_info" tables, if they do not exist,
+	// and removes the expired sessions every 10 minutes.
+	db, err := sqldb.New(service, sqldb.Config{
+		Table:           "iris_sessions",
+		Dialect:         sqldb.SQLite(),
+		CleanupInterval: 10 * time.Minute,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// close the database when control+C/cmd+C pressed
+	iris.RegisterOnInterrupt(func() {
+		db.Close()
+	})
+
+	defer db.Close() // close the database if application errored.
+
+	sess := sessions.New(sessions.Config{
+		Cookie:       "sessionscookieid",
+		Expires:      45 * time.Minute, // <=0 means unlimited life. Defaults to 0.
+		AllowReclaim: true,
+	})
+
+	//
+	// IMPORTANT:
+	//
+	sess.UseDatabase(db)
+
+	// the rest of the code stays the same.
+	app := iris.New()
+
+	app.Get("/", func(ctx iris.Context) {
+		ctx.Writef("You should navigate to the /set, /get, /delete, /clear,/destroy instead")
+	})
+	app.Get("/set", func(ctx iris.Context) {
+		s := sess.Start(ctx)
+		// set session values
+		s.Set("name", "iris")
+
+		// test if set here
+		ctx.Writef("All ok session value of the 'name' is: %s", s.GetString("name"))
+	})
+
+	app.Get("/set/{key}/{value}", func(ctx iris.Context) {
+		key, value := ctx.Params().Get("key"), ctx.Params().Get("value")
+		s := sess.Start(ctx)
+		// set session values
+		s.Set(key, value)
+
+		// test if set here
+		ctx.Writef("All ok session value of the '%s' is: %s", key, s.GetString(key))
+	})
+
+	app.Get("/get", func(ctx iris.Context) {
+		// get a specific key, as string, if no found returns just an empty string
+		name := sess.Start(ctx).GetString("name")
+
+		ctx.Writef("The 'name' on the /set was: %s", name)
+	})
+
+	app.Get("/get/{key}", func(ctx iris.Context) {
+		// get a specific key, as string, if no found returns just an empty string
+		name := sess.Start(ctx).GetString(ctx.Params().Get("key"))
+
+		ctx.Writef("The name on the /set was: %s", name)
+	})
+
+	app.Get("/delete", func(ctx iris.Context) {
+		// delete a specific key
+		sess.Start(ctx).Delete("name")
+	})
+
+	app.Get("/clear", func(ctx iris.Context) {
+		// removes all entries
+		sess.Start(ctx).Clear()
+	})
+
+	app.Get("/destroy", func(ctx iris.Context) {
+		// destroy, removes the entire session data and cookie
+		sess.Destroy(ctx)
+	})
+
+	app.Get("/update", func(ctx iris.Context) {
+		// updates resets the expiration based on the session's `Expires` field.
+		if err := sess.ShiftExpiration(ctx); err != nil {
+			if sessions.ErrNotFound.Equal(err) {
+				ctx.StatusCode(iris.StatusNotFound)
+			} else if sessions.ErrNotImplemented.Equal(err) {
+				ctx.StatusCode(iris.StatusNotImplemented)
+			} else {
+				ctx.StatusCode(iris.StatusNotModified)
+			}
+
+			ctx.Writef("%v", err)
+			ctx.Application().Logger().Error(err)
+		}
+	})
+
+	app.Run(iris.Addr(":8080"), iris.WithoutServerError(iris.ErrServerClosed))
+}
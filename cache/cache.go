@@ -28,12 +28,88 @@ Example code:
 package cache
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/radiantrfid/iris/cache/client"
+	"github.com/radiantrfid/iris/cache/store"
 	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/core/router"
 )
 
+// Store is the interface of the cached responses' storage, see the `Handler.Store` method of the `Cache`.
+//
+// The in-memory stores are the `NewLRU` (default) and the `NewLFU`,
+// persistent ones which can be shared across replicas are created through
+// the boltdb, badger and redis session databases, i.e `redis.New(...).CacheStore()`.
+type Store = store.Store
+
+var (
+	// NewLRU returns a new size-bounded, in bytes, in-memory least recently used `Store`.
+	NewLRU = store.NewLRU
+	// NewLFU returns a new size-bounded, in bytes, in-memory least frequently used `Store`.
+	NewLFU = store.NewLFU
+	// Sweep removes the expired entries of a `Store` every "interval",
+	// the stores that can't expire their entries on their own implement a `Sweep()` method.
+	Sweep = store.Sweep
+)
+
+// Purger keeps an index of the stored responses in order to remove them before their expiration,
+// by their keys, tags, route names and paths, see the `PurgeAPI` too.
+type Purger = client.Purger
+
+var (
+	// NewPurger returns a new `Purger`, set it to a cache handler through its `Purger` method.
+	NewPurger = client.NewPurger
+	// DefaultPurger is the `Purger` of the cache handlers, unless `Handler.Purger` is used.
+	// Use its `Broker` method to propagate the purges to all the instances of an application,
+	// i.e `cache.DefaultPurger.Broker(redis.New(...).CacheBroker())`.
+	DefaultPurger = client.DefaultPurger
+	// Tag tags the response of the current request,
+	// i.e `cache.Tag(ctx, "user:42")` and later on `cache.DefaultPurger.PurgeTag("user:42")`.
+	Tag = client.Tag
+)
+
+// PurgeAPI returns a function which registers the purge endpoints of the "p" `Purger` to a Party,
+// the responses are JSON objects of the number of the purged responses, i.e {"purged": 3}.
+//
+// DELETE /keys?key=https://example.com/users?page=2
+// DELETE /tags/{tag}
+// DELETE /routes/{name}
+// DELETE /paths/{prefix}, i.e /paths/users purges the "/users" and "/users/42".
+//
+// Note that the purges are not protected, register them to a Party with an authentication middleware, i.e
+// cache.PurgeAPI(cache.DefaultPurger)(app.Party("/admin/cache", myAdminAuth))
+func PurgeAPI(p *Purger) func(router.Party) {
+	purged := func(ctx context.Context, n int) {
+		ctx.JSON(context.Map{"purged": n})
+	}
+
+	return func(party router.Party) {
+		party.Delete("/keys", func(ctx context.Context) {
+			key := ctx.URLParam("key")
+			if key == "" {
+				ctx.StatusCode(http.StatusBadRequest)
+				return
+			}
+
+			purged(ctx, p.PurgeKey(key))
+		})
+
+		party.Delete("/tags/{tag:path}", func(ctx context.Context) {
+			purged(ctx, p.PurgeTag(ctx.Params().Get("tag")))
+		})
+
+		party.Delete("/routes/{name:path}", func(ctx context.Context) {
+			purged(ctx, p.PurgeRoute(ctx.Params().Get("name")))
+		})
+
+		party.Delete("/paths/{prefix:path}", func(ctx context.Context) {
+			purged(ctx, p.PurgePrefix("/"+ctx.Params().Get("prefix")))
+		})
+	}
+}
+
 // Cache accepts the cache expiration duration.
 // If the "expiration" input argument is invalid, <=2 seconds,
 // then expiration is taken by the "cache-control's maxage" header.
@@ -44,6 +120,12 @@ import (
 // Use it for server-side caching, see the `iris#Cache304` for an alternative approach that
 // may be more suited to your needs.
 //
+// It acts as a shared cache (RFC 9111), it respects the "Cache-Control" and "Vary" headers
+// of the requests and responses, so it can be used on authenticated APIs too,
+// i.e responses to requests with an Authorization header are stored only if they are marked as "public".
+// The "s-maxage", "max-age", "stale-while-revalidate" and "stale-if-error" directives of the handler's response are used
+// and conditional requests are answered with 304 based on the stored, or generated, ETag.
+//
 // You can add validators with this function.
 func Cache(expiration time.Duration) *client.Handler {
 	return client.NewHandler(expiration)
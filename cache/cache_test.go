@@ -2,6 +2,7 @@ package cache_test
 
 import (
 	"net/http"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -206,3 +207,323 @@ func TestCacheValidator(t *testing.T) {
 		t.Fatalf(t.Name()+": %v", errTestFailed.Format(3, counter))
 	}
 }
+
+func TestCacheStore(t *testing.T) {
+	app := iris.New()
+	var n uint32
+
+	s := cache.NewLFU(0)
+	app.Get("/", cache.Cache(cacheDuration).Store(s).ServeHTTP, func(ctx context.Context) {
+		atomic.AddUint32(&n, 1)
+		ctx.Header("X-Custom", "value")
+		ctx.Write([]byte(expectedBodyStr))
+	})
+
+	e := httptest.New(t, app)
+	if err := runTest(e, "/", &n, expectedBodyStr, ""); err != nil {
+		t.Fatalf(t.Name()+": %v", err)
+	}
+
+	e.GET("/").Expect().Status(http.StatusOK).Header("X-Custom").Equal("value")
+	if s.Len() != 1 {
+		t.Fatalf("expected the response to be stored in the custom store")
+	}
+}
+
+func TestCacheVary(t *testing.T) {
+	app := iris.New()
+	var n uint32
+
+	app.Get("/", cache.Handler(cacheDuration), func(ctx context.Context) {
+		atomic.AddUint32(&n, 1)
+		ctx.Header("Vary", "Accept-Language")
+		ctx.WriteString("lang: " + ctx.GetHeader("Accept-Language"))
+	})
+
+	e := httptest.New(t, app)
+	for i := 0; i < 2; i++ {
+		e.GET("/").WithHeader("Accept-Language", "en").Expect().Status(http.StatusOK).Body().Equal("lang: en")
+		e.GET("/").WithHeader("Accept-Language", "el").Expect().Status(http.StatusOK).Body().Equal("lang: el")
+	}
+
+	if counter := atomic.LoadUint32(&n); counter != 2 {
+		t.Fatal(errTestFailed.Format(2, counter))
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	app := iris.New()
+	var n uint32
+
+	app.Use(cache.Handler(cacheDuration))
+	h := func(cacheControl string) context.Handler {
+		return func(ctx context.Context) {
+			atomic.AddUint32(&n, 1)
+			if cacheControl != "" {
+				ctx.Header("Cache-Control", cacheControl)
+			}
+			ctx.WriteString(expectedBodyStr)
+		}
+	}
+
+	app.Get("/private", h("private"))
+	app.Get("/nostore", h("no-store"))
+	app.Get("/zero", h("max-age=0"))
+	app.Get("/default", h(""))
+	app.Get("/public", h("public, max-age=60"))
+	app.Post("/public", func(ctx context.Context) {})
+
+	e := httptest.New(t, app)
+	expect := func(path string, expected uint32, authorization ...string) {
+		t.Helper()
+		atomic.StoreUint32(&n, 0)
+		for i := 0; i < 2; i++ {
+			req := e.GET(path)
+			if len(authorization) > 0 {
+				req.WithHeader("Authorization", authorization[0])
+			}
+			req.Expect().Status(http.StatusOK).Body().Equal(expectedBodyStr)
+		}
+
+		if counter := atomic.LoadUint32(&n); counter != expected {
+			t.Fatalf("%s: %v", path, errTestFailed.Format(expected, counter))
+		}
+	}
+
+	expect("/private", 2)
+	expect("/nostore", 2)
+	expect("/zero", 2)
+	// requests with authorization may be served only by explicitly shareable responses.
+	expect("/default", 2, "Basic dXNlcjpwYXNz")
+	expect("/public", 1, "Basic dXNlcjpwYXNz")
+	expect("/public", 0)
+
+	// unsafe methods invalidate the stored response.
+	e.POST("/public").Expect().Status(http.StatusOK)
+	expect("/public", 1)
+
+	// the client asks for a fresh response.
+	atomic.StoreUint32(&n, 0)
+	e.GET("/public").WithHeader("Cache-Control", "no-cache").Expect().Status(http.StatusOK)
+	if counter := atomic.LoadUint32(&n); counter != 1 {
+		t.Fatal(errTestFailed.Format(1, counter))
+	}
+}
+
+func TestCacheConditional(t *testing.T) {
+	app := iris.New()
+	var n uint32
+
+	app.Get("/", cache.Handler(cacheDuration), func(ctx context.Context) {
+		atomic.AddUint32(&n, 1)
+		ctx.WriteString(expectedBodyStr)
+	})
+
+	e := httptest.New(t, app)
+	etag := e.GET("/").Expect().Status(http.StatusOK).Header("ETag").NotEmpty().Raw()
+
+	e.GET("/").WithHeader("If-None-Match", etag).Expect().Status(http.StatusNotModified).Body().Empty()
+	e.GET("/").WithHeader("If-None-Match", `W/`+etag).Expect().Status(http.StatusNotModified)
+	e.GET("/").WithHeader("If-None-Match", `"other"`).Expect().Status(http.StatusOK).
+		Header("Age").NotEmpty()
+
+	if counter := atomic.LoadUint32(&n); counter != 1 {
+		t.Fatal(errTestFailed.Format(1, counter))
+	}
+}
+
+func TestCacheStale(t *testing.T) {
+	app := iris.New()
+	var n, n2 uint32
+
+	app.Get("/revalidate", cache.Handler(cacheDuration), func(ctx context.Context) {
+		v := atomic.AddUint32(&n, 1)
+		ctx.Header("Cache-Control", "max-age=2, stale-while-revalidate=30")
+		ctx.Writef("%d", v)
+	})
+
+	app.Get("/error", cache.Handler(cacheDuration), func(ctx context.Context) {
+		if atomic.AddUint32(&n2, 1) > 1 {
+			ctx.StatusCode(http.StatusInternalServerError)
+			return
+		}
+		ctx.Header("Cache-Control", "max-age=2, stale-if-error=30")
+		ctx.WriteString(expectedBodyStr)
+	})
+
+	e := httptest.New(t, app)
+	e.GET("/revalidate").Expect().Status(http.StatusOK).Body().Equal("1")
+	e.GET("/error").Expect().Status(http.StatusOK).Body().Equal(expectedBodyStr)
+
+	time.Sleep(cacheDuration + cacheDuration/5)
+
+	// stale, revalidated on the background.
+	e.GET("/revalidate").Expect().Status(http.StatusOK).Body().Equal("1")
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadUint32(&n) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the stale response to be revalidated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	deadline = time.Now().Add(5 * time.Second)
+	for e.GET("/revalidate").Expect().Status(http.StatusOK).Body().Raw() != "2" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the revalidated response to be served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the handler fails, serve the stale one.
+	e.GET("/error").Expect().Status(http.StatusOK).Body().Equal(expectedBodyStr)
+	if counter := atomic.LoadUint32(&n2); counter != 2 {
+		t.Fatal(errTestFailed.Format(2, counter))
+	}
+}
+
+func TestCachePurge(t *testing.T) {
+	app := iris.New()
+	var n uint32
+
+	p := cache.NewPurger()
+	app.Use(cache.Cache(cacheDuration * 10).Purger(p).ServeHTTP)
+
+	h := func(ctx context.Context) {
+		atomic.AddUint32(&n, 1)
+		cache.Tag(ctx, "user:"+ctx.Params().Get("id"))
+		ctx.WriteString(ctx.Path())
+	}
+	app.Get("/users/{id}", h).Name = "user"
+	app.Get("/posts/{id}", h)
+
+	cache.PurgeAPI(p)(app.Party("/admin/cache"))
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	expect := func(expected uint32, paths ...string) {
+		t.Helper()
+		atomic.StoreUint32(&n, 0)
+		for _, path := range paths {
+			e.GET(path).Expect().Status(http.StatusOK).Body().Equal(path)
+		}
+
+		if counter := atomic.LoadUint32(&n); counter != expected {
+			t.Fatal(errTestFailed.Format(expected, counter))
+		}
+	}
+
+	paths := []string{"/users/1", "/users/2", "/posts/1"}
+	expect(3, paths...)
+	expect(0, paths...)
+
+	if purged := p.PurgeTag("user:1"); purged != 2 {
+		t.Fatalf("expected 2 responses to be purged by tag but got %d", purged)
+	}
+	expect(2, paths...)
+
+	if purged := p.PurgeRoute("user"); purged != 2 {
+		t.Fatalf("expected 2 responses to be purged by route but got %d", purged)
+	}
+	expect(2, paths...)
+
+	e.DELETE("/admin/cache/paths/users").Expect().Status(http.StatusOK).JSON().Equal(map[string]int{"purged": 2})
+	expect(2, paths...)
+
+	e.DELETE("/admin/cache/keys").Expect().Status(http.StatusBadRequest)
+	e.DELETE("/admin/cache/keys").WithQuery("key", "http://example.com/posts/1").
+		Expect().Status(http.StatusOK).JSON().Equal(map[string]int{"purged": 1})
+	e.DELETE("/admin/cache/tags/user:2").Expect().Status(http.StatusOK).JSON().Equal(map[string]int{"purged": 1})
+	expect(2, paths...)
+}
+
+// testBroker is an in-memory `store.Broker` of the TestCachePurgeBroker.
+type testBroker struct {
+	mu       sync.Mutex
+	handlers []func(string)
+}
+
+func (b *testBroker) Publish(message string) error {
+	b.mu.Lock()
+	handlers := b.handlers
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		h(message)
+	}
+	return nil
+}
+
+func (b *testBroker) Subscribe(handler func(string)) (func(), error) {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handler)
+	b.mu.Unlock()
+	return func() {}, nil
+}
+
+func TestCachePurgeBroker(t *testing.T) {
+	broker := new(testBroker)
+	var n uint32
+
+	newInstance := func() (*httpexpect.Expect, *cache.Purger) {
+		p := cache.NewPurger()
+		if err := p.Broker(broker); err != nil {
+			t.Fatal(err)
+		}
+
+		app := iris.New()
+		app.Get("/", cache.Cache(cacheDuration*10).Purger(p).ServeHTTP, func(ctx context.Context) {
+			atomic.AddUint32(&n, 1)
+			cache.Tag(ctx, "home")
+			ctx.WriteString(expectedBodyStr)
+		})
+
+		return httptest.New(t, app), p
+	}
+
+	e1, p1 := newInstance()
+	e2, _ := newInstance()
+
+	for i := 0; i < 2; i++ {
+		e1.GET("/").Expect().Status(http.StatusOK)
+		e2.GET("/").Expect().Status(http.StatusOK)
+	}
+
+	if counter := atomic.LoadUint32(&n); counter != 2 {
+		t.Fatal(errTestFailed.Format(2, counter))
+	}
+
+	// purged from both instances.
+	p1.PurgeTag("home")
+	e1.GET("/").Expect().Status(http.StatusOK)
+	e2.GET("/").Expect().Status(http.StatusOK)
+	if counter := atomic.LoadUint32(&n); counter != 4 {
+		t.Fatal(errTestFailed.Format(4, counter))
+	}
+}
+
+func TestCacheCoalesce(t *testing.T) {
+	app := iris.New()
+	var n uint32
+
+	app.Get("/", cache.Handler(cacheDuration), func(ctx context.Context) {
+		atomic.AddUint32(&n, 1)
+		time.Sleep(200 * time.Millisecond)
+		ctx.WriteString(expectedBodyStr)
+	})
+
+	e := httptest.New(t, app)
+
+	var wg sync.WaitGroup
+	wg.Add(5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			defer wg.Done()
+			e.GET("/").Expect().Status(http.StatusOK).Body().Equal(expectedBodyStr)
+		}()
+	}
+	wg.Wait()
+
+	if counter := atomic.LoadUint32(&n); counter != 1 {
+		t.Fatal(errTestFailed.Format(1, counter))
+	}
+}
@@ -42,6 +42,9 @@ type ClientHandler struct {
 // the ClientHandler is useful when user
 // wants to apply horizontal scaling to the app and
 // has a central http server which handles
+//
+// Note that a `Handler` with a shared `store.Store`, i.e a redis one,
+// is the preferred way to share the cache across replicas.
 func NewClientHandler(bodyHandler context.Handler, life time.Duration, remote string) *ClientHandler {
 	return &ClientHandler{
 		bodyHandler:      bodyHandler,
@@ -1,12 +1,22 @@
 package client
 
 import (
+	stdContext "context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/radiantrfid/iris/cache/cfg"
 	"github.com/radiantrfid/iris/cache/client/rule"
 	"github.com/radiantrfid/iris/cache/entry"
+	"github.com/radiantrfid/iris/cache/store"
 	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/middleware/coalesce"
+
+	"github.com/kataras/golog"
 )
 
 // Handler the local cache service handler contains
@@ -19,19 +29,94 @@ type Handler struct {
 	rule rule.Rule
 	// when expires.
 	expiration time.Duration
-	// entries the memory cache stored responses.
-	entries map[string]*entry.Entry
-	mu      sync.RWMutex
+	// store keeps the encoded cache entries,
+	// defaults to an in-memory LRU store.
+	store store.Store
+	// purger indexes the stored responses in order to be removed before their expiration,
+	// defaults to the `DefaultPurger`.
+	purger *Purger
+	// group collapses the concurrent requests of an expired or missing response,
+	// nil if it's disabled.
+	group *coalesce.Group
+	// revalidating keeps the keys of the stale responses
+	// which are being revalidated on the background, see "stale-while-revalidate".
+	revalidating sync.Map
 }
 
 // NewHandler returns a new cached handler for the "bodyHandler"
 // which expires every "expiration".
 func NewHandler(expiration time.Duration) *Handler {
-	return &Handler{
+	h := &Handler{
 		rule:       DefaultRuleSet,
 		expiration: expiration,
-		entries:    make(map[string]*entry.Entry, 0),
+		store:      store.NewLRU(0),
+		purger:     DefaultPurger,
+	}
+
+	return h.Coalesce(0)
+}
+
+// Coalesce collapses the concurrent identical requests of an expired or missing response,
+// only the first one executes the handler and the rest wait and receive its response,
+// for a maximum of "timeout", after that they execute the handler on their own.
+// Zero "timeout" means the `coalesce.DefaultTimeout` and a negative one disables it.
+//
+// Enabled by default.
+//
+// returns itself.
+func (h *Handler) Coalesce(timeout time.Duration) *Handler {
+	if timeout < 0 {
+		h.group = nil
+		return h
 	}
+
+	if timeout == 0 {
+		timeout = coalesce.DefaultTimeout
+	}
+
+	h.group = &coalesce.Group{Timeout: timeout, Share: h.share}
+	return h
+}
+
+// share reports whether the recorded response of an identical request
+// can be written to the waiting one, like it was stored and served by the cache.
+func (h *Handler) share(ctx context.Context, r *coalesce.Response) bool {
+	if r.Header.Get(cfg.NoCacheHeader) == "true" {
+		return false
+	}
+
+	authorized := ctx.GetHeader("Authorization") != ""
+	if _, _, ok := h.storeLifetime(r.StatusCode, r.Header, authorized); !ok {
+		return false
+	}
+
+	return coalesce.CanShare(ctx, r)
+}
+
+// Store sets the storage of the cached responses, i.e
+// a `store.NewLFU` or a redis one which can be shared across replicas.
+// Defaults to a `store.NewLRU` of `store.DefaultMaxSize`.
+//
+// returns itself.
+func (h *Handler) Store(s store.Store) *Handler {
+	if s != nil {
+		h.store = s
+	}
+
+	return h
+}
+
+// Purger sets the `Purger` which indexes the stored responses of this handler,
+// so they can be removed before their expiration.
+// Defaults to the `DefaultPurger`.
+//
+// returns itself.
+func (h *Handler) Purger(p *Purger) *Handler {
+	if p != nil {
+		h.purger = p
+	}
+
+	return h
 }
 
 // Rule sets the ruleset for this handler.
@@ -65,12 +150,211 @@ var emptyHandler = func(ctx context.Context) {
 	ctx.StopExecution()
 }
 
-func parseLifeChanger(ctx context.Context) entry.LifeChanger {
-	return func() time.Duration {
-		return time.Duration(ctx.MaxAge()) * time.Second
+// revalidationKey is the request context key which marks
+// the background requests of the "stale-while-revalidate".
+type revalidationKey struct{}
+
+// heuristicallyCacheable are the status codes which can be stored
+// without an explicit freshness lifetime (RFC 9110 section 15.1),
+// the configured expiration is used for them.
+var heuristicallyCacheable = map[int]bool{
+	200: true, 203: true, 204: true, 206: true, 300: true, 301: true, 308: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+func (h *Handler) getEntry(key string) (*entry.Entry, bool) {
+	data, found := h.store.Get(key)
+	if !found {
+		return nil, false
 	}
+
+	e := new(entry.Entry)
+	if err := e.UnmarshalBinary(data); err != nil {
+		golog.Debugf("cache: unable to decode the entry of '%s': %v", key, err)
+		h.store.Delete(key)
+		return nil, false
+	}
+
+	return e, true
+}
+
+func (h *Handler) setEntry(key string, e *entry.Entry, lifetime time.Duration) {
+	data, err := e.MarshalBinary()
+	if err != nil {
+		golog.Debugf("cache: unable to encode the entry of '%s': %v", key, err)
+		return
+	}
+
+	h.store.Set(key, data, lifetime)
+}
+
+// lookup returns the stored entry of the request.
+// The responses which vary are stored under their variant key
+// and their primary key keeps an entry of their "Vary" header only.
+func (h *Handler) lookup(key string, r *http.Request) (*entry.Entry, bool) {
+	e, found := h.getEntry(key)
+	if !found {
+		return nil, false
+	}
+
+	response, _ := e.Stale()
+	if names := varyNames(response.Headers()); len(names) > 0 {
+		return h.getEntry(variantKey(key, names, r.Header))
+	}
+
+	return e, true
+}
+
+// varyNames returns the request header names of the "Vary" response header.
+func varyNames(header http.Header) (names []string) {
+	for _, value := range header[context.VaryHeaderKey] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return
 }
 
+func variantKey(key string, names []string, header http.Header) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range names {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(header[name], ","))
+	}
+
+	return b.String()
+}
+
+// shareable reports whether a stored response can be used for requests with an Authorization header,
+// a shared cache can do that only if the response is explicitly allowed (RFC 9111 section 3.5).
+func shareable(cc entry.CacheControl) bool {
+	return cc.Has("public") || cc.Has("s-maxage") || cc.Has("must-revalidate")
+}
+
+// canServeStale reports whether a stale response can be served
+// on "stale-while-revalidate" and "stale-if-error" (RFC 9111 section 4.2.4).
+func canServeStale(cc entry.CacheControl) bool {
+	return !cc.Has("must-revalidate") && !cc.Has("proxy-revalidate") && !cc.Has("s-maxage")
+}
+
+// storeLifetime returns the freshness lifetime of the recorded response
+// and the time it should be kept to be served stale after that,
+// store is false if the response must not be stored by a shared cache.
+func (h *Handler) storeLifetime(statusCode int, header http.Header, authorized bool) (lifetime, staleLifetime time.Duration, ok bool) {
+	cc := entry.ParseCacheControl(header)
+	if cc.Has("no-store") || cc.Has("private") || cc.Has("no-cache") {
+		return
+	}
+
+	if authorized && !shareable(cc) {
+		return
+	}
+
+	for _, name := range varyNames(header) {
+		if name == "*" {
+			return
+		}
+	}
+
+	lifetime, explicit := entry.Freshness(header)
+	if !explicit {
+		if !heuristicallyCacheable[statusCode] {
+			return
+		}
+
+		lifetime = h.expiration
+	}
+
+	if lifetime <= 0 {
+		return
+	}
+
+	if canServeStale(cc) {
+		staleLifetime, _ = cc.Duration("stale-while-revalidate")
+		if d, _ := cc.Duration("stale-if-error"); d > staleLifetime {
+			staleLifetime = d
+		}
+	}
+
+	return lifetime, staleLifetime, true
+}
+
+// notModified reports whether the conditional request can be answered with a 304 status code.
+func notModified(ctx context.Context, header http.Header) bool {
+	lastModified, _ := context.ParseTime(ctx, header.Get(context.LastModifiedHeaderKey))
+	return ctx.CheckPreconditions(header.Get(context.ETagHeaderKey), lastModified) == http.StatusNotModified
+}
+
+// revalidate executes the request of the stale response of the "key"
+// on the background, once, in order to refresh the stored response.
+func (h *Handler) revalidate(ctx context.Context, key string) {
+	if _, loaded := h.revalidating.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+
+	// a copy of the request with its own URL and header,
+	// the original request is reused by the server once the handler returns.
+	r := ctx.Request().WithContext(stdContext.WithValue(stdContext.Background(), revalidationKey{}, true))
+	u := *r.URL
+	r.URL = &u
+	r.Header = make(http.Header, len(r.Header))
+	entry.CopyHeaders(r.Header, ctx.Request().Header)
+	r.Header.Del(context.IfNoneMatchHeaderKey)
+	r.Header.Del(context.IfModifiedSinceHeaderKey)
+	app := ctx.Application()
+
+	go func() {
+		defer h.revalidating.Delete(key)
+		app.ServeHTTP(&discardResponseWriter{header: make(http.Header)}, r)
+	}()
+}
+
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
+
+// write writes the stored response of the "e" to the client,
+// if "conditional" is true then it answers the conditional requests with a 304 status code.
+func write(ctx context.Context, e *entry.Entry, response *entry.Response, conditional bool) {
+	header := ctx.ResponseWriter().Header()
+	entry.CopyHeaders(header, response.Headers())
+	if header.Get(context.LastModifiedHeaderKey) == "" {
+		ctx.SetLastModified(e.LastModified)
+	}
+	ctx.Header("Age", strconv.FormatInt(int64(e.Age()/time.Second), 10))
+
+	if conditional && notModified(ctx, header) {
+		ctx.WriteNotModified()
+		return
+	}
+
+	ctx.StatusCode(response.StatusCode())
+	ctx.Write(response.Body())
+}
+
+// ServeHTTP serves the stored responses as a shared cache (RFC 9111).
+//
+// Only the responses of GET requests are stored and they are served to GET and HEAD ones,
+// the successful responses of unsafe methods, i.e POST, remove the stored response of their URI
+// from this instance, use the `Purger` to remove responses from all instances.
+// The "Vary" response header is respected, the responses to requests with an Authorization
+// header are stored and served only if they are marked as "public", "s-maxage" or "must-revalidate"
+// and the "no-store", "private" and "no-cache" responses are never stored.
+// The "s-maxage", "max-age" and "Expires" of the response override the handler's expiration,
+// the "stale-while-revalidate" and "stale-if-error" directives are supported too.
+// An ETag is generated for the stored responses that don't have one,
+// so conditional requests are answered with a 304 status code.
 func (h *Handler) ServeHTTP(ctx context.Context) {
 	// check for pre-cache validators, if at least one of them return false
 	// for this specific request, then skip the whole cache
@@ -88,84 +372,146 @@ func (h *Handler) ServeHTTP(ctx context.Context) {
 		return
 	}
 
+	r := ctx.Request()
 	scheme := "http"
-	if ctx.Request().TLS != nil {
+	if r.TLS != nil {
 		scheme = "https"
 	}
 
+	// unique per subdomains and paths with different url query.
+	key := scheme + "://" + ctx.Host() + r.URL.RequestURI()
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodOptions, http.MethodTrace:
+		bodyHandler(ctx)
+		return
+	default:
+		// invalidate the stored response of an unsafe request's URI (RFC 9111 section 4.4).
+		bodyHandler(ctx)
+		if status := ctx.GetStatusCode(); status >= 200 && status < 400 {
+			h.purger.purgeKey(key)
+			h.store.Delete(key)
+		}
+		return
+	}
+
 	var (
-		response *entry.Response
-		valid    = false
-		// unique per subdomains and paths with different url query.
-		key = scheme + ctx.Host() + ctx.Request().URL.RequestURI()
+		requestCC  = entry.ParseCacheControl(r.Header)
+		authorized = r.Header.Get("Authorization") != ""
+		// the stale response to serve if the handler fails.
+		stale *entry.Entry
 	)
 
-	h.mu.RLock()
-	e, found := h.entries[key]
-	h.mu.RUnlock()
+	if r.Context().Value(revalidationKey{}) == nil && !requestCC.Has("no-cache") {
+		if e, found := h.lookup(key, r); found {
+			response, staleness := e.Stale()
+			cc := entry.ParseCacheControl(response.Headers())
+			maxAge, hasMaxAge := requestCC.Duration("max-age")
 
-	if found {
-		// the entry is here, .Response will give us
-		// if it's expired or no
-		response, valid = e.Response()
-	} else {
-		// create the entry now.
-		// fmt.Printf("create new cache entry\n")
-		// fmt.Printf("key: %s\n", key)
-
-		e = entry.NewEntry(h.expiration)
-		h.mu.Lock()
-		h.entries[key] = e
-		h.mu.Unlock()
-	}
-
-	if !valid {
-		// if it's expired, then execute the original handler
-		// with our custom response recorder response writer
-		// because the net/http doesn't give us
-		// a builtin way to get the status code & body
-		recorder := ctx.Recorder()
-		bodyHandler(ctx)
+			if (!authorized || shareable(cc)) && (!hasMaxAge || e.Age() <= maxAge) {
+				if staleness == 0 {
+					write(ctx, e, response, true)
+					return
+				}
 
-		// now that we have recordered the response,
-		// we are ready to check if that specific response is valid to be stored.
+				if canServeStale(cc) {
+					if d, ok := cc.Duration("stale-while-revalidate"); ok && staleness <= d {
+						h.revalidate(ctx, key)
+						write(ctx, e, response, true)
+						return
+					}
 
-		// check if it's a valid response, if it's not then just return.
-		if !h.rule.Valid(ctx) {
-			return
+					if d, ok := cc.Duration("stale-if-error"); ok && staleness <= d {
+						stale = e
+					}
+				}
+			}
 		}
+	}
+
+	// if it's expired, then execute the original handler
+	// with our custom response recorder response writer
+	// because the net/http doesn't give us
+	// a builtin way to get the status code & body
+	recorder := ctx.Recorder()
+	execute := func(ctx context.Context) {
+		bodyHandler(ctx)
 
-		// no need to copy the body, its already done inside
-		body := recorder.Body()
-		if len(body) == 0 {
-			// if no body then just exit.
+		if stale != nil && recorder.StatusCode() >= 500 {
+			// "stale-if-error", replace the failed response with the stale one.
+			recorder.ClearHeaders()
+			recorder.ResetBody()
+			response, _ := stale.Stale()
+			write(ctx, stale, response, false)
 			return
 		}
 
-		// check for an expiration time if the
-		// given expiration was not valid then check for GetMaxAge &
-		// update the response & release the recorder
-		e.Reset(
-			recorder.StatusCode(),
-			recorder.Header(),
-			body,
-			parseLifeChanger(ctx),
-		)
-
-		// fmt.Printf("reset cache entry\n")
-		// fmt.Printf("key: %s\n", key)
-		// fmt.Printf("content type: %s\n", recorder.Header().Get(cfg.ContentTypeHeader))
-		// fmt.Printf("body len: %d\n", len(body))
+		h.save(ctx, key, requestCC.Has("no-store"), authorized)
+	}
+
+	if h.group != nil {
+		// identical requests wait for the response of the first one.
+		h.group.Do(ctx, r.Method+" "+key+" "+r.Header.Get("Authorization"), execute)
+	} else {
+		execute(ctx)
+	}
+
+	if recorder.StatusCode() == http.StatusOK && notModified(ctx, recorder.Header()) {
+		recorder.ResetBody()
+		ctx.WriteNotModified()
+	}
+}
+
+// save stores the recorded response of the "ctx" under the "key", if it's allowed to.
+func (h *Handler) save(ctx context.Context, key string, noStore, authorized bool) {
+	r := ctx.Request()
+	recorder := ctx.Recorder()
+
+	// now that we have recordered the response,
+	// we are ready to check if that specific response is valid to be stored.
+
+	// check if it's a valid response, if it's not then just return.
+	if r.Method != http.MethodGet || noStore || !h.rule.Valid(ctx) {
 		return
 	}
 
-	// if it's valid then just write the cached results
-	entry.CopyHeaders(ctx.ResponseWriter().Header(), response.Headers())
-	ctx.SetLastModified(e.LastModified)
-	ctx.StatusCode(response.StatusCode())
-	ctx.Write(response.Body())
+	// no need to copy the body, its already done inside
+	body := recorder.Body()
+	if len(body) == 0 {
+		// if no body then just exit.
+		return
+	}
+
+	header := recorder.Header()
+	lifetime, staleLifetime, ok := h.storeLifetime(recorder.StatusCode(), header, authorized)
+	if !ok {
+		return
+	}
+
+	if header.Get(context.ETagHeaderKey) == "" {
+		header.Set(context.ETagHeaderKey, context.ComputeETag(body, false))
+	}
+
+	e := entry.NewEntry(lifetime)
+	e.Reset(recorder.StatusCode(), header, body, nil)
+	// keep it for the stale period too.
+	keep := time.Until(e.ExpiresAt()) + staleLifetime
 
-	// fmt.Printf("key: %s\n", key)
-	// fmt.Printf("write content type: %s\n", response.Headers()["ContentType"])
-	// fmt.Printf("write body len: %d\n", len(response.Body()))
+	primaryKey := key
+	if names := varyNames(header); len(names) > 0 {
+		// keep the "Vary" on the primary key, see `lookup`.
+		index := entry.NewEntry(lifetime)
+		index.Reset(0, http.Header{context.VaryHeaderKey: header[context.VaryHeaderKey]}, nil, nil)
+		h.setEntry(primaryKey, index, keep)
+		key = variantKey(primaryKey, names, r.Header)
+	}
+
+	h.setEntry(key, e, keep)
+
+	var routeName string
+	if route := ctx.GetCurrentRoute(); route != nil {
+		routeName = route.Name()
+	}
+	h.purger.add(h.store, primaryKey, key, getTags(ctx), routeName, r.URL.Path, time.Now().Add(keep))
 }
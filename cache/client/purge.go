@@ -0,0 +1,258 @@
+package client
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/radiantrfid/iris/cache/store"
+	"github.com/radiantrfid/iris/context"
+
+	"github.com/kataras/golog"
+)
+
+const tagsContextKey = "iris.cache.tags"
+
+// Tag tags the response of the current request,
+// if it's stored it can be removed before its expiration by any of its "tags",
+// see `Purger.PurgeTag`.
+func Tag(ctx context.Context, tags ...string) {
+	existing, _ := ctx.Values().Get(tagsContextKey).([]string)
+	ctx.Values().Set(tagsContextKey, append(existing, tags...))
+}
+
+func getTags(ctx context.Context) []string {
+	tags, _ := ctx.Values().Get(tagsContextKey).([]string)
+	return tags
+}
+
+// the message prefixes of the purges which are sent through a `store.Broker`.
+const (
+	purgeKeyMessage    = "key:"
+	purgeTagMessage    = "tag:"
+	purgeRouteMessage  = "route:"
+	purgePrefixMessage = "prefix:"
+)
+
+// purgeRecord keeps the stored keys of a request's response
+// and the information that can be used to purge them.
+type purgeRecord struct {
+	store     store.Store
+	keys      map[string]struct{} // the primary and the variants' keys.
+	tags      []string
+	route     string
+	path      string
+	expiresAt time.Time
+}
+
+func (r *purgeRecord) hasTag(tag string) bool {
+	for _, t := range r.tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Purger keeps an index of the stored responses of the handlers which use it,
+// by their keys, tags, route names and paths,
+// in order to remove them before their expiration.
+//
+// The index lives in memory, each instance of an application purges the responses it stored,
+// use a `Broker` to propagate the purges to all the instances.
+type Purger struct {
+	mu      sync.Mutex
+	records map[string]*purgeRecord // by primary key.
+	pruneAt int
+
+	broker      store.Broker
+	unsubscribe func()
+}
+
+// DefaultPurger is the `Purger` of the cache handlers, unless `Handler.Purger` is used.
+var DefaultPurger = NewPurger()
+
+// NewPurger returns a new `Purger`, see `Handler.Purger`.
+func NewPurger() *Purger {
+	return &Purger{
+		records: make(map[string]*purgeRecord),
+		pruneAt: 1024,
+	}
+}
+
+// Broker sets the "b" `store.Broker` which propagates the purges
+// to all the instances of the application, i.e `redis.New(...).CacheBroker()`.
+// The purges that are published by the rest of the instances are applied to this one too.
+func (p *Purger) Broker(b store.Broker) error {
+	unsubscribe, err := b.Subscribe(p.receive)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	if p.unsubscribe != nil {
+		p.unsubscribe()
+	}
+	p.broker = b
+	p.unsubscribe = unsubscribe
+	p.mu.Unlock()
+	return nil
+}
+
+// Close unsubscribes from its `Broker`, if any.
+func (p *Purger) Close() {
+	p.mu.Lock()
+	if p.unsubscribe != nil {
+		p.unsubscribe()
+		p.unsubscribe = nil
+	}
+	p.broker = nil
+	p.mu.Unlock()
+}
+
+// add indexes the "key" of the "s" store
+// which is the "primaryKey" itself or one of its variants.
+func (p *Purger) add(s store.Store, primaryKey, key string, tags []string, route, path string, expiresAt time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r, ok := p.records[primaryKey]
+	if !ok || r.store != s {
+		r = &purgeRecord{store: s, keys: make(map[string]struct{})}
+		p.records[primaryKey] = r
+	}
+
+	r.keys[primaryKey] = struct{}{}
+	r.keys[key] = struct{}{}
+	for _, tag := range tags {
+		if !r.hasTag(tag) {
+			r.tags = append(r.tags, tag)
+		}
+	}
+	r.route = route
+	r.path = path
+	if expiresAt.After(r.expiresAt) {
+		r.expiresAt = expiresAt
+	}
+
+	if len(p.records) >= p.pruneAt {
+		p.prune()
+	}
+}
+
+// prune removes the records of the expired responses.
+func (p *Purger) prune() {
+	now := time.Now()
+	for key, r := range p.records {
+		if now.After(r.expiresAt) {
+			delete(p.records, key)
+		}
+	}
+
+	p.pruneAt = 2*len(p.records) + 1024
+}
+
+// remove removes the stored responses of the records which "match",
+// returns the number of the removed responses, without their variants.
+func (p *Purger) remove(match func(primaryKey string, r *purgeRecord) bool) int {
+	var removed []*purgeRecord
+
+	p.mu.Lock()
+	for primaryKey, r := range p.records {
+		if match(primaryKey, r) {
+			removed = append(removed, r)
+			delete(p.records, primaryKey)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, r := range removed {
+		for key := range r.keys {
+			r.store.Delete(key)
+		}
+	}
+
+	return len(removed)
+}
+
+func (p *Purger) publish(message string) {
+	p.mu.Lock()
+	broker := p.broker
+	p.mu.Unlock()
+
+	if broker == nil {
+		return
+	}
+
+	if err := broker.Publish(message); err != nil {
+		golog.Debugf("cache: unable to publish the purge '%s': %v", message, err)
+	}
+}
+
+func (p *Purger) receive(message string) {
+	switch {
+	case strings.HasPrefix(message, purgeKeyMessage):
+		p.purgeKey(message[len(purgeKeyMessage):])
+	case strings.HasPrefix(message, purgeTagMessage):
+		p.purgeTag(message[len(purgeTagMessage):])
+	case strings.HasPrefix(message, purgeRouteMessage):
+		p.purgeRoute(message[len(purgeRouteMessage):])
+	case strings.HasPrefix(message, purgePrefixMessage):
+		p.purgePrefix(message[len(purgePrefixMessage):])
+	}
+}
+
+// PurgeKey removes the stored response of the "key", including its variants.
+// The key of a response is the absolute url of its request, i.e "https://example.com/users?page=2".
+// It returns the number of the removed responses of this instance.
+func (p *Purger) PurgeKey(key string) int {
+	p.publish(purgeKeyMessage + key)
+	return p.purgeKey(key)
+}
+
+func (p *Purger) purgeKey(key string) int {
+	return p.remove(func(primaryKey string, _ *purgeRecord) bool {
+		return primaryKey == key
+	})
+}
+
+// PurgeTag removes the stored responses which are tagged with the "tag", see `Tag`.
+// It returns the number of the removed responses of this instance.
+func (p *Purger) PurgeTag(tag string) int {
+	p.publish(purgeTagMessage + tag)
+	return p.purgeTag(tag)
+}
+
+func (p *Purger) purgeTag(tag string) int {
+	return p.remove(func(_ string, r *purgeRecord) bool {
+		return r.hasTag(tag)
+	})
+}
+
+// PurgeRoute removes the stored responses of the route with the "routeName".
+// It returns the number of the removed responses of this instance.
+func (p *Purger) PurgeRoute(routeName string) int {
+	p.publish(purgeRouteMessage + routeName)
+	return p.purgeRoute(routeName)
+}
+
+func (p *Purger) purgeRoute(routeName string) int {
+	return p.remove(func(_ string, r *purgeRecord) bool {
+		return r.route == routeName
+	})
+}
+
+// PurgePrefix removes the stored responses of the request paths which start with the "pathPrefix",
+// i.e "/users" removes the "/users", "/users/42" and "/users?page=2".
+// It returns the number of the removed responses of this instance.
+func (p *Purger) PurgePrefix(pathPrefix string) int {
+	p.publish(purgePrefixMessage + pathPrefix)
+	return p.purgePrefix(pathPrefix)
+}
+
+func (p *Purger) purgePrefix(pathPrefix string) int {
+	return p.remove(func(_ string, r *purgeRecord) bool {
+		return strings.HasPrefix(r.path, pathPrefix)
+	})
+}
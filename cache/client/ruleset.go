@@ -9,11 +9,13 @@ import (
 
 // DefaultRuleSet is a list of the default pre-cache validators
 // which exists in ALL handlers, local and remote.
+//
+// Note that the requests with an Authorization header field are not skipped,
+// a shared cache can use and store their responses only if they are explicitly
+// allowed to, that's checked by the `Handler` itself. Add the `ruleset.AuthorizationRule`
+// to skip them completely.
 var DefaultRuleSet = rule.Chained(
-	// #1 A shared cache MUST NOT use a cached response to a request with an
-	// Authorization header field
-	rule.HeaderClaim(ruleset.AuthorizationRule),
-	// #2 "must-revalidate" and/or
+	// #1 "must-revalidate" and/or
 	// "s-maxage" response directives are not allowed to be served stale
 	// (Section 4.2.4) by shared caches.  In particular, a response with
 	// either "max-age=0, must-revalidate" or "s-maxage=0" cannot be used to
@@ -21,7 +23,7 @@ var DefaultRuleSet = rule.Chained(
 	// server.
 	rule.HeaderClaim(ruleset.MustRevalidateRule),
 	rule.HeaderClaim(ruleset.ZeroMaxAgeRule),
-	// #3 custom No-Cache header used inside this library
+	// #2 custom No-Cache header used inside this library
 	// for BOTH request and response (after get-cache action)
 	rule.Header(ruleset.NoCacheRule, ruleset.NoCacheRule),
 )
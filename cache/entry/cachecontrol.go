@@ -0,0 +1,98 @@
+package entry
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheControl is a set of parsed "Cache-Control" directives,
+// the keys are the lowercase directive names and the values are their arguments, if any.
+type CacheControl map[string]string
+
+// ParseCacheControl parses the "Cache-Control" header(s) of the "header".
+//
+// The field-names of the qualified "private" and "no-cache" forms are dropped,
+// so they are treated as their unqualified, stricter versions.
+func ParseCacheControl(header http.Header) CacheControl {
+	cc := make(CacheControl)
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+
+			name, arg := directive, ""
+			if idx := strings.IndexByte(directive, '='); idx > 0 {
+				name, arg = directive[:idx], strings.Trim(strings.TrimSpace(directive[idx+1:]), `"`)
+			}
+
+			name = strings.ToLower(strings.TrimSpace(name))
+			if _, exists := cc[name]; !exists {
+				cc[name] = arg
+			}
+		}
+	}
+
+	return cc
+}
+
+// Has reports whether the "directive" exists.
+func (cc CacheControl) Has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// Duration returns the delta-seconds argument of the "directive", i.e "max-age",
+// found is false if the directive is missing or its argument is not a valid number.
+func (cc CacheControl) Duration(directive string) (time.Duration, bool) {
+	arg, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+// Freshness returns the freshness lifetime of a response which is stored by a shared cache,
+// the "s-maxage" directive takes precedence over the "max-age"
+// and the "max-age" over the "Expires" header.
+// Found is false if the response does not define an explicit lifetime.
+func Freshness(header http.Header) (time.Duration, bool) {
+	cc := ParseCacheControl(header)
+	if d, ok := cc.Duration("s-maxage"); ok {
+		return d, true
+	}
+
+	if d, ok := cc.Duration("max-age"); ok {
+		return d, true
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			// an invalid date, i.e "0", represents a time in the past.
+			return 0, true
+		}
+
+		date := time.Now()
+		if d, err := http.ParseTime(header.Get("Date")); err == nil {
+			date = d
+		}
+
+		if lifetime := t.Sub(date); lifetime > 0 {
+			return lifetime, true
+		}
+
+		return 0, true
+	}
+
+	return 0, false
+}
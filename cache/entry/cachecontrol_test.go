@@ -0,0 +1,44 @@
+package entry
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	header := http.Header{"Cache-Control": []string{`Public, max-age=60`, `private="Set-Cookie", s-maxage="120"`}}
+	cc := ParseCacheControl(header)
+
+	for _, directive := range []string{"public", "private", "max-age", "s-maxage"} {
+		if !cc.Has(directive) {
+			t.Fatalf("expected the %q directive to be parsed", directive)
+		}
+	}
+
+	if d, ok := cc.Duration("s-maxage"); !ok || d != 2*time.Minute {
+		t.Fatalf("expected s-maxage to be 2m but got: %s", d)
+	}
+
+	if d, ok := Freshness(header); !ok || d != 2*time.Minute {
+		t.Fatalf("expected the s-maxage to take precedence but got: %s", d)
+	}
+
+	now := time.Now().UTC()
+	header = http.Header{
+		"Date":    []string{now.Format(http.TimeFormat)},
+		"Expires": []string{now.Add(time.Hour).Format(http.TimeFormat)},
+	}
+	if d, ok := Freshness(header); !ok || d != time.Hour {
+		t.Fatalf("expected the lifetime of the Expires header to be 1h but got: %s", d)
+	}
+
+	header = http.Header{"Expires": []string{"0"}}
+	if d, ok := Freshness(header); !ok || d != 0 {
+		t.Fatalf("expected an invalid Expires header to be already expired but got: %s", d)
+	}
+
+	if _, ok := Freshness(http.Header{}); ok {
+		t.Fatalf("expected no explicit lifetime")
+	}
+}
@@ -0,0 +1,170 @@
+package entry
+
+import (
+	"encoding/binary"
+	"net/http"
+	"time"
+
+	"github.com/radiantrfid/iris/core/errors"
+)
+
+// encodingVersion is the first byte of an encoded entry.
+const encodingVersion byte = 1
+
+var errInvalidEncoding = errors.New("cache: invalid entry encoding")
+
+// MarshalBinary encodes the entry, its response and its expiration,
+// it's used to save the entry to a `store.Store`.
+func (e *Entry) MarshalBinary() ([]byte, error) {
+	r := e.response
+	if r == nil {
+		r = &Response{}
+	}
+
+	size := 1 + 3*binary.MaxVarintLen64 + 2*binary.MaxVarintLen64 + len(r.body) + binary.MaxVarintLen64
+	for k, vv := range r.headers {
+		size += binary.MaxVarintLen64 + len(k)
+		for _, v := range vv {
+			size += binary.MaxVarintLen64 + len(v)
+		}
+	}
+
+	b := make([]byte, 0, size)
+	b = append(b, encodingVersion)
+	b = appendVarint(b, int64(e.life))
+	b = appendTime(b, e.expiresAt)
+	b = appendTime(b, e.LastModified)
+	b = appendVarint(b, int64(r.statusCode))
+
+	b = appendVarint(b, int64(len(r.headers)))
+	for k, vv := range r.headers {
+		b = appendString(b, k)
+		b = appendVarint(b, int64(len(vv)))
+		for _, v := range vv {
+			b = appendString(b, v)
+		}
+	}
+
+	return append(b, r.body...), nil
+}
+
+// UnmarshalBinary decodes an entry which is encoded by the `MarshalBinary`.
+func (e *Entry) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != encodingVersion {
+		return errInvalidEncoding
+	}
+
+	d := decoder{b: data[1:]}
+	life := d.varint()
+	expiresAt := d.time()
+	lastModified := d.time()
+	statusCode := d.varint()
+
+	n := d.varint()
+	if n < 0 || n > int64(len(d.b)) {
+		return errInvalidEncoding
+	}
+
+	var headers http.Header
+	if n > 0 {
+		headers = make(http.Header, n)
+	}
+
+	for i := int64(0); i < n && d.err == nil; i++ {
+		k := d.string()
+		m := d.varint()
+		if m < 0 || m > int64(len(d.b)) {
+			return errInvalidEncoding
+		}
+
+		vv := make([]string, 0, m)
+		for j := int64(0); j < m; j++ {
+			vv = append(vv, d.string())
+		}
+		headers[k] = vv
+	}
+
+	if d.err != nil {
+		return d.err
+	}
+
+	e.life = time.Duration(life)
+	e.expiresAt = expiresAt
+	e.LastModified = lastModified
+	e.response = &Response{
+		statusCode: int(statusCode),
+		headers:    headers,
+		body:       d.b,
+	}
+
+	return nil
+}
+
+// ExpiresAt returns the time that the entry's response expires.
+func (e *Entry) ExpiresAt() time.Time {
+	return e.expiresAt
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func appendTime(b []byte, t time.Time) []byte {
+	if t.IsZero() {
+		return appendVarint(b, 0)
+	}
+
+	return appendVarint(b, t.UnixNano())
+}
+
+func appendString(b []byte, s string) []byte {
+	b = appendVarint(b, int64(len(s)))
+	return append(b, s...)
+}
+
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.err = errInvalidEncoding
+		return 0
+	}
+
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) time() time.Time {
+	v := d.varint()
+	if v == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, v)
+}
+
+func (d *decoder) string() string {
+	n := d.varint()
+	if d.err != nil {
+		return ""
+	}
+
+	if n < 0 || n > int64(len(d.b)) {
+		d.err = errInvalidEncoding
+		return ""
+	}
+
+	s := string(d.b[:n])
+	d.b = d.b[n:]
+	return s
+}
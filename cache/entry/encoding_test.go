@@ -0,0 +1,46 @@
+package entry
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEntryEncoding(t *testing.T) {
+	e := NewEntry(time.Minute)
+	e.Reset(http.StatusCreated, http.Header{"Content-Type": {"text/plain"}, "X-Multi": {"a", "b"}}, []byte("body"), nil)
+
+	data, err := e.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := new(Entry)
+	if err = got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	response, valid := got.Response()
+	if !valid {
+		t.Fatalf("expected a valid response")
+	}
+
+	if response.StatusCode() != http.StatusCreated || !bytes.Equal(response.Body(), []byte("body")) {
+		t.Fatalf("unexpected response: %d %q", response.StatusCode(), response.Body())
+	}
+
+	if h := response.Headers(); h.Get("Content-Type") != "text/plain" || len(h["X-Multi"]) != 2 {
+		t.Fatalf("unexpected headers: %v", h)
+	}
+
+	if !got.ExpiresAt().Equal(e.ExpiresAt()) || !got.LastModified.Equal(e.LastModified) {
+		t.Fatalf("expected the same times")
+	}
+
+	for _, invalid := range [][]byte{nil, {0}, data[:5]} {
+		if err = new(Entry).UnmarshalBinary(invalid); err == nil {
+			t.Fatalf("expected an error for %v", invalid)
+		}
+	}
+}
@@ -52,6 +52,23 @@ func (e *Entry) Response() (*Response, bool) {
 	return e.response, true
 }
 
+// Stale returns the response contents even if it's expired
+// and for how long it's expired, zero if it's still valid.
+// It's used to serve stale responses, i.e on "stale-while-revalidate".
+func (e *Entry) Stale() (*Response, time.Duration) {
+	staleness := time.Since(e.expiresAt)
+	if staleness < 0 {
+		staleness = 0
+	}
+
+	return e.response, staleness
+}
+
+// Age returns the time passed since the response was saved, see `Reset`.
+func (e *Entry) Age() time.Duration {
+	return time.Since(e.LastModified)
+}
+
 // valid returns true if this entry's response is still valid
 // or false if the expiration time passed
 func (e *Entry) valid() bool {
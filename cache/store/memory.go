@@ -0,0 +1,325 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSize is the default maximum size, in bytes, of the in-memory stores
+// which is used when the given size is zero, 64MB.
+var DefaultMaxSize int64 = 64 << 20
+
+type memoryItem struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+	freq      int // LFU only.
+}
+
+func (item *memoryItem) size() int64 {
+	return int64(len(item.key) + len(item.value))
+}
+
+// LRU is an in-memory `Store` which, when its maximum size is reached,
+// removes the least recently used entries first.
+type LRU struct {
+	maxSize int64
+
+	mu    sync.Mutex
+	size  int64
+	ll    *list.List // front is the most recently used.
+	items map[string]*list.Element
+}
+
+var (
+	_ Store   = (*LRU)(nil)
+	_ Sweeper = (*LRU)(nil)
+)
+
+// NewLRU returns a new in-memory least recently used `Store`,
+// the "maxSize" is the total size, in bytes, of its keys and values.
+// If zero then the `DefaultMaxSize` is used, negative means unbounded.
+func NewLRU(maxSize int64) *LRU {
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	return &LRU{
+		maxSize: maxSize,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value of the "key", if it's not expired.
+func (s *LRU) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := elem.Value.(*memoryItem)
+	if Expired(item.expiresAt) {
+		s.remove(elem)
+		return nil, false
+	}
+
+	s.ll.MoveToFront(elem)
+	return item.value, true
+}
+
+// Set stores the "value" under the "key" for "lifetime",
+// the least recently used entries are removed if the maximum size is reached.
+func (s *LRU) Set(key string, value []byte, lifetime time.Duration) {
+	item := &memoryItem{key: key, value: value, expiresAt: Expiration(lifetime)}
+	if s.maxSize > 0 && item.size() > s.maxSize {
+		// it would remove everything else and still not fit.
+		s.Delete(key)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+
+	s.items[key] = s.ll.PushFront(item)
+	s.size += item.size()
+
+	for s.maxSize > 0 && s.size > s.maxSize {
+		s.remove(s.ll.Back())
+	}
+}
+
+// Delete removes the "key".
+func (s *LRU) Delete(key string) {
+	s.mu.Lock()
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+	s.mu.Unlock()
+}
+
+// Sweep removes the expired entries.
+func (s *LRU) Sweep() {
+	s.mu.Lock()
+	for _, elem := range s.items {
+		if Expired(elem.Value.(*memoryItem).expiresAt) {
+			s.remove(elem)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// Len returns the number of the stored entries, including the expired ones which are not swept yet.
+func (s *LRU) Len() int {
+	s.mu.Lock()
+	n := len(s.items)
+	s.mu.Unlock()
+	return n
+}
+
+// Size returns the total size, in bytes, of the stored keys and values.
+func (s *LRU) Size() int64 {
+	s.mu.Lock()
+	size := s.size
+	s.mu.Unlock()
+	return size
+}
+
+func (s *LRU) remove(elem *list.Element) {
+	item := s.ll.Remove(elem).(*memoryItem)
+	delete(s.items, item.key)
+	s.size -= item.size()
+}
+
+// LFU is an in-memory `Store` which, when its maximum size is reached,
+// removes the least frequently used entries first,
+// the least recently used one of them on ties.
+type LFU struct {
+	maxSize int64
+
+	mu      sync.Mutex
+	size    int64
+	items   map[string]*list.Element
+	freqs   map[int]*list.List // frequency:items, front is the most recently used.
+	minFreq int
+}
+
+var (
+	_ Store   = (*LFU)(nil)
+	_ Sweeper = (*LFU)(nil)
+)
+
+// NewLFU returns a new in-memory least frequently used `Store`,
+// the "maxSize" is the total size, in bytes, of its keys and values.
+// If zero then the `DefaultMaxSize` is used, negative means unbounded.
+func NewLFU(maxSize int64) *LFU {
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	return &LFU{
+		maxSize: maxSize,
+		items:   make(map[string]*list.Element),
+		freqs:   make(map[int]*list.List),
+	}
+}
+
+// Get returns the value of the "key", if it's not expired.
+func (s *LFU) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := elem.Value.(*memoryItem)
+	if Expired(item.expiresAt) {
+		s.remove(elem)
+		return nil, false
+	}
+
+	s.touch(elem)
+	return item.value, true
+}
+
+// Set stores the "value" under the "key" for "lifetime",
+// the least frequently used entries are removed if the maximum size is reached.
+// Replacing a value keeps the frequency of its key.
+func (s *LFU) Set(key string, value []byte, lifetime time.Duration) {
+	item := &memoryItem{key: key, value: value, expiresAt: Expiration(lifetime), freq: 1}
+	if s.maxSize > 0 && item.size() > s.maxSize {
+		s.Delete(key)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		old := elem.Value.(*memoryItem)
+		s.size += item.size() - old.size()
+		old.value, old.expiresAt = item.value, item.expiresAt
+		s.touch(elem)
+	} else {
+		// evict before the insert, so the new entry is not the least frequently used one.
+		for s.maxSize > 0 && s.size+item.size() > s.maxSize && len(s.items) > 0 {
+			s.evict()
+		}
+
+		s.items[key] = s.frequency(1).PushFront(item)
+		s.size += item.size()
+		s.minFreq = 1
+	}
+
+	for s.maxSize > 0 && s.size > s.maxSize && len(s.items) > 1 {
+		s.evict()
+	}
+}
+
+// Delete removes the "key".
+func (s *LFU) Delete(key string) {
+	s.mu.Lock()
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+	s.mu.Unlock()
+}
+
+// Sweep removes the expired entries.
+func (s *LFU) Sweep() {
+	s.mu.Lock()
+	for _, elem := range s.items {
+		if Expired(elem.Value.(*memoryItem).expiresAt) {
+			s.remove(elem)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// Len returns the number of the stored entries, including the expired ones which are not swept yet.
+func (s *LFU) Len() int {
+	s.mu.Lock()
+	n := len(s.items)
+	s.mu.Unlock()
+	return n
+}
+
+// Size returns the total size, in bytes, of the stored keys and values.
+func (s *LFU) Size() int64 {
+	s.mu.Lock()
+	size := s.size
+	s.mu.Unlock()
+	return size
+}
+
+func (s *LFU) frequency(freq int) *list.List {
+	l, ok := s.freqs[freq]
+	if !ok {
+		l = list.New()
+		s.freqs[freq] = l
+	}
+
+	return l
+}
+
+// touch moves the item of the "elem" to the next frequency.
+func (s *LFU) touch(elem *list.Element) {
+	item := elem.Value.(*memoryItem)
+	l := s.freqs[item.freq]
+	l.Remove(elem)
+	if l.Len() == 0 {
+		delete(s.freqs, item.freq)
+		if s.minFreq == item.freq {
+			s.minFreq++
+		}
+	}
+
+	item.freq++
+	s.items[item.key] = s.frequency(item.freq).PushFront(item)
+}
+
+func (s *LFU) evict() {
+	l, ok := s.freqs[s.minFreq]
+	if !ok {
+		s.resetMinFreq()
+		if l, ok = s.freqs[s.minFreq]; !ok {
+			return
+		}
+	}
+
+	s.remove(l.Back())
+}
+
+func (s *LFU) remove(elem *list.Element) {
+	item := elem.Value.(*memoryItem)
+	l := s.freqs[item.freq]
+	l.Remove(elem)
+	if l.Len() == 0 {
+		delete(s.freqs, item.freq)
+		if s.minFreq == item.freq {
+			s.resetMinFreq()
+		}
+	}
+
+	delete(s.items, item.key)
+	s.size -= item.size()
+}
+
+func (s *LFU) resetMinFreq() {
+	s.minFreq = 0
+	for freq := range s.freqs {
+		if s.minFreq == 0 || freq < s.minFreq {
+			s.minFreq = freq
+		}
+	}
+}
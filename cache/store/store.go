@@ -0,0 +1,92 @@
+// Package store provides the storage backends of the cache handler,
+// a size-bounded in-memory LRU and LFU `Store` lives here,
+// the persistent ones can be created through the boltdb, badger and redis session databases,
+// i.e `redis.New(...).CacheStore()`, which can be shared across replicas.
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+// Store is the interface which all cache storage backends should implement.
+// Errors are logged by the implementations, a failed `Get` is a cache miss.
+type Store interface {
+	// Get returns the value of the "key",
+	// found is false if it does not exist or it's expired.
+	Get(key string) (value []byte, found bool)
+	// Set stores the "value" under the "key" for "lifetime",
+	// zero or negative lifetime means that it never expires.
+	Set(key string, value []byte, lifetime time.Duration)
+	// Delete removes the "key".
+	Delete(key string)
+}
+
+// Sweeper is implemented by the stores which
+// can't remove their expired entries on their own, see `Sweep`.
+type Sweeper interface {
+	// Sweep removes the expired entries.
+	Sweep()
+}
+
+// Sweep removes the expired entries of the "s" every "interval",
+// if the "s" is a `Sweeper`, otherwise it does nothing.
+// It returns a function which stops the sweeper.
+func Sweep(s Store, interval time.Duration) (stop func()) {
+	sweeper, ok := s.(Sweeper)
+	if !ok {
+		return func() {}
+	}
+
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				sweeper.Sweep()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// Expiration returns the expiration time of an entry which
+// is stored for "lifetime", zero time if it never expires.
+func Expiration(lifetime time.Duration) time.Time {
+	if lifetime <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(lifetime)
+}
+
+// Expired reports whether the "expiresAt" is passed, zero time never expires.
+func Expired(expiresAt time.Time) bool {
+	return !expiresAt.IsZero() && time.Now().After(expiresAt)
+}
+
+// Broker propagates messages to all the instances which are subscribed to it,
+// it's used to purge the cached responses of all the replicas of an application,
+// i.e `redis.New(...).CacheBroker()`.
+type Broker interface {
+	// Publish sends the "message" to all the subscribers, including this instance.
+	Publish(message string) error
+	// Subscribe calls the "handler" on each published message,
+	// until the returned "unsubscribe" function is called.
+	Subscribe(handler func(message string)) (unsubscribe func(), err error)
+}
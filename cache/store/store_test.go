@@ -0,0 +1,102 @@
+package store
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	s := NewLRU(30) // 3 entries of 10 bytes.
+	for i := 0; i < 3; i++ {
+		s.Set("key"+strconv.Itoa(i), []byte("value0"), 0)
+	}
+
+	// use the oldest, so "key1" is the least recently used now.
+	if _, ok := s.Get("key0"); !ok {
+		t.Fatalf("expected key0 to exist")
+	}
+
+	s.Set("key3", []byte("value0"), 0)
+	if _, ok := s.Get("key1"); ok {
+		t.Fatalf("expected the least recently used key1 to be removed")
+	}
+
+	for _, key := range []string{"key0", "key2", "key3"} {
+		if _, ok := s.Get(key); !ok {
+			t.Fatalf("expected %s to exist", key)
+		}
+	}
+
+	if expected, got := int64(30), s.Size(); expected != got {
+		t.Fatalf("expected size %d but got %d", expected, got)
+	}
+
+	// too big to fit.
+	s.Set("key4", make([]byte, 100), 0)
+	if _, ok := s.Get("key4"); ok || s.Len() != 3 {
+		t.Fatalf("expected a value bigger than the maximum size to be ignored")
+	}
+}
+
+func TestLFU(t *testing.T) {
+	s := NewLFU(30)
+	for i := 0; i < 3; i++ {
+		s.Set("key"+strconv.Itoa(i), []byte("value0"), 0)
+	}
+
+	// key0: 3 uses, key1: 1, key2: 2.
+	s.Get("key0")
+	s.Get("key0")
+	s.Get("key2")
+
+	s.Set("key3", []byte("value0"), 0)
+	if _, ok := s.Get("key1"); ok {
+		t.Fatalf("expected the least frequently used key1 to be removed")
+	}
+
+	// key3 is the least frequently used now.
+	s.Set("key4", []byte("value0"), 0)
+	if _, ok := s.Get("key3"); ok {
+		t.Fatalf("expected the least frequently used key3 to be removed")
+	}
+
+	for _, key := range []string{"key0", "key2", "key4"} {
+		if _, ok := s.Get(key); !ok {
+			t.Fatalf("expected %s to exist", key)
+		}
+	}
+
+	s.Delete("key0")
+	if s.Len() != 2 || s.Size() != 20 {
+		t.Fatalf("expected 2 entries of 20 bytes but got %d of %d", s.Len(), s.Size())
+	}
+}
+
+func TestSweep(t *testing.T) {
+	for _, s := range []interface {
+		Store
+		Len() int
+	}{NewLRU(-1), NewLFU(-1)} {
+		s.Set("expired", []byte("value"), time.Millisecond)
+		s.Set("valid", []byte("value"), time.Hour)
+		s.Set("forever", []byte("value"), 0)
+
+		time.Sleep(5 * time.Millisecond)
+		if _, ok := s.Get("expired"); ok {
+			t.Fatalf("%T: expected the expired entry to not be returned", s)
+		}
+
+		s.Set("expired", []byte("value"), time.Millisecond)
+		stop := Sweep(s, time.Millisecond)
+
+		deadline := time.Now().Add(5 * time.Second)
+		for s.Len() != 2 {
+			if time.Now().After(deadline) {
+				t.Fatalf("%T: expected the expired entry to be swept", s)
+			}
+			time.Sleep(time.Millisecond)
+		}
+		stop()
+	}
+}
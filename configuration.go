@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/radiantrfid/iris/context"
 	"github.com/radiantrfid/iris/core/errors"
+	"github.com/radiantrfid/iris/core/netutil"
 )
 
 const globalConfigurationKeyword = "~"
@@ -56,6 +58,8 @@ func homeDir() (home string) {
 
 var errConfigurationDecode = errors.New("error while trying to decode configuration")
 
+var errConfigurationTrustedProxies = errors.New("invalid trusted proxies")
+
 func parseYAML(filename string) (Configuration, error) {
 	c := DefaultConfiguration()
 	// get the abs
@@ -320,6 +324,10 @@ func WithPostMaxMemory(limit int64) Configurator {
 //
 // Defaults to an empty map but an example usage is:
 // WithRemoteAddrHeader("X-Forwarded-For")
+// The RFC 7239 "Forwarded" header is supported as well.
+//
+// Combine it with `WithTrustedProxies` to trust the headers only
+// when the request is received from one of your proxies.
 //
 // Look `context.RemoteAddr()` for more.
 func WithRemoteAddrHeader(headerName string) Configurator {
@@ -349,6 +357,28 @@ func WithoutRemoteAddrHeader(headerName string) Configurator {
 	}
 }
 
+// WithTrustedProxies adds one or more CIDR ranges (or single IPs)
+// of reverse proxies that can be trusted to set the
+// "X-Forwarded-For", "Forwarded", "X-Forwarded-Proto" and "X-Forwarded-Host" request headers.
+//
+// Usage:
+// WithTrustedProxies("10.0.0.0/8", "192.168.1.1")
+//
+// It panics on an invalid CIDR range or IP.
+//
+// See `Configuration.TrustedProxies` for more.
+func WithTrustedProxies(cidrs ...string) Configurator {
+	return func(app *Application) {
+		trustedProxies := append(app.config.TrustedProxies, cidrs...)
+		nets, err := netutil.ParseCIDRs(trustedProxies...)
+		if err != nil {
+			panic(errConfigurationTrustedProxies.AppendErr(err))
+		}
+		app.config.TrustedProxies = trustedProxies
+		app.config.trustedProxies = nets
+	}
+}
+
 // WithOtherValue adds a value based on a key to the Other setting.
 //
 // See `Configuration.Other`.
@@ -749,6 +779,27 @@ type Configuration struct {
 	//
 	// Look `context.RemoteAddr()` for more.
 	RemoteAddrHeaders map[string]bool `json:"remoteAddrHeaders,omitempty" yaml:"RemoteAddrHeaders" toml:"RemoteAddrHeaders"`
+	// TrustedProxies is a list of CIDR ranges, i.e "10.0.0.0/8", or single IPs
+	// of the reverse proxies and load balancers that sit in front of the server.
+	//
+	// When it's not empty the `RemoteAddrHeaders` are respected
+	// only if the request is received from one of these proxies
+	// and the list headers ("X-Forwarded-For" and RFC 7239 "Forwarded")
+	// are read right-to-left, skipping the trusted hops,
+	// the first untrusted address is the client's one.
+	// The "Forwarded" (proto and host parameters), "X-Forwarded-Proto" and "X-Forwarded-Host" headers
+	// are also used by the `context.Scheme()`, `context.AbsoluteURI` and `context.FullRequestURI`.
+	//
+	// Defaults to empty, the headers are trusted as they are and the first
+	// address of the list headers is the client's one, as it used to be.
+	//
+	// They are parsed once, by the `WithTrustedProxies` and the `Application.Build`,
+	// an invalid CIDR range or IP fails the build.
+	//
+	// Look `context.RemoteAddr()` and `context.Scheme()` for more.
+	TrustedProxies []string `json:"trustedProxies,omitempty" yaml:"TrustedProxies" toml:"TrustedProxies"`
+	// the parsed TrustedProxies, see `parseTrustedProxies`.
+	trustedProxies []*net.IPNet
 
 	// Other are the custom, dynamic options, can be empty.
 	// This field used only by you to set any app's options you want.
@@ -892,6 +943,27 @@ func (c Configuration) GetRemoteAddrHeaders() map[string]bool {
 	return c.RemoteAddrHeaders
 }
 
+// GetTrustedProxies returns the parsed Configuration#TrustedProxies,
+// the CIDR ranges of the reverse proxies that can be trusted
+// to set the forwarding request headers.
+//
+// Look `context.RemoteAddr()` and `context.Scheme()` for more.
+func (c Configuration) GetTrustedProxies() []*net.IPNet {
+	return c.trustedProxies
+}
+
+// parseTrustedProxies parses the Configuration#TrustedProxies,
+// it's called by the `Application.Build`.
+func (c *Configuration) parseTrustedProxies() error {
+	nets, err := netutil.ParseCIDRs(c.TrustedProxies...)
+	if err != nil {
+		return errConfigurationTrustedProxies.AppendErr(err)
+	}
+
+	c.trustedProxies = nets
+	return nil
+}
+
 // GetOther returns the Configuration#Other map.
 func (c Configuration) GetOther() map[string]interface{} {
 	return c.Other
@@ -990,6 +1062,10 @@ func WithConfiguration(c Configuration) Configurator {
 			}
 		}
 
+		if v := c.TrustedProxies; len(v) > 0 {
+			main.TrustedProxies = append(main.TrustedProxies, v...)
+		}
+
 		if v := c.Other; len(v) > 0 {
 			if main.Other == nil {
 				main.Other = make(map[string]interface{}, len(v))
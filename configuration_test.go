@@ -2,11 +2,14 @@ package iris
 
 import (
 	"io/ioutil"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/radiantrfid/iris/context"
+
 	"gopkg.in/yaml.v2"
 )
 
@@ -323,3 +326,78 @@ Charset = "UTF-8"
 		t.Fatalf("error on TestConfigurationTOML: Expected Other['MyServerName'] %s but got %s", expected, got)
 	}
 }
+
+func TestConfigurationTrustedProxies(t *testing.T) {
+	app := New()
+	app.Configure(
+		WithRemoteAddrHeader("X-Forwarded-For"),
+		WithRemoteAddrHeader("Forwarded"),
+		WithTrustedProxies("10.0.0.0/8", "192.168.1.1"),
+	)
+
+	app.Get("/", func(ctx context.Context) {
+		ctx.Writef("%s %s %s", ctx.RemoteAddr(), ctx.Scheme(), ctx.FullRequestURI())
+	})
+
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		peer     string
+		headers  map[string]string
+		expected string
+	}{
+		// direct client, headers are ignored.
+		{"203.0.113.7:1234", map[string]string{"X-Forwarded-For": "1.1.1.1", "X-Forwarded-Proto": "https"},
+			"203.0.113.7 http http://example.com/"},
+		// right-to-left, skipping the trusted hops.
+		{"10.0.0.2:1234", map[string]string{"X-Forwarded-For": "1.1.1.1, 203.0.113.9, 192.168.1.1, 10.0.0.5", "X-Forwarded-Proto": "https"},
+			"203.0.113.9 https https://example.com/"},
+		// all hops are trusted, the left-most is the client.
+		{"10.0.0.2:1234", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.4"},
+			"10.0.0.3 http http://example.com/"},
+		{"10.0.0.2:1234", map[string]string{"Forwarded": `for=198.51.100.17;proto=https;host=api.example.com, for="[2001:db8:cafe::17]:4711", for=10.0.0.9`},
+			"2001:db8:cafe::17 http http://example.com/"},
+		{"10.0.0.2:1234", map[string]string{"Forwarded": `for=198.51.100.17;proto=https;host="api.example.com", for=10.0.0.9;proto=http`},
+			"198.51.100.17 https https://api.example.com/"},
+		{"10.0.0.2:1234", map[string]string{"X-Forwarded-Host": "www.example.com", "X-Forwarded-Proto": "https"},
+			"10.0.0.2 https https://www.example.com/"},
+		// the values of the nearest proxy, the left-most are set by the client.
+		{"10.0.0.2:1234", map[string]string{"X-Forwarded-Host": "evil.example.com, www.example.com", "X-Forwarded-Proto": "http, https"},
+			"10.0.0.2 https https://www.example.com/"},
+	}
+
+	for i, tt := range tests {
+		req := httptest.NewRequest("GET", "http://example.com/", nil)
+		req.RemoteAddr = tt.peer
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+
+		if got := rec.Body.String(); got != tt.expected {
+			t.Fatalf("[%d] expected %q but got %q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestConfigurationInvalidTrustedProxies(t *testing.T) {
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected WithTrustedProxies to panic on an invalid CIDR")
+			}
+		}()
+
+		New().Configure(WithTrustedProxies("10.0.0.0/33"))
+	}()
+
+	app := New()
+	app.Configure(WithConfiguration(Configuration{TrustedProxies: []string{"not-an-ip"}}))
+	if err := app.Build(); err == nil {
+		t.Fatal("expected Build to fail on an invalid trusted proxy")
+	}
+}
@@ -57,4 +57,8 @@ type Application interface {
 	// RouteExists reports whether a particular route exists
 	// It will search from the current subdomain of context's host, if not inside the root domain.
 	RouteExists(ctx Context, method, path string) bool
+
+	// ErrorMapper returns the application's errors to responses registry,
+	// it's used by the `Context.StopWithError` and the hero and mvc handlers that return an error.
+	ErrorMapper() *ErrorMapper
 }
@@ -1,5 +1,7 @@
 package context
 
+import "net"
+
 // ConfigurationReadOnly can be implemented
 // by Configuration, it's being used inside the Context.
 // All methods that it contains should be "safe" to be called by the context
@@ -95,6 +97,13 @@ type ConfigurationReadOnly interface {
 	//
 	// Look `context.RemoteAddr()` for more.
 	GetRemoteAddrHeaders() map[string]bool
+	// GetTrustedProxies returns the parsed CIDR ranges of the reverse proxies
+	// that can be trusted to set the forwarding request headers.
+	//
+	// Defaults to empty.
+	//
+	// Look `context.RemoteAddr()` and `context.Scheme()` for more.
+	GetTrustedProxies() []*net.IPNet
 
 	// GetOther returns the configuration.Other map.
 	GetOther() map[string]interface{}
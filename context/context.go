@@ -2,9 +2,11 @@ package context
 
 import (
 	"bytes"
+	"crypto/x509"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"io/ioutil"
 	"mime"
@@ -23,6 +25,7 @@ import (
 
 	"github.com/radiantrfid/iris/core/errors"
 	"github.com/radiantrfid/iris/core/memstore"
+	"github.com/radiantrfid/iris/core/netutil"
 
 	"github.com/Shopify/goreferrer"
 	"github.com/fatih/structs"
@@ -106,6 +109,10 @@ type Context interface {
 	// 2. release the response writer
 	// and any other optional steps, depends on dev's application type.
 	EndRequest()
+	// OnEndRequest registers a function which is called by the `EndRequest`, after the response is sent,
+	// i.e to release the resources which are acquired for the request.
+	// The functions are called in the reverse order of their registration.
+	OnEndRequest(cb func())
 
 	// ResponseWriter returns an http.ResponseWriter compatible response writer, as expected.
 	ResponseWriter() ResponseWriter
@@ -247,6 +254,10 @@ type Context interface {
 	// IsStopped checks and returns true if the current position of the Context is 255,
 	// means that the StopExecution() was called.
 	IsStopped() bool
+	// StopWithError writes the response of the "err" and stops the execution of the handlers chain.
+	// The errors that are mapped to the application's `ErrorMapper` are written as a Problem,
+	// otherwise the "statusCode" and the error's text are written.
+	StopWithError(statusCode int, err error)
 	// OnConnectionClose registers the "cb" function which will fire (on its own goroutine, no need to be registered goroutine by the end-dev)
 	// when the underlying connection has gone away.
 	//
@@ -321,17 +332,31 @@ type Context interface {
 	// FullRqeuestURI returns the full URI,
 	// including the scheme, the host and the relative requested path/resource.
 	FullRequestURI() string
+	// Scheme returns the protocol the client used to send the request, "http" or "https".
+	//
+	// If the request was received from a trusted proxy (see `Configuration.TrustedProxies`)
+	// then the "Forwarded" (proto parameter) and "X-Forwarded-Proto" headers are respected.
+	Scheme() string
 	// RemoteAddr tries to parse and return the real client's request IP.
 	//
 	// Based on allowed headers names that can be modified from Configuration.RemoteAddrHeaders.
+	// When Configuration.TrustedProxies is not empty the headers are respected
+	// only if the request is received from a trusted proxy and
+	// the "X-Forwarded-For" and "Forwarded" addresses are read right-to-left, skipping the trusted ones.
 	//
 	// If parse based on these headers fail then it will return the Request's `RemoteAddr` field
 	// which is filled by the server before the HTTP handler.
 	//
 	// Look `Configuration.RemoteAddrHeaders`,
+	//      `Configuration.TrustedProxies`,
 	//      `Configuration.WithRemoteAddrHeader(...)`,
 	//      `Configuration.WithoutRemoteAddrHeader(...)` for more.
 	RemoteAddr() string
+	// ClientCertificate returns the verified certificate of the client (mutual TLS),
+	// if any, otherwise nil.
+	//
+	// Look `core/netutil#CertManager.AddClientCA` for more.
+	ClientCertificate() *x509.Certificate
 	// GetHeader returns the request header's value based on its name.
 	GetHeader(name string) string
 	// IsAjax returns true if this request is an 'ajax request'( XMLHttpRequest)
@@ -667,6 +692,30 @@ type Context interface {
 	//
 	// It's mostly used internally on core/router/fs.go and context methods.
 	WriteNotModified()
+	// SetETag sets the "ETag" response header to the "etag",
+	// see the `FormatETag` and `ComputeETag` package-level functions.
+	SetETag(etag string)
+	// CheckPreconditions evaluates the conditional request headers,
+	// "If-Match", "If-Unmodified-Since", "If-None-Match" and "If-Modified-Since" in that order (RFC 9110 section 13.2.2),
+	// against the current "etag" and "modtime" of the resource.
+	// An empty "etag" means that the resource does not exist or it has no ETag,
+	// a zero "modtime" means that it has no modification time.
+	//
+	// It returns zero if the request should be processed,
+	// 304 (Not Modified) if the resource is not modified on GET and HEAD requests
+	// or 412 (Precondition Failed) if a precondition failed,
+	// i.e a PUT request of a client which has an outdated copy of the resource.
+	CheckPreconditions(etag string, modtime time.Time) int
+	// HandlePreconditions is like `CheckPreconditions` but it writes the 304 or 412 status code
+	// and stops the execution of the rest handlers, in that case it returns true
+	// and the handler should return without writing anything else, i.e
+	//
+	// if ctx.HandlePreconditions(context.FormatETag(user.Version, false), user.UpdatedAt) {
+	// 	return
+	// }
+	//
+	// Protects the write requests from lost updates when the clients send the "If-Match" header.
+	HandlePreconditions(etag string, modtime time.Time) bool
 	// WriteWithExpiration works like `Write` but it will check if a resource is modified,
 	// based on the "modtime" input argument,
 	// otherwise sends a 304 status code in order to let the client-side render the cached content.
@@ -1061,6 +1110,8 @@ type context struct {
 	handlers Handlers
 	// the current position of the handler's chain
 	currentHandlerIndex int
+	// the functions to call on EndRequest, see `OnEndRequest`.
+	endRequestFuncs []func()
 }
 
 // NewContext returns the default, internal, context implementation.
@@ -1088,6 +1139,7 @@ func (ctx *context) BeginRequest(w http.ResponseWriter, r *http.Request) {
 	ctx.params.Store = ctx.params.Store[0:0]
 	ctx.request = r
 	ctx.currentHandlerIndex = 0
+	ctx.endRequestFuncs = ctx.endRequestFuncs[0:0]
 	ctx.writer = AcquireResponseWriter()
 	ctx.writer.BeginResponse(w)
 }
@@ -1141,6 +1193,17 @@ func (ctx *context) EndRequest() {
 
 	ctx.writer.FlushResponse()
 	ctx.writer.EndResponse()
+
+	for i := len(ctx.endRequestFuncs) - 1; i >= 0; i-- {
+		ctx.endRequestFuncs[i]()
+	}
+}
+
+// OnEndRequest registers a function which is called by the `EndRequest`, after the response is sent,
+// i.e to release the resources which are acquired for the request.
+// The functions are called in the reverse order of their registration.
+func (ctx *context) OnEndRequest(cb func()) {
+	ctx.endRequestFuncs = append(ctx.endRequestFuncs, cb)
 }
 
 // ResponseWriter returns an http.ResponseWriter compatible response writer, as expected.
@@ -1404,6 +1467,21 @@ func (ctx *context) IsStopped() bool {
 	return ctx.currentHandlerIndex == stopExecutionIndex
 }
 
+// StopWithError writes the response of the "err" and stops the execution of the handlers chain.
+// The errors that are mapped to the application's `ErrorMapper` are written as a Problem,
+// otherwise the "statusCode" and the error's text are written.
+func (ctx *context) StopWithError(statusCode int, err error) {
+	if ctx.app.ErrorMapper().Dispatch(ctx, err) {
+		return
+	}
+
+	ctx.StatusCode(statusCode)
+	if err != nil {
+		ctx.WriteString(err.Error())
+	}
+	ctx.StopExecution()
+}
+
 // OnConnectionClose registers the "cb" function which will fire (on its own goroutine, no need to be registered goroutine by the end-dev)
 // when the underlying connection has gone away.
 //
@@ -1629,39 +1707,98 @@ func (ctx *context) FullRequestURI() string {
 	return ctx.AbsoluteURI(ctx.Path())
 }
 
-const xForwardedForHeaderKey = "X-Forwarded-For"
+// Scheme returns the protocol the client used to send the request, "http" or "https".
+//
+// If the request was received from a trusted proxy (see `Configuration.TrustedProxies`)
+// then the "Forwarded" (proto parameter) and "X-Forwarded-Proto" headers are respected.
+func (ctx *context) Scheme() string {
+	if scheme, _ := ctx.forwarded(); scheme != "" {
+		return scheme
+	}
+
+	return ctx.requestScheme()
+}
+
+// requestScheme returns the scheme of the request as received by the server.
+func (ctx *context) requestScheme() string {
+	if scheme := ctx.request.URL.Scheme; scheme != "" {
+		return scheme
+	}
+
+	return netutil.ResolveScheme(ctx.request.TLS != nil)
+}
+
+const (
+	xForwardedForHeaderKey   = "X-Forwarded-For"
+	xForwardedProtoHeaderKey = "X-Forwarded-Proto"
+	xForwardedHostHeaderKey  = "X-Forwarded-Host"
+	forwardedHeaderKey       = "Forwarded"
+)
 
 // RemoteAddr tries to parse and return the real client's request IP.
 //
 // Based on allowed headers names that can be modified from Configuration.RemoteAddrHeaders.
+// When Configuration.TrustedProxies is not empty the headers are respected
+// only if the request is received from a trusted proxy and
+// the "X-Forwarded-For" and "Forwarded" addresses are read right-to-left, skipping the trusted ones.
 //
 // If parse based on these headers fail then it will return the Request's `RemoteAddr` field
 // which is filled by the server before the HTTP handler.
 //
 // Look `Configuration.RemoteAddrHeaders`,
+//      `Configuration.TrustedProxies`,
 //      `Configuration.WithRemoteAddrHeader(...)`,
 //      `Configuration.WithoutRemoteAddrHeader(...)` for more.
 func (ctx *context) RemoteAddr() string {
+	addr := ctx.remotePeer()
+	trusted, fromTrusted := ctx.trustedProxies(addr)
+	if len(trusted) > 0 && !fromTrusted {
+		// a direct client, the forwarding headers can be spoofed.
+		return addr
+	}
+
 	remoteHeaders := ctx.Application().ConfigurationReadOnly().GetRemoteAddrHeaders()
 
 	for headerName, enabled := range remoteHeaders {
 		if enabled {
-			headerValue := ctx.GetHeader(headerName)
-			// exception needed for 'X-Forwarded-For' only , if enabled.
-			if headerName == xForwardedForHeaderKey {
-				idx := strings.IndexByte(headerValue, ',')
-				if idx >= 0 {
-					headerValue = headerValue[0:idx]
+			var realIP string
+
+			switch http.CanonicalHeaderKey(headerName) {
+			case xForwardedForHeaderKey:
+				// exception needed for 'X-Forwarded-For' only , if enabled.
+				var hops []string
+				for _, headerValue := range ctx.request.Header[xForwardedForHeaderKey] {
+					for _, hop := range strings.Split(headerValue, ",") {
+						hops = append(hops, strings.TrimSpace(hop))
+					}
+				}
+
+				if idx := clientHopIndex(hops, trusted); idx >= 0 {
+					realIP = hops[idx]
 				}
+			case forwardedHeaderKey:
+				elements := netutil.ParseForwarded(ctx.request.Header[forwardedHeaderKey])
+				if idx := forwardedClientIndex(elements, trusted); idx >= 0 {
+					if ip := netutil.ForwardedNodeIP(elements[idx].For); ip != nil {
+						realIP = ip.String()
+					}
+				}
+			default:
+				realIP = strings.TrimSpace(ctx.GetHeader(headerName))
 			}
 
-			realIP := strings.TrimSpace(headerValue)
 			if realIP != "" {
 				return realIP
 			}
 		}
 	}
 
+	return addr
+}
+
+// remotePeer returns the IP of the connection's peer,
+// the Request's `RemoteAddr` field without the port.
+func (ctx *context) remotePeer() string {
 	addr := strings.TrimSpace(ctx.request.RemoteAddr)
 	if addr != "" {
 		// if addr has port use the net.SplitHostPort otherwise(error occurs) take as it is
@@ -1673,6 +1810,119 @@ func (ctx *context) RemoteAddr() string {
 	return addr
 }
 
+// trustedProxies returns the parsed Configuration.TrustedProxies and
+// reports whether the "peer" address is one of them.
+func (ctx *context) trustedProxies(peer string) ([]*net.IPNet, bool) {
+	trusted := ctx.Application().ConfigurationReadOnly().GetTrustedProxies()
+	if len(trusted) == 0 {
+		return nil, false
+	}
+
+	return trusted, netutil.IPInNets(net.ParseIP(peer), trusted)
+}
+
+// clientHopIndex returns the index of the client's address in the "hops" list.
+// If "trusted" is empty then it's the first one, as it used to be,
+// otherwise the list is walked right-to-left and the first untrusted address is returned.
+// Returns -1 if the list is empty or an invalid address is found before the client's one.
+func clientHopIndex(hops []string, trusted []*net.IPNet) int {
+	if len(trusted) == 0 {
+		for i, hop := range hops {
+			if hop != "" {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			return -1
+		}
+
+		if !netutil.IPInNets(ip, trusted) {
+			return i
+		}
+	}
+
+	// all of them are trusted, the left-most is the client.
+	if len(hops) > 0 {
+		return 0
+	}
+
+	return -1
+}
+
+// forwardedClientIndex same as `clientHopIndex` but for the "Forwarded" header's elements.
+func forwardedClientIndex(elements []netutil.ForwardedElement, trusted []*net.IPNet) int {
+	hops := make([]string, len(elements))
+	for i, element := range elements {
+		if ip := netutil.ForwardedNodeIP(element.For); ip != nil {
+			hops[i] = ip.String()
+		}
+	}
+
+	return clientHopIndex(hops, trusted)
+}
+
+// forwarded returns the scheme and the host the client used to send the request,
+// as they reported by a trusted proxy, if any.
+func (ctx *context) forwarded() (scheme string, host string) {
+	trusted, fromTrusted := ctx.trustedProxies(ctx.remotePeer())
+	if !fromTrusted {
+		return
+	}
+
+	if elements := netutil.ParseForwarded(ctx.request.Header[forwardedHeaderKey]); len(elements) > 0 {
+		if idx := forwardedClientIndex(elements, trusted); idx >= 0 {
+			scheme, host = elements[idx].Proto, elements[idx].Host
+		}
+	}
+
+	if scheme == "" {
+		scheme = strings.ToLower(ctx.lastHeaderValue(xForwardedProtoHeaderKey))
+	}
+
+	if host == "" {
+		host = ctx.lastHeaderValue(xForwardedHostHeaderKey)
+	}
+
+	if scheme != netutil.SchemeHTTP && scheme != netutil.SchemeHTTPS {
+		scheme = ""
+	}
+
+	return
+}
+
+// lastHeaderValue returns the right-most value of a comma-separated list request header,
+// the one which is appended by the nearest proxy, the rest are controlled by the client.
+func (ctx *context) lastHeaderValue(name string) string {
+	values := ctx.request.Header[name]
+	if len(values) == 0 {
+		return ""
+	}
+
+	last := values[len(values)-1]
+	if idx := strings.LastIndexByte(last, ','); idx >= 0 {
+		last = last[idx+1:]
+	}
+
+	return strings.TrimSpace(last)
+}
+
+// ClientCertificate returns the verified certificate of the client (mutual TLS),
+// if any, otherwise nil.
+//
+// Look `core/netutil#CertManager.AddClientCA` for more.
+func (ctx *context) ClientCertificate() *x509.Certificate {
+	if state := ctx.request.TLS; state != nil && len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+		return state.VerifiedChains[0][0]
+	}
+
+	return nil
+}
+
 // GetHeader returns the request header's value based on its name.
 func (ctx *context) GetHeader(name string) string {
 	return ctx.request.Header.Get(name)
@@ -2373,18 +2623,16 @@ func (ctx *context) AbsoluteURI(s string) string {
 	}
 
 	if s[0] == '/' {
-		scheme := ctx.request.URL.Scheme
+		scheme, host := ctx.forwarded()
 		if scheme == "" {
-			if ctx.request.TLS != nil {
-				scheme = "https:"
-			} else {
-				scheme = "http:"
-			}
+			scheme = ctx.requestScheme()
 		}
 
-		host := ctx.Host()
+		if host == "" {
+			host = ctx.Host()
+		}
 
-		return scheme + "//" + host + path.Clean(s)
+		return scheme + "://" + host + path.Clean(s)
 	}
 
 	if u, err := url.Parse(s); err == nil {
@@ -2639,6 +2887,12 @@ const (
 	CacheControlHeaderKey = "Cache-Control"
 	// ETagHeaderKey is the header key of "ETag".
 	ETagHeaderKey = "ETag"
+	// IfNoneMatchHeaderKey is the header key of "If-None-Match".
+	IfNoneMatchHeaderKey = "If-None-Match"
+	// IfMatchHeaderKey is the header key of "If-Match".
+	IfMatchHeaderKey = "If-Match"
+	// IfUnmodifiedSinceHeaderKey is the header key of "If-Unmodified-Since".
+	IfUnmodifiedSinceHeaderKey = "If-Unmodified-Since"
 
 	// ContentDispositionHeaderKey is the header key of "Content-Disposition".
 	ContentDispositionHeaderKey = "Content-Disposition"
@@ -2750,6 +3004,134 @@ func (ctx *context) WriteNotModified() {
 	ctx.StatusCode(http.StatusNotModified)
 }
 
+// FormatETag returns the quoted "ETag" header value of the "version",
+// i.e v1 to "v1" or to W/"v1" if "weak" is true.
+func FormatETag(version string, weak bool) string {
+	etag := strconv.Quote(version)
+	if weak {
+		return "W/" + etag
+	}
+
+	return etag
+}
+
+// ComputeETag returns an ETag of the "body", see `FormatETag`.
+// A strong ETag should be used when the exact same bytes are sent,
+// a weak one when the body is semantically equivalent,
+// i.e it's compressed by a next middleware.
+func ComputeETag(body []byte, weak bool) string {
+	hash := fnv.New64a()
+	hash.Write(body)
+	return FormatETag(strconv.FormatInt(int64(len(body)), 16)+"-"+strconv.FormatUint(hash.Sum64(), 16), weak)
+}
+
+// matchETags reports whether the "header", a list of ETags or "*",
+// matches the "etag" with the weak or the strong comparison (RFC 9110 section 8.8.3.2).
+func matchETags(header, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+
+	if !weak && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetETag sets the "ETag" response header to the "etag",
+// see the `FormatETag` and `ComputeETag` package-level functions.
+func (ctx *context) SetETag(etag string) {
+	ctx.Header(ETagHeaderKey, etag)
+}
+
+// CheckPreconditions evaluates the conditional request headers,
+// "If-Match", "If-Unmodified-Since", "If-None-Match" and "If-Modified-Since" in that order (RFC 9110 section 13.2.2),
+// against the current "etag" and "modtime" of the resource.
+// An empty "etag" means that the resource does not exist or it has no ETag,
+// a zero "modtime" means that it has no modification time.
+//
+// It returns zero if the request should be processed,
+// 304 (Not Modified) if the resource is not modified on GET and HEAD requests
+// or 412 (Precondition Failed) if a precondition failed,
+// i.e a PUT request of a client which has an outdated copy of the resource.
+func (ctx *context) CheckPreconditions(etag string, modtime time.Time) int {
+	method := ctx.Method()
+	read := method == http.MethodGet || method == http.MethodHead
+
+	if ifMatch := ctx.GetHeader(IfMatchHeaderKey); ifMatch != "" {
+		if !matchETags(ifMatch, etag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if ius := ctx.GetHeader(IfUnmodifiedSinceHeaderKey); ius != "" && !IsZeroTime(modtime) {
+		if t, err := ParseTime(ctx, ius); err == nil && modtime.UTC().Truncate(time.Second).After(t) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if ifNoneMatch := ctx.GetHeader(IfNoneMatchHeaderKey); ifNoneMatch != "" {
+		if matchETags(ifNoneMatch, etag, true) {
+			if read {
+				return http.StatusNotModified
+			}
+
+			return http.StatusPreconditionFailed
+		}
+	} else if read {
+		if modified, err := ctx.CheckIfModifiedSince(modtime); !modified && err == nil {
+			return http.StatusNotModified
+		}
+	}
+
+	return 0
+}
+
+// HandlePreconditions is like `CheckPreconditions` but it writes the 304 or 412 status code
+// and stops the execution of the rest handlers, in that case it returns true
+// and the handler should return without writing anything else, i.e
+//
+// if ctx.HandlePreconditions(context.FormatETag(user.Version, false), user.UpdatedAt) {
+// 	return
+// }
+//
+// Protects the write requests from lost updates when the clients send the "If-Match" header.
+func (ctx *context) HandlePreconditions(etag string, modtime time.Time) bool {
+	switch ctx.CheckPreconditions(etag, modtime) {
+	case http.StatusNotModified:
+		if etag != "" {
+			ctx.SetETag(etag)
+		}
+		ctx.SetLastModified(modtime)
+		ctx.WriteNotModified()
+	case http.StatusPreconditionFailed:
+		ctx.StatusCode(http.StatusPreconditionFailed)
+	default:
+		return false
+	}
+
+	ctx.StopExecution()
+	return true
+}
+
 // WriteWithExpiration works like `Write` but it will check if a resource is modified,
 // based on the "modtime" input argument,
 // otherwise sends a 304 status code in order to let the client-side render the cached content.
@@ -0,0 +1,178 @@
+package context
+
+import (
+	"reflect"
+	"sync"
+)
+
+// ProblemFunc builds the Problem of an error which matches an `ErrorMapper` mapping,
+// the "err" is the matched error of the chain, i.e a *ValidationError.
+type ProblemFunc func(ctx Context, err error) Problem
+
+// ErrorMapper maps errors to status codes and `Problem` responses.
+// An error matches a mapping if it is, or it wraps, the mapped sentinel value
+// or a value of the mapped type, see `Map`.
+//
+// There is one error mapper per Application, see `Application.ErrorMapper` and `Context.StopWithError`,
+// the "hero" and "mvc" packages use that to write the errors returned by their handlers.
+type ErrorMapper struct {
+	mu       sync.RWMutex
+	mappings []errorMapping
+}
+
+type errorMapping struct {
+	match   func(err error) error
+	status  int
+	problem ProblemFunc
+}
+
+// NewErrorMapper returns a new, empty, error mapper.
+func NewErrorMapper() *ErrorMapper {
+	return new(ErrorMapper)
+}
+
+// Map registers the "status" code and the optional "problem" builder of the errors which match the "target".
+//
+// The "target" can be:
+// a sentinel error value, i.e `ErrNotFound`, the matched errors are equal to it or they report true on its `Is(error) bool`,
+// a nil pointer of an error type, i.e `(*ValidationError)(nil)`, the matched errors are of that type,
+// a nil pointer of an interface, i.e `(*NotFounder)(nil)`, the matched errors implement that interface,
+// or a `reflect.Type` of an error type or interface.
+// The errors which wrap a matched error, through their `Unwrap() error`, are matched too.
+//
+// The response is a "status" Problem with the error's text as its detail,
+// unless a "problem" builder is given; the "status" is set to its result if it's missing.
+// The first matched mapping, in order of registration, is used.
+//
+// Example:
+//
+//	app.ErrorMapper().
+//		Map(ErrNotFound, iris.StatusNotFound).
+//		Map((*ValidationError)(nil), iris.StatusUnprocessableEntity, func(ctx iris.Context, err error) iris.Problem {
+//			return iris.NewProblem().Detail(err.Error()).Key("fields", err.(*ValidationError).Fields)
+//		})
+func (m *ErrorMapper) Map(target interface{}, status int, problem ...ProblemFunc) *ErrorMapper {
+	mapping := errorMapping{match: matcherOf(target), status: status}
+	if len(problem) > 0 {
+		mapping.problem = problem[0]
+	}
+
+	m.mu.Lock()
+	m.mappings = append(m.mappings, mapping)
+	m.mu.Unlock()
+
+	return m
+}
+
+var errorTyp = reflect.TypeOf((*error)(nil)).Elem()
+
+func matcherOf(target interface{}) func(error) error {
+	typ, ok := target.(reflect.Type)
+	if !ok {
+		v := reflect.ValueOf(target)
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			typ = v.Type()
+			if typ.Elem().Kind() == reflect.Interface {
+				typ = typ.Elem()
+			}
+		}
+	}
+
+	if typ != nil {
+		return func(err error) error {
+			return findError(err, func(e error) bool {
+				errTyp := reflect.TypeOf(e)
+				return errTyp == typ || (typ.Kind() == reflect.Interface && typ != errorTyp && errTyp.Implements(typ))
+			})
+		}
+	}
+
+	sentinel, ok := target.(error)
+	if !ok {
+		panic("context: error mapper: target should be an error, a nil pointer of an error type or a reflect.Type")
+	}
+
+	comparable := reflect.TypeOf(sentinel).Comparable()
+	return func(err error) error {
+		return findError(err, func(e error) bool {
+			if comparable && reflect.TypeOf(e).Comparable() && e == sentinel {
+				return true
+			}
+
+			if is, ok := e.(interface{ Is(error) bool }); ok {
+				return is.Is(sentinel)
+			}
+
+			return false
+		})
+	}
+}
+
+// findError returns the first error of the "err" chain which passes the "match".
+func findError(err error, match func(error) bool) error {
+	for err != nil {
+		if match(err) {
+			return err
+		}
+
+		wrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return nil
+		}
+
+		err = wrapper.Unwrap()
+	}
+
+	return nil
+}
+
+// Problem returns the status code and the Problem of the "err" based on the first matched mapping.
+// It reports false if the "err" does not match any mapping.
+func (m *ErrorMapper) Problem(ctx Context, err error) (int, Problem, bool) {
+	if m == nil || err == nil {
+		return 0, nil, false
+	}
+
+	m.mu.RLock()
+	mappings := m.mappings
+	m.mu.RUnlock()
+
+	for _, mapping := range mappings {
+		matched := mapping.match(err)
+		if matched == nil {
+			continue
+		}
+
+		var p Problem
+		if mapping.problem != nil {
+			p = mapping.problem(ctx, matched)
+		}
+
+		if p == nil {
+			p = NewProblem().Detail(err.Error())
+		}
+
+		status, ok := p.getStatus()
+		if !ok {
+			status = mapping.status
+			p.Status(status)
+		}
+
+		return status, p, true
+	}
+
+	return 0, nil, false
+}
+
+// Dispatch writes the Problem of the "err" and stops the execution of the handlers chain.
+// It reports false, and writes nothing, if the "err" does not match any mapping.
+func (m *ErrorMapper) Dispatch(ctx Context, err error) bool {
+	_, p, ok := m.Problem(ctx, err)
+	if !ok {
+		return false
+	}
+
+	ctx.Problem(p)
+	ctx.StopExecution()
+	return true
+}
@@ -1,6 +1,7 @@
 package context
 
 import (
+	"net/http"
 	"reflect"
 	"runtime"
 	"strings"
@@ -41,6 +42,96 @@ func HandlerFileLine(h Handler) (file string, line int) {
 	return runtime.FuncForPC(pc).FileLine(pc)
 }
 
+// InvalidHandler returns a handler in place of a handler which could not be created because of the "err",
+// i.e a hero handler with input arguments that can not be resolved.
+// The router reports its error on the route registration, so the `Application.Build` fails, see `HandlerError`.
+// If it's served anyway then it logs the error and responds with 500 Internal Server Error.
+//
+//go:noinline
+func InvalidHandler(err error) Handler {
+	return func(ctx Context) {
+		if probe, ok := ctx.(*handlerErrorProbe); ok {
+			probe.err = err
+			return
+		}
+
+		ctx.Application().Logger().Error(err)
+		ctx.StatusCode(http.StatusInternalServerError)
+		ctx.StopExecution()
+	}
+}
+
+// handlerErrorProbe is passed to an `InvalidHandler` to read its error.
+type handlerErrorProbe struct {
+	Context
+	err error
+}
+
+// the handlers of the `InvalidHandler` share the same function,
+// it's not inlined so it's not copied to its callers.
+var invalidHandlerPC = reflect.ValueOf(InvalidHandler(nil)).Pointer()
+
+// HandlerError returns the error of a handler which is created by the `InvalidHandler`,
+// it returns nil for any other handler.
+func HandlerError(h Handler) error {
+	if h == nil || reflect.ValueOf(h).Pointer() != invalidHandlerPC {
+		return nil
+	}
+
+	probe := new(handlerErrorProbe)
+	h(probe)
+	return probe.err
+}
+
+// ExpectedParam is a path parameter which a handler binds by its name, see `ParamsHandler`.
+type ExpectedParam struct {
+	Name string
+	// Type is the Go type of the parameter's value, i.e int64.
+	Type reflect.Type
+	// Optional reports whether the parameter may be missing from the route's path.
+	Optional bool
+	// Field is the struct field which binds the parameter, for the errors, i.e "main.userParams.ID".
+	Field string
+}
+
+// ParamsHandler returns a handler which executes the "h" and describes the path parameters that it expects,
+// i.e a hero handler with a parameters struct input.
+// The router checks them against the route's path on the route registration,
+// so a missing parameter, or a parameter of a different type, fails the `Application.Build`, see `HandlerParams`.
+//
+//go:noinline
+func ParamsHandler(h Handler, params []ExpectedParam) Handler {
+	return func(ctx Context) {
+		if probe, ok := ctx.(*handlerParamsProbe); ok {
+			probe.params = params
+			return
+		}
+
+		h(ctx)
+	}
+}
+
+// handlerParamsProbe is passed to a `ParamsHandler` to read its expected parameters.
+type handlerParamsProbe struct {
+	Context
+	params []ExpectedParam
+}
+
+// the handlers of the `ParamsHandler` share the same function, see `invalidHandlerPC`.
+var paramsHandlerPC = reflect.ValueOf(ParamsHandler(nil, nil)).Pointer()
+
+// HandlerParams returns the expected path parameters of a handler which is created by the `ParamsHandler`,
+// it returns nil for any other handler.
+func HandlerParams(h Handler) []ExpectedParam {
+	if h == nil || reflect.ValueOf(h).Pointer() != paramsHandlerPC {
+		return nil
+	}
+
+	probe := new(handlerParamsProbe)
+	h(probe)
+	return probe.params
+}
+
 // MainHandlerName tries to find the main handler than end-developer
 // registered on the provided chain of handlers and returns its function name.
 func MainHandlerName(handlers Handlers) (name string) {
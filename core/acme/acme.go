@@ -0,0 +1,645 @@
+// Package acme implements an ACME (RFC 8555) certificates manager
+// which obtains, caches and renews certificates from any ACME directory,
+// i.e Let's Encrypt or a local test CA like Pebble.
+//
+// It can complete the "http-01", "tls-alpn-01" and, through a `DNSProvider`, the "dns-01" challenges.
+// Use it through the `Supervisor.ListenAndServeACME` of the core/host package
+// or the `iris.ACME` runner.
+package acme
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/radiantrfid/iris/core/errors"
+
+	xacme "golang.org/x/crypto/acme"
+)
+
+// LetsEncryptURL is the directory URL of the Let's Encrypt production CA,
+// the default `Config.DirectoryURL`.
+const LetsEncryptURL = xacme.LetsEncryptURL
+
+// accountKeyCacheKey is the cache key of the ACME account's private key.
+const accountKeyCacheKey = "acme_account+key"
+
+var (
+	errMissingServerName = errors.New("acme: missing server name")
+	errHostNotAllowed    = errors.New("acme: host %q is not allowed")
+	errNoChallenge       = errors.New("acme: no solver for any of the offered challenges of %q")
+	errAuthorization     = errors.New("acme: authorization of %q failed: %v")
+	errNoALPNCertificate = errors.New("acme: no tls-alpn-01 challenge certificate for %q")
+)
+
+// Config is the configuration of the `Manager`, see `New`.
+type Config struct {
+	// DirectoryURL is the ACME directory URL of the CA,
+	// i.e "https://localhost:14000/dir" for a local Pebble server.
+	//
+	// Defaults to the `LetsEncryptURL`.
+	DirectoryURL string
+	// HTTPClient is the client which communicates with the CA,
+	// set a client which trusts the local CA's root certificate for tests.
+	//
+	// Defaults to the `http.DefaultClient`.
+	HTTPClient *http.Client
+	// Email is the contact e-mail of the account, optional.
+	Email string
+	// Domains is the whitelist of the hosts that certificates can be obtained for,
+	// a wildcard entry, i.e "*.example.com", obtains a single certificate for all
+	// its subdomains and requires a "dns-01" solver.
+	// If empty, all hosts are allowed. This is not recommended,
+	// as clients can ask for any host name and exhaust the CA's rate limits.
+	Domains []string
+	// Cache stores the account key and the certificates,
+	// i.e a `DirCache` or the `CertCache` of the boltdb, badger and redis session databases.
+	//
+	// Defaults to a `MemCache`.
+	Cache Cache
+	// RenewBefore is the time before the expiration of a certificate that it should be renewed.
+	//
+	// Defaults to 30 days.
+	RenewBefore time.Duration
+	// ForceRSA when true the certificates' keys are RSA 2048 instead of ECDSA P-256.
+	//
+	// Defaults to false.
+	ForceRSA bool
+	// DisableHTTPChallenge disables the "http-01" challenge solver.
+	//
+	// Defaults to false.
+	DisableHTTPChallenge bool
+	// DisableTLSALPNChallenge disables the "tls-alpn-01" challenge solver.
+	//
+	// Defaults to false.
+	DisableTLSALPNChallenge bool
+	// Solvers are custom challenge solvers, i.e the `DNS01`,
+	// they're preferred over the built'n ones.
+	Solvers []Solver
+}
+
+// Manager obtains certificates on demand (on the first TLS handshake of a host)
+// or through its `Obtain`, keeps them in its `Config.Cache` and renews them, see `Watch`.
+type Manager struct {
+	config Config
+
+	http01    *http01Solver
+	tlsALPN01 *tlsALPN01Solver
+	solvers   []Solver
+
+	clientMu sync.Mutex
+	client   *xacme.Client
+
+	mu          sync.RWMutex
+	certs       map[string]*tls.Certificate
+	domainLocks map[string]*sync.Mutex
+
+	hooksMu  sync.Mutex
+	onObtain []func(domain string, cert *tls.Certificate)
+	onRenew  []func(domain string, cert *tls.Certificate)
+	onError  []func(domain string, err error)
+}
+
+// New returns a new ACME certificates `Manager` based on the "config".
+func New(config Config) *Manager {
+	if config.DirectoryURL == "" {
+		config.DirectoryURL = LetsEncryptURL
+	}
+
+	if config.Cache == nil {
+		config.Cache = NewMemCache()
+	}
+
+	if config.RenewBefore <= 0 {
+		config.RenewBefore = 30 * 24 * time.Hour
+	}
+
+	// copy, the caller's slice is not modified.
+	domains := make([]string, len(config.Domains))
+	for i, domain := range config.Domains {
+		domains[i] = normalizeDomain(domain)
+	}
+	config.Domains = domains
+
+	m := &Manager{
+		config:      config,
+		http01:      &http01Solver{responses: make(map[string]string)},
+		tlsALPN01:   &tlsALPN01Solver{certs: make(map[string]*tls.Certificate)},
+		certs:       make(map[string]*tls.Certificate),
+		domainLocks: make(map[string]*sync.Mutex),
+	}
+
+	m.solvers = append(m.solvers, config.Solvers...)
+	if !config.DisableTLSALPNChallenge {
+		m.solvers = append(m.solvers, m.tlsALPN01)
+	}
+	if !config.DisableHTTPChallenge {
+		m.solvers = append(m.solvers, m.http01)
+	}
+
+	return m
+}
+
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
+
+// RegisterOnObtain registers a function to call when a new certificate is obtained for a domain.
+func (m *Manager) RegisterOnObtain(cb func(domain string, cert *tls.Certificate)) {
+	m.hooksMu.Lock()
+	m.onObtain = append(m.onObtain, cb)
+	m.hooksMu.Unlock()
+}
+
+// RegisterOnRenew registers a function to call when the certificate of a domain is renewed.
+func (m *Manager) RegisterOnRenew(cb func(domain string, cert *tls.Certificate)) {
+	m.hooksMu.Lock()
+	m.onRenew = append(m.onRenew, cb)
+	m.hooksMu.Unlock()
+}
+
+// RegisterOnError registers a function to call when a certificate couldn't be obtained or renewed.
+func (m *Manager) RegisterOnError(cb func(domain string, err error)) {
+	m.hooksMu.Lock()
+	m.onError = append(m.onError, cb)
+	m.hooksMu.Unlock()
+}
+
+// HasErrorListeners reports whether any `RegisterOnError` listeners are registered.
+func (m *Manager) HasErrorListeners() bool {
+	m.hooksMu.Lock()
+	n := len(m.onError)
+	m.hooksMu.Unlock()
+	return n > 0
+}
+
+func (m *Manager) notifyCert(renewal bool, domain string, cert *tls.Certificate) {
+	m.hooksMu.Lock()
+	callbacks := m.onObtain
+	if renewal {
+		callbacks = m.onRenew
+	}
+	m.hooksMu.Unlock()
+
+	for _, cb := range callbacks {
+		cb(domain, cert)
+	}
+}
+
+func (m *Manager) notifyErr(domain string, err error) {
+	m.hooksMu.Lock()
+	callbacks := m.onError
+	m.hooksMu.Unlock()
+
+	for _, cb := range callbacks {
+		cb(domain, err)
+	}
+}
+
+// certName returns the whitelisted domain entry that the "host" matches, or empty if it's not allowed.
+func (m *Manager) certName(host string) string {
+	if len(m.config.Domains) == 0 {
+		return host
+	}
+
+	for _, domain := range m.config.Domains {
+		if domain == host {
+			return domain
+		}
+	}
+
+	// try the wildcard, i.e "*.example.com" for "www.example.com".
+	if dotIdx := strings.IndexByte(host, '.'); dotIdx > 0 {
+		wildcard := "*" + host[dotIdx:]
+		for _, domain := range m.config.Domains {
+			if domain == wildcard {
+				return domain
+			}
+		}
+	}
+
+	return ""
+}
+
+// GetCertificate returns the certificate of the client's requested server name,
+// it's the `tls.Config.GetCertificate` of the `TLSConfig`.
+// The certificate is obtained on demand if it's not cached yet.
+// It serves the "tls-alpn-01" challenge certificates as well.
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := normalizeDomain(hello.ServerName)
+	if name == "" {
+		return nil, errMissingServerName
+	}
+
+	if len(hello.SupportedProtos) == 1 && hello.SupportedProtos[0] == xacme.ALPNProto {
+		cert, ok := m.tlsALPN01.certificate(name)
+		if !ok {
+			return nil, errNoALPNCertificate.Format(name)
+		}
+		return cert, nil
+	}
+
+	certName := m.certName(name)
+	if certName == "" {
+		return nil, errHostNotAllowed.Format(name)
+	}
+
+	ctx, cancel := context.WithTimeout(helloContext(hello), 5*time.Minute)
+	defer cancel()
+
+	return m.certificate(ctx, certName)
+}
+
+// certificate returns the certificate of the "domain" from memory, the cache
+// or obtains a new one, one at a time per domain.
+func (m *Manager) certificate(ctx context.Context, domain string) (*tls.Certificate, error) {
+	if cert, ok := m.cachedCertificate(domain); ok {
+		return cert, nil
+	}
+
+	lock := m.domainLock(domain)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// obtained or loaded while waiting for the lock.
+	if cert, ok := m.cachedCertificate(domain); ok {
+		return cert, nil
+	}
+
+	if data, err := m.config.Cache.Get(ctx, domain); err == nil {
+		if cert, err := decodeCertificate(data); err == nil && time.Now().Before(cert.Leaf.NotAfter) {
+			m.storeCertificate(domain, cert)
+			return cert, nil
+		}
+	}
+
+	cert, err := m.obtain(ctx, domain)
+	if err != nil {
+		m.notifyErr(domain, err)
+		return nil, err
+	}
+
+	m.notifyCert(false, domain, cert)
+	return cert, nil
+}
+
+func (m *Manager) cachedCertificate(domain string) (*tls.Certificate, bool) {
+	m.mu.RLock()
+	cert, ok := m.certs[domain]
+	m.mu.RUnlock()
+	return cert, ok
+}
+
+func (m *Manager) storeCertificate(domain string, cert *tls.Certificate) {
+	m.mu.Lock()
+	m.certs[domain] = cert
+	m.mu.Unlock()
+}
+
+func (m *Manager) domainLock(domain string) *sync.Mutex {
+	m.mu.Lock()
+	lock, ok := m.domainLocks[domain]
+	if !ok {
+		lock = new(sync.Mutex)
+		m.domainLocks[domain] = lock
+	}
+	m.mu.Unlock()
+	return lock
+}
+
+// Obtain requests a new certificate for the "domain" from the CA
+// even if a valid one exists, stores it to the cache and returns it.
+// It can be used to obtain the certificates before the server starts.
+func (m *Manager) Obtain(ctx context.Context, domain string) (*tls.Certificate, error) {
+	domain = normalizeDomain(domain)
+	if m.certName(domain) != domain {
+		return nil, errHostNotAllowed.Format(domain)
+	}
+
+	lock := m.domainLock(domain)
+	lock.Lock()
+	defer lock.Unlock()
+
+	_, renewal := m.cachedCertificate(domain)
+
+	cert, err := m.obtain(ctx, domain)
+	if err != nil {
+		m.notifyErr(domain, err)
+		return nil, err
+	}
+
+	m.notifyCert(renewal, domain, cert)
+	return cert, nil
+}
+
+// obtain completes an order for the "domain" and stores its certificate,
+// the caller should hold the domain's lock.
+func (m *Manager) obtain(ctx context.Context, domain string) (*tls.Certificate, error) {
+	client, err := m.acmeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := client.AuthorizeOrder(ctx, xacme.DomainIDs(domain))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, authzURL := range order.AuthzURLs {
+		if err = m.authorize(ctx, client, authzURL); err != nil {
+			return nil, err
+		}
+	}
+
+	if order, err = client.WaitOrder(ctx, order.URI); err != nil {
+		return nil, err
+	}
+
+	key, err := m.newCertificateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domain},
+		DNSNames: []string{domain},
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := encodeCertificate(key, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := decodeCertificate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = m.config.Cache.Put(ctx, domain, data); err != nil {
+		return nil, err
+	}
+
+	m.storeCertificate(domain, cert)
+	return cert, nil
+}
+
+// authorize completes the authorization of the "authzURL",
+// with the first solver that its challenge type is offered.
+func (m *Manager) authorize(ctx context.Context, client *xacme.Client, authzURL string) error {
+	authz, err := client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return err
+	}
+
+	if authz.Status == xacme.StatusValid {
+		return nil
+	}
+
+	domain := authz.Identifier.Value
+	if authz.Wildcard {
+		domain = "*." + domain
+	}
+
+	solver, chal := m.findSolver(authz)
+	if solver == nil {
+		return errNoChallenge.Format(domain)
+	}
+
+	// "tls-alpn-01" and "http-01" are verified against the domain without the wildcard prefix.
+	identifier := authz.Identifier.Value
+	if err = solver.Present(ctx, client, identifier, chal); err != nil {
+		return errAuthorization.Format(domain, err)
+	}
+	defer solver.CleanUp(ctx, client, identifier, chal)
+
+	if _, err = client.Accept(ctx, chal); err != nil {
+		return errAuthorization.Format(domain, err)
+	}
+
+	if _, err = client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return errAuthorization.Format(domain, err)
+	}
+
+	return nil
+}
+
+func (m *Manager) findSolver(authz *xacme.Authorization) (Solver, *xacme.Challenge) {
+	for _, solver := range m.solvers {
+		for _, chal := range authz.Challenges {
+			if chal.Type == solver.Type() {
+				return solver, chal
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (m *Manager) newCertificateKey() (crypto.Signer, error) {
+	if m.config.ForceRSA {
+		return rsa.GenerateKey(rand.Reader, 2048)
+	}
+
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// acmeClient returns the registered ACME client,
+// the account key is loaded from the cache or generated and stored on the first call.
+func (m *Manager) acmeClient(ctx context.Context) (*xacme.Client, error) {
+	m.clientMu.Lock()
+	defer m.clientMu.Unlock()
+
+	if m.client != nil {
+		return m.client, nil
+	}
+
+	key, err := m.accountKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &xacme.Client{
+		Key:          key,
+		DirectoryURL: m.config.DirectoryURL,
+		HTTPClient:   m.config.HTTPClient,
+		UserAgent:    "iris",
+	}
+
+	account := new(xacme.Account)
+	if m.config.Email != "" {
+		account.Contact = []string{"mailto:" + m.config.Email}
+	}
+
+	if _, err = client.Register(ctx, account, xacme.AcceptTOS); err != nil && err != xacme.ErrAccountAlreadyExists {
+		return nil, err
+	}
+
+	m.client = client
+	return client, nil
+}
+
+func (m *Manager) accountKey(ctx context.Context) (crypto.Signer, error) {
+	data, err := m.config.Cache.Get(ctx, accountKeyCacheKey)
+	if err == nil {
+		if block, _ := pem.Decode(data); block != nil {
+			return decodeKey(block)
+		}
+		return nil, errCacheInvalidKey
+	}
+
+	if err != ErrCacheMiss {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b})
+	if err = m.config.Cache.Put(ctx, accountKeyCacheKey, data); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// HTTPChallengeEnabled reports whether the "http-01" challenge solver is enabled,
+// if true then the `HTTPHandler` should be served on port 80.
+func (m *Manager) HTTPChallengeEnabled() bool {
+	return !m.config.DisableHTTPChallenge
+}
+
+// HTTPHandler returns a handler which serves the "http-01" challenges,
+// all other requests are served by the "fallback" handler,
+// if nil then they're redirected to their https version.
+func (m *Manager) HTTPHandler(fallback http.Handler) http.Handler {
+	if fallback == nil {
+		fallback = http.HandlerFunc(redirectHTTPS)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/.well-known/acme-challenge/") {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+
+		response, ok := m.http01.response(r.URL.Path)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(response))
+	})
+}
+
+func redirectHTTPS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Use HTTPS", http.StatusBadRequest)
+		return
+	}
+
+	host := r.Host
+	if hostOnly, _, err := net.SplitHostPort(host); err == nil {
+		host = hostOnly
+	}
+
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusFound)
+}
+
+// TLSConfig returns a new `tls.Config` which serves the manager's certificates
+// and completes the "tls-alpn-01" challenges.
+func (m *Manager) TLSConfig() *tls.Config {
+	nextProtos := []string{"h2", "http/1.1"}
+	if !m.config.DisableTLSALPNChallenge {
+		nextProtos = append(nextProtos, xacme.ALPNProto)
+	}
+
+	return &tls.Config{
+		GetCertificate:           m.GetCertificate,
+		PreferServerCipherSuites: true,
+		NextProtos:               nextProtos,
+	}
+}
+
+// Watch checks the certificates every "interval" and renews
+// those that expire in less than the `Config.RenewBefore`,
+// see `RegisterOnRenew` and `RegisterOnError` too.
+// It returns a function which stops the watcher.
+func (m *Manager) Watch(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				m.renewExpiring(done)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+func (m *Manager) renewExpiring(done chan struct{}) {
+	var domains []string
+	m.mu.RLock()
+	for domain, cert := range m.certs {
+		if time.Until(cert.Leaf.NotAfter) < m.config.RenewBefore {
+			domains = append(domains, domain)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, domain := range domains {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		m.Obtain(ctx, domain)
+		cancel()
+	}
+}
@@ -0,0 +1,17 @@
+// +build go1.17
+
+package acme
+
+import (
+	"context"
+	"crypto/tls"
+)
+
+// helloContext returns the context of the TLS handshake, if any.
+func helloContext(hello *tls.ClientHelloInfo) context.Context {
+	if ctx := hello.Context(); ctx != nil {
+		return ctx
+	}
+
+	return context.Background()
+}
@@ -0,0 +1,14 @@
+// +build !go1.17
+
+package acme
+
+import (
+	"context"
+	"crypto/tls"
+)
+
+// helloContext returns a background context,
+// the `tls.ClientHelloInfo` does not expose the context of the TLS handshake before go1.17.
+func helloContext(hello *tls.ClientHelloInfo) context.Context {
+	return context.Background()
+}
@@ -0,0 +1,164 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	xacme "golang.org/x/crypto/acme"
+)
+
+func TestCertificateEncoding(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := encodeCertificate(key, [][]byte{der})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewMemCache()
+	if _, err = cache.Get(context.Background(), "example.com"); err != ErrCacheMiss {
+		t.Fatalf("expected a cache miss but got: %v", err)
+	}
+
+	cache.Put(context.Background(), "example.com", data)
+	if data, err = cache.Get(context.Background(), "example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := decodeCertificate(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := "example.com", cert.Leaf.Subject.CommonName; expected != got {
+		t.Fatalf("expected common name %s but got %s", expected, got)
+	}
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	data, _ = encodeCertificate(otherKey, [][]byte{der})
+	if _, err = decodeCertificate(data); err == nil {
+		t.Fatalf("expected an error when the key does not match the certificate")
+	}
+}
+
+func TestManagerHostPolicy(t *testing.T) {
+	domains := []string{"Example.com.", "*.example.org"}
+	m := New(Config{Domains: domains})
+	if domains[0] != "Example.com." {
+		t.Fatalf("expected the configured domains to not be modified but got %q", domains[0])
+	}
+
+	tests := []struct {
+		host     string
+		certName string
+	}{
+		{"example.com", "example.com"},
+		{"www.example.com", ""},
+		{"example.org", ""},
+		{"www.example.org", "*.example.org"},
+		{"a.b.example.org", ""},
+	}
+
+	for i, tt := range tests {
+		if got := m.certName(tt.host); got != tt.certName {
+			t.Fatalf("[%d] expected certificate name %q for %q but got %q", i, tt.certName, tt.host, got)
+		}
+	}
+
+	if _, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.com"}); err == nil {
+		t.Fatalf("expected an error for a host which is not allowed")
+	}
+
+	if expected, got := "_acme-challenge.example.org.", dns01FQDN("*.example.org"); expected != got {
+		t.Fatalf("expected fqdn %s but got %s", expected, got)
+	}
+}
+
+func TestManagerChallenges(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &xacme.Client{Key: key}
+	chal := &xacme.Challenge{Token: "token"}
+
+	m := New(Config{Domains: []string{"example.com"}})
+
+	// http-01.
+	if err = m.http01.Present(context.Background(), client, "example.com", chal); err != nil {
+		t.Fatal(err)
+	}
+
+	h := m.HTTPHandler(nil)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/.well-known/acme-challenge/token", nil))
+	expected, _ := client.HTTP01ChallengeResponse("token")
+	if got := rec.Body.String(); rec.Code != http.StatusOK || got != expected {
+		t.Fatalf("expected the challenge response %q but got %d %q", expected, rec.Code, got)
+	}
+
+	m.http01.CleanUp(context.Background(), client, "example.com", chal)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/.well-known/acme-challenge/token", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected not found after clean up but got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com:80/path?q=1", nil))
+	if expected, got := "https://example.com/path?q=1", rec.Header().Get("Location"); rec.Code != http.StatusFound || got != expected {
+		t.Fatalf("expected a redirect to %s but got %d %s", expected, rec.Code, got)
+	}
+
+	// tls-alpn-01.
+	hello := &tls.ClientHelloInfo{ServerName: "example.com", SupportedProtos: []string{xacme.ALPNProto}}
+	if _, err = m.GetCertificate(hello); err == nil {
+		t.Fatalf("expected an error when there is no challenge certificate")
+	}
+
+	if err = m.tlsALPN01.Present(context.Background(), client, "example.com", chal); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := m.GetCertificate(hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(leaf.DNSNames) != 1 || leaf.DNSNames[0] != "example.com" {
+		t.Fatalf("unexpected challenge certificate names: %v", leaf.DNSNames)
+	}
+}
@@ -0,0 +1,191 @@
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"sync"
+
+	"github.com/radiantrfid/iris/core/errors"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ErrCacheMiss should be returned by the `Cache.Get` when the key is not found.
+// It's the same as the `autocert.ErrCacheMiss` so the caches can be shared between the two.
+var ErrCacheMiss = autocert.ErrCacheMiss
+
+// Cache is the interface of the certificates and the account key storage.
+// It's compatible with the `autocert.Cache`.
+//
+// See the `DirCache` and `MemCache` and the `CertCache` of the
+// sessions/sessiondb/boltdb, badger and redis packages.
+type Cache interface {
+	// Get returns the data of the "key" or `ErrCacheMiss` if it does not exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put stores the "data" under the "key".
+	Put(ctx context.Context, key string, data []byte) error
+	// Delete removes the "key", it should not return an error if the key does not exist.
+	Delete(ctx context.Context, key string) error
+}
+
+// DirCache implements the `Cache` using a directory on the local filesystem.
+// If the directory does not exist, it will be created with 0700 permissions.
+type DirCache = autocert.DirCache
+
+// MemCache is an in-memory `Cache`,
+// the certificates are lost on restart, useful for tests and local CAs.
+type MemCache struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+var _ Cache = (*MemCache)(nil)
+
+// NewMemCache returns a new in-memory `Cache`.
+func NewMemCache() *MemCache {
+	return &MemCache{data: make(map[string][]byte)}
+}
+
+// Get returns the data of the "key" or `ErrCacheMiss` if it does not exist.
+func (c *MemCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.RLock()
+	data, ok := c.data[key]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	return data, nil
+}
+
+// Put stores the "data" under the "key".
+func (c *MemCache) Put(ctx context.Context, key string, data []byte) error {
+	c.mu.Lock()
+	c.data[key] = data
+	c.mu.Unlock()
+	return nil
+}
+
+// Delete removes the "key".
+func (c *MemCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	delete(c.data, key)
+	c.mu.Unlock()
+	return nil
+}
+
+var (
+	errCacheInvalidKey  = errors.New("acme: cache: invalid private key")
+	errCacheInvalidCert = errors.New("acme: cache: invalid certificate chain")
+	errCacheKeyMismatch = errors.New("acme: cache: private key does not match the certificate's public key")
+	errCacheKeyType     = errors.New("acme: cache: unknown private key type")
+)
+
+// encodeCertificate encodes the private key and the certificate chain
+// in the same PEM format as the `autocert` package does.
+func encodeCertificate(key crypto.Signer, chain [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeKey(&buf, key); err != nil {
+		return nil, err
+	}
+
+	for _, der := range chain {
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeKey(buf *bytes.Buffer, key crypto.Signer) error {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		b, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return err
+		}
+		return pem.Encode(buf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: b})
+	case *rsa.PrivateKey:
+		return pem.Encode(buf, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)})
+	default:
+		return errCacheKeyType
+	}
+}
+
+func decodeKey(block *pem.Block) (crypto.Signer, error) {
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+	}
+
+	return nil, errCacheKeyType
+}
+
+// decodeCertificate decodes the data that `encodeCertificate` produced.
+func decodeCertificate(data []byte) (*tls.Certificate, error) {
+	block, rest := pem.Decode(data)
+	if block == nil || !bytes.Contains([]byte(block.Type), []byte("PRIVATE")) {
+		return nil, errCacheInvalidKey
+	}
+
+	key, err := decodeKey(block)
+	if err != nil {
+		return nil, errCacheInvalidKey.AppendErr(err)
+	}
+
+	var chain [][]byte
+	for len(rest) > 0 {
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, errCacheInvalidCert
+		}
+		chain = append(chain, block.Bytes)
+	}
+
+	if len(chain) == 0 {
+		return nil, errCacheInvalidCert
+	}
+
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, errCacheInvalidCert.AppendErr(err)
+	}
+
+	if !publicKeysEqual(leaf.PublicKey, key.Public()) {
+		return nil, errCacheKeyMismatch
+	}
+
+	return &tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: leaf}, nil
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	type equaler interface {
+		Equal(crypto.PublicKey) bool
+	}
+
+	if e, ok := a.(equaler); ok {
+		return e.Equal(b)
+	}
+
+	return false
+}
@@ -0,0 +1,184 @@
+package acme
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+	"sync"
+	"time"
+
+	xacme "golang.org/x/crypto/acme"
+)
+
+// The challenge types that the built'n solvers can complete.
+const (
+	// ChallengeHTTP01 is the "http-01" challenge type,
+	// the CA requests a token from the "/.well-known/acme-challenge/" path on port 80,
+	// see `Manager.HTTPHandler`.
+	ChallengeHTTP01 = "http-01"
+	// ChallengeTLSALPN01 is the "tls-alpn-01" challenge type,
+	// the CA connects on port 443 with the "acme-tls/1" protocol,
+	// see `Manager.GetCertificate`.
+	ChallengeTLSALPN01 = "tls-alpn-01"
+	// ChallengeDNS01 is the "dns-01" challenge type,
+	// the CA looks up a TXT record of the "_acme-challenge" subdomain,
+	// it's the only one that can be used for wildcard certificates.
+	ChallengeDNS01 = "dns-01"
+)
+
+// Solver completes a challenge of a specific type,
+// it should make the challenge's response available to the CA on `Present`
+// and remove it on `CleanUp`.
+type Solver interface {
+	// Type returns the challenge type this Solver completes, i.e "dns-01".
+	Type() string
+	// Present makes the response of the "chal" of the "domain" available to the CA.
+	Present(ctx context.Context, client *xacme.Client, domain string, chal *xacme.Challenge) error
+	// CleanUp removes the response of the "chal" of the "domain",
+	// it's called after the authorization is completed, even on failures.
+	CleanUp(ctx context.Context, client *xacme.Client, domain string, chal *xacme.Challenge) error
+}
+
+// http01Solver keeps the responses of the "http-01" challenges in memory,
+// they're served by the `Manager.HTTPHandler`.
+type http01Solver struct {
+	mu        sync.RWMutex
+	responses map[string]string // path:key authorization.
+}
+
+var _ Solver = (*http01Solver)(nil)
+
+func (s *http01Solver) Type() string { return ChallengeHTTP01 }
+
+func (s *http01Solver) Present(ctx context.Context, client *xacme.Client, domain string, chal *xacme.Challenge) error {
+	response, err := client.HTTP01ChallengeResponse(chal.Token)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.responses[client.HTTP01ChallengePath(chal.Token)] = response
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *http01Solver) CleanUp(ctx context.Context, client *xacme.Client, domain string, chal *xacme.Challenge) error {
+	s.mu.Lock()
+	delete(s.responses, client.HTTP01ChallengePath(chal.Token))
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *http01Solver) response(path string) (string, bool) {
+	s.mu.RLock()
+	response, ok := s.responses[path]
+	s.mu.RUnlock()
+	return response, ok
+}
+
+// tlsALPN01Solver keeps the challenge certificates of the "tls-alpn-01" challenges in memory,
+// they're served by the `Manager.GetCertificate`.
+type tlsALPN01Solver struct {
+	mu    sync.RWMutex
+	certs map[string]*tls.Certificate // domain:challenge certificate.
+}
+
+var _ Solver = (*tlsALPN01Solver)(nil)
+
+func (s *tlsALPN01Solver) Type() string { return ChallengeTLSALPN01 }
+
+func (s *tlsALPN01Solver) Present(ctx context.Context, client *xacme.Client, domain string, chal *xacme.Challenge) error {
+	cert, err := client.TLSALPN01ChallengeCert(chal.Token, domain)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.certs[domain] = &cert
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *tlsALPN01Solver) CleanUp(ctx context.Context, client *xacme.Client, domain string, chal *xacme.Challenge) error {
+	s.mu.Lock()
+	delete(s.certs, domain)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *tlsALPN01Solver) certificate(domain string) (*tls.Certificate, bool) {
+	s.mu.RLock()
+	cert, ok := s.certs[domain]
+	s.mu.RUnlock()
+	return cert, ok
+}
+
+// DNSProvider manages the TXT records of a DNS zone,
+// implementations usually call the API of a DNS hosting service.
+// See `DNS01`.
+type DNSProvider interface {
+	// SetRecord creates (or replaces) the TXT record of the "fqdn" with the "value",
+	// the "fqdn" is always ending with a dot, i.e "_acme-challenge.example.com.".
+	SetRecord(ctx context.Context, fqdn, value string) error
+	// RemoveRecord removes the TXT record of the "fqdn" with the "value".
+	RemoveRecord(ctx context.Context, fqdn, value string) error
+}
+
+// DNS01Solver is the "dns-01" challenge `Solver`, see `DNS01`.
+type DNS01Solver struct {
+	// Provider sets and removes the TXT records.
+	Provider DNSProvider
+	// PropagationDelay is the time to wait after the record is set
+	// and before the CA is told to verify it, DNS providers need some time
+	// to update their name servers.
+	//
+	// Defaults to zero.
+	PropagationDelay time.Duration
+}
+
+var _ Solver = (*DNS01Solver)(nil)
+
+// DNS01 returns a new "dns-01" challenge `Solver` which uses the "provider" to set the TXT records.
+// Add it to the `Config.Solvers` field.
+func DNS01(provider DNSProvider) *DNS01Solver {
+	return &DNS01Solver{Provider: provider}
+}
+
+// Type returns the "dns-01".
+func (s *DNS01Solver) Type() string { return ChallengeDNS01 }
+
+// Present sets the TXT record of the "_acme-challenge" subdomain of the "domain".
+func (s *DNS01Solver) Present(ctx context.Context, client *xacme.Client, domain string, chal *xacme.Challenge) error {
+	value, err := client.DNS01ChallengeRecord(chal.Token)
+	if err != nil {
+		return err
+	}
+
+	if err = s.Provider.SetRecord(ctx, dns01FQDN(domain), value); err != nil {
+		return err
+	}
+
+	if s.PropagationDelay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.PropagationDelay):
+		}
+	}
+
+	return nil
+}
+
+// CleanUp removes the TXT record of the "_acme-challenge" subdomain of the "domain".
+func (s *DNS01Solver) CleanUp(ctx context.Context, client *xacme.Client, domain string, chal *xacme.Challenge) error {
+	value, err := client.DNS01ChallengeRecord(chal.Token)
+	if err != nil {
+		return err
+	}
+
+	return s.Provider.RemoveRecord(ctx, dns01FQDN(domain), value)
+}
+
+func dns01FQDN(domain string) string {
+	return "_acme-challenge." + strings.TrimSuffix(strings.TrimPrefix(domain, "*."), ".") + "."
+}
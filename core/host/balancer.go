@@ -0,0 +1,252 @@
+package host
+
+import (
+	"hash/fnv"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Upstream is a back-end server of a `LoadBalancer`.
+type Upstream struct {
+	// URL is the scheme, host and the, optional, base path of the upstream.
+	URL *url.URL
+
+	unhealthy uint32 // 1 when the active health check failed.
+	conns     int64  // in-flight requests.
+	breaker   *circuitBreaker
+}
+
+// Healthy reports whether the last active health check of the upstream succeeded
+// and its circuit breaker is not open.
+func (u *Upstream) Healthy() bool {
+	return atomic.LoadUint32(&u.unhealthy) == 0 && u.breaker.state() != breakerOpen
+}
+
+// Available reports whether the upstream can accept a request,
+// it's used by the `Balancer` implementations.
+func (u *Upstream) Available() bool {
+	return atomic.LoadUint32(&u.unhealthy) == 0 && u.breaker.ready()
+}
+
+// Connections returns the number of the in-flight requests to the upstream.
+func (u *Upstream) Connections() int64 {
+	return atomic.LoadInt64(&u.conns)
+}
+
+func (u *Upstream) setHealthy(healthy bool) {
+	var v uint32
+	if !healthy {
+		v = 1
+	}
+	atomic.StoreUint32(&u.unhealthy, v)
+}
+
+// Balancer selects the upstream which should serve a request.
+// See `RoundRobin`, `LeastConnections` and `ConsistentHash`.
+type Balancer interface {
+	// Next returns one of the `Upstream.Available` "upstreams" for the "r" request
+	// or nil if none of them is available.
+	Next(r *http.Request, upstreams []*Upstream) *Upstream
+}
+
+type roundRobin struct {
+	counter uint32
+}
+
+// RoundRobin returns a `Balancer` which selects the available upstreams in turn.
+// It's the default `LoadBalancerConfig.Balancer`.
+func RoundRobin() Balancer {
+	return new(roundRobin)
+}
+
+func (b *roundRobin) Next(r *http.Request, upstreams []*Upstream) *Upstream {
+	n := uint32(len(upstreams))
+	start := atomic.AddUint32(&b.counter, 1)
+	for i := uint32(0); i < n; i++ {
+		if u := upstreams[(start+i)%n]; u.Available() {
+			return u
+		}
+	}
+
+	return nil
+}
+
+type leastConnections struct{}
+
+// LeastConnections returns a `Balancer` which selects the available upstream
+// with the fewest in-flight requests, the first one wins on ties.
+func LeastConnections() Balancer {
+	return leastConnections{}
+}
+
+func (leastConnections) Next(r *http.Request, upstreams []*Upstream) *Upstream {
+	var best *Upstream
+	for _, u := range upstreams {
+		if !u.Available() {
+			continue
+		}
+
+		if best == nil || u.Connections() < best.Connections() {
+			best = u
+		}
+	}
+
+	return best
+}
+
+type consistentHash struct {
+	key func(r *http.Request) string
+}
+
+// ConsistentHash returns a `Balancer` which always selects the same upstream for the same key,
+// while it's available. When an upstream is added or becomes unavailable
+// only its own keys are moved to the rest of the upstreams (rendezvous hashing).
+//
+// The "key" function returns the key of a request, i.e a header or a cookie value.
+// If nil, the client's IP address is used.
+func ConsistentHash(key func(r *http.Request) string) Balancer {
+	if key == nil {
+		key = clientIP
+	}
+
+	return &consistentHash{key: key}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func (b *consistentHash) Next(r *http.Request, upstreams []*Upstream) *Upstream {
+	key := b.key(r)
+
+	var (
+		best      *Upstream
+		bestScore uint64
+	)
+
+	for _, u := range upstreams {
+		if !u.Available() {
+			continue
+		}
+
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte(u.URL.Host))
+		if score := h.Sum64(); best == nil || score > bestScore {
+			best, bestScore = u, score
+		}
+	}
+
+	return best
+}
+
+const (
+	breakerClosed uint32 = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker stops sending requests to an upstream after "threshold" consecutive failures
+// for "timeout", then it lets a single trial request pass (half-open),
+// its success closes the circuit, otherwise it's opened again.
+type circuitBreaker struct {
+	threshold int
+	timeout   time.Duration
+
+	mu       sync.Mutex
+	current  uint32
+	failures int
+	openedAt time.Time
+	trial    bool // a half-open trial request is in-flight.
+}
+
+func (cb *circuitBreaker) state() uint32 {
+	cb.mu.Lock()
+	s := cb.current
+	cb.mu.Unlock()
+	return s
+}
+
+// ready reports whether the next `allow` would succeed.
+func (cb *circuitBreaker) ready() bool {
+	if cb.threshold <= 0 {
+		return true
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.current {
+	case breakerOpen:
+		return time.Since(cb.openedAt) >= cb.timeout
+	case breakerHalfOpen:
+		return !cb.trial
+	default:
+		return true
+	}
+}
+
+// allow reports whether a request can be sent,
+// an open circuit becomes half-open after its timeout.
+func (cb *circuitBreaker) allow() bool {
+	if cb.threshold <= 0 {
+		return true
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.current {
+	case breakerOpen:
+		if time.Since(cb.openedAt) < cb.timeout {
+			return false
+		}
+		cb.current = breakerHalfOpen
+		cb.trial = true
+		return true
+	case breakerHalfOpen:
+		if cb.trial {
+			return false
+		}
+		cb.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (cb *circuitBreaker) success() {
+	if cb.threshold <= 0 {
+		return
+	}
+
+	cb.mu.Lock()
+	cb.current = breakerClosed
+	cb.failures = 0
+	cb.trial = false
+	cb.mu.Unlock()
+}
+
+func (cb *circuitBreaker) failure() {
+	if cb.threshold <= 0 {
+		return
+	}
+
+	cb.mu.Lock()
+	cb.failures++
+	if cb.current == breakerHalfOpen || cb.failures >= cb.threshold {
+		cb.current = breakerOpen
+		cb.openedAt = time.Now()
+		cb.trial = false
+	}
+	cb.mu.Unlock()
+}
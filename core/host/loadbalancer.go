@@ -0,0 +1,505 @@
+package host
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/radiantrfid/iris/core/errors"
+	"github.com/radiantrfid/iris/core/netutil"
+)
+
+var (
+	errNoUpstreams      = errors.New("load balancer: at least one upstream is required")
+	errInvalidUpstream  = errors.New("load balancer: invalid upstream %q: %v")
+	errNoUpstream       = errors.New("load balancer: no available upstream")
+	errUpstreamResponse = errors.New("load balancer: upstream %s responded with %d")
+)
+
+// HeaderRewrite sets and removes headers of the proxied requests or responses.
+type HeaderRewrite struct {
+	// Set sets (replaces) the headers.
+	Set map[string]string
+	// Delete removes the headers.
+	Delete []string
+}
+
+func (h HeaderRewrite) apply(header http.Header) {
+	for _, key := range h.Delete {
+		header.Del(key)
+	}
+
+	for key, value := range h.Set {
+		header.Set(key, value)
+	}
+}
+
+// LoadBalancerConfig is the configuration of the `LoadBalancer`, see `NewLoadBalancer`.
+type LoadBalancerConfig struct {
+	// Upstreams are the URLs of the back-end servers, i.e "http://10.0.0.1:8080".
+	// A path, i.e "http://10.0.0.1:8080/api", is prepended to the requested path.
+	Upstreams []string
+	// Balancer selects the upstream of each request,
+	// see `RoundRobin`, `LeastConnections` and `ConsistentHash`.
+	//
+	// Defaults to `RoundRobin`.
+	Balancer Balancer
+	// Transport is used to send the requests to the upstreams and for the health checks.
+	//
+	// Defaults to the `http.DefaultTransport`,
+	// certificates of loopback upstreams are not verified.
+	Transport http.RoundTripper
+	// PreserveHost when true the "Host" header of the incoming request is sent to the upstreams,
+	// otherwise the upstream's host is used.
+	//
+	// Defaults to false.
+	PreserveHost bool
+	// MaxRetries is the number of the other upstreams to try when an upstream
+	// couldn't be reached or responded with 502, 503 or 504,
+	// only idempotent requests without a body are retried.
+	// Negative value disables retries.
+	//
+	// Defaults to 2.
+	MaxRetries int
+	// HealthCheckPath enables the active health checks, when not empty
+	// each upstream is requested on that path every `HealthCheckInterval`,
+	// a response status code other than 2xx and 3xx marks it as unhealthy until the next successful check.
+	HealthCheckPath string
+	// HealthCheckInterval is the time between the active health checks.
+	//
+	// Defaults to 10 seconds.
+	HealthCheckInterval time.Duration
+	// HealthCheckTimeout is the timeout of a health check request.
+	//
+	// Defaults to 5 seconds.
+	HealthCheckTimeout time.Duration
+	// BreakerThreshold is the number of the consecutive failures (unreachable upstream or 502, 503 and 504 responses)
+	// that opens the circuit of an upstream (passive health check),
+	// no requests are sent to it for `BreakerTimeout`, then a single trial request decides
+	// if the circuit should be closed or opened again.
+	// Negative value disables the circuit breaker.
+	//
+	// Defaults to 5.
+	BreakerThreshold int
+	// BreakerTimeout is the time that the circuit of an upstream stays open.
+	//
+	// Defaults to 30 seconds.
+	BreakerTimeout time.Duration
+	// RequestHeaders rewrites the headers of the requests sent to the upstreams.
+	RequestHeaders HeaderRewrite
+	// ResponseHeaders rewrites the headers of the upstreams' responses.
+	ResponseHeaders HeaderRewrite
+}
+
+// LoadBalancer is a reverse proxy which distributes the requests between multiple upstreams,
+// it checks their health, retries idempotent requests on a different upstream
+// and proxies the websocket (upgrade) connections as well.
+//
+// It's an `http.Handler`, mount it on a Party with its `HandleProxy` method
+// or use the `NewLoadBalancerProxy` to serve it on its own host.
+type LoadBalancer struct {
+	upstreams  []*Upstream
+	balancer   Balancer
+	transport  http.RoundTripper
+	maxRetries int
+	config     LoadBalancerConfig
+
+	proxy *httputil.ReverseProxy
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+var _ http.Handler = (*LoadBalancer)(nil)
+
+// NewLoadBalancer returns a new `LoadBalancer` based on the "cfg",
+// the active health checks, if enabled, start immediately, see `Close`.
+func NewLoadBalancer(cfg LoadBalancerConfig) (*LoadBalancer, error) {
+	if len(cfg.Upstreams) == 0 {
+		return nil, errNoUpstreams
+	}
+
+	if cfg.Balancer == nil {
+		cfg.Balancer = RoundRobin()
+	}
+
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 2
+	}
+
+	if cfg.HealthCheckInterval <= 0 {
+		cfg.HealthCheckInterval = 10 * time.Second
+	}
+
+	if cfg.HealthCheckTimeout <= 0 {
+		cfg.HealthCheckTimeout = 5 * time.Second
+	}
+
+	if cfg.BreakerThreshold == 0 {
+		cfg.BreakerThreshold = 5
+	}
+
+	if cfg.BreakerTimeout <= 0 {
+		cfg.BreakerTimeout = 30 * time.Second
+	}
+
+	lb := &LoadBalancer{
+		balancer:   cfg.Balancer,
+		maxRetries: cfg.MaxRetries,
+		config:     cfg,
+		stop:       make(chan struct{}),
+	}
+
+	allLoopback := true
+	for _, rawurl := range cfg.Upstreams {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, errInvalidUpstream.Format(rawurl, err)
+		}
+
+		if u.Scheme == "" || u.Host == "" {
+			return nil, errInvalidUpstream.Format(rawurl, "missing scheme or host")
+		}
+
+		allLoopback = allLoopback && netutil.IsLoopbackHost(u.Host)
+		lb.upstreams = append(lb.upstreams, &Upstream{
+			URL:     u,
+			breaker: &circuitBreaker{threshold: cfg.BreakerThreshold, timeout: cfg.BreakerTimeout},
+		})
+	}
+
+	lb.transport = cfg.Transport
+	if lb.transport == nil {
+		lb.transport = http.DefaultTransport
+		if allLoopback {
+			lb.transport = &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			}
+		}
+	}
+
+	lb.proxy = &httputil.ReverseProxy{
+		Director:       lb.director,
+		Transport:      &balancedTransport{lb},
+		ModifyResponse: lb.modifyResponse,
+		ErrorHandler:   lb.errorHandler,
+	}
+
+	if cfg.HealthCheckPath != "" {
+		go lb.healthChecks()
+	}
+
+	return lb, nil
+}
+
+// Upstreams returns the upstreams of the load balancer, i.e to check their health.
+func (lb *LoadBalancer) Upstreams() []*Upstream {
+	return lb.upstreams
+}
+
+// ServeHTTP proxies the request to one of the upstreams.
+func (lb *LoadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lb.proxy.ServeHTTP(w, r)
+}
+
+// Close stops the active health checks and closes the idle connections to the upstreams.
+func (lb *LoadBalancer) Close() error {
+	lb.stopOnce.Do(func() {
+		close(lb.stop)
+		if t, ok := lb.transport.(interface{ CloseIdleConnections() }); ok {
+			t.CloseIdleConnections()
+		}
+	})
+
+	return nil
+}
+
+// director prepares the outgoing request, the upstream is selected later on by the `balancedTransport`.
+func (lb *LoadBalancer) director(req *http.Request) {
+	if _, ok := req.Header["User-Agent"]; !ok {
+		// explicitly disable User-Agent so it's not set to default value
+		req.Header.Set("User-Agent", "")
+	}
+
+	if req.Header.Get("X-Forwarded-Host") == "" {
+		req.Header.Set("X-Forwarded-Host", req.Host)
+	}
+
+	if req.Header.Get("X-Forwarded-Proto") == "" {
+		proto := "http"
+		if req.TLS != nil {
+			proto = "https"
+		}
+		req.Header.Set("X-Forwarded-Proto", proto)
+	}
+
+	lb.config.RequestHeaders.apply(req.Header)
+}
+
+func (lb *LoadBalancer) modifyResponse(resp *http.Response) error {
+	lb.config.ResponseHeaders.apply(resp.Header)
+	return nil
+}
+
+func (lb *LoadBalancer) errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errNoUpstream.Equal(err):
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case r.Context().Err() == context.DeadlineExceeded:
+		w.WriteHeader(http.StatusGatewayTimeout)
+	default:
+		w.WriteHeader(http.StatusBadGateway)
+	}
+}
+
+// next returns the upstream for the "r" request, skipping the already "tried" ones.
+func (lb *LoadBalancer) next(r *http.Request, tried []*Upstream) *Upstream {
+	if len(tried) == 0 {
+		return lb.balancer.Next(r, lb.upstreams)
+	}
+
+	candidates := make([]*Upstream, 0, len(lb.upstreams)-len(tried))
+	for _, u := range lb.upstreams {
+		if !containsUpstream(tried, u) {
+			candidates = append(candidates, u)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return lb.balancer.Next(r, candidates)
+}
+
+func containsUpstream(upstreams []*Upstream, u *Upstream) bool {
+	for _, upstream := range upstreams {
+		if upstream == u {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (lb *LoadBalancer) healthChecks() {
+	client := &http.Client{
+		Transport: lb.transport,
+		Timeout:   lb.config.HealthCheckTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	ticker := time.NewTicker(lb.config.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, u := range lb.upstreams {
+			u.setHealthy(checkHealth(client, u, lb.config.HealthCheckPath))
+		}
+
+		select {
+		case <-lb.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkHealth(client *http.Client, u *Upstream, path string) bool {
+	target := *u.URL
+	target.Path = singleJoiningSlash(u.URL.Path, path)
+
+	resp, err := client.Get(target.String())
+	if err != nil {
+		return false
+	}
+
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 400
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func isUpstreamFailure(statusCode int) bool {
+	return statusCode == http.StatusBadGateway ||
+		statusCode == http.StatusServiceUnavailable ||
+		statusCode == http.StatusGatewayTimeout
+}
+
+// balancedTransport selects the upstream of each attempt and retries the idempotent requests.
+type balancedTransport struct {
+	lb *LoadBalancer
+}
+
+func (t *balancedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	lb := t.lb
+
+	// the `httputil.ReverseProxy` sets a nil body on empty requests.
+	retryable := lb.maxRetries > 0 && isIdempotent(req.Method) && (req.Body == nil || req.Body == http.NoBody)
+	path, rawQuery := req.URL.Path, req.URL.RawQuery
+
+	var (
+		// tried holds the upstreams which are excluded from the next selections,
+		// including the ones skipped by an open circuit breaker,
+		// only the real round trips are counted by "attempts" though.
+		tried    []*Upstream
+		attempts int
+		lastErr  error
+	)
+
+	for {
+		u := lb.next(req, tried)
+		if u == nil {
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, errNoUpstream
+		}
+
+		tried = append(tried, u)
+		if !u.breaker.allow() {
+			continue
+		}
+
+		attempts++
+
+		outreq := req
+		if attempts > 1 {
+			outreq = cloneRequest(req)
+		}
+
+		outreq.URL.Scheme = u.URL.Scheme
+		outreq.URL.Host = u.URL.Host
+		outreq.URL.Path = singleJoiningSlash(u.URL.Path, path)
+		if u.URL.RawQuery == "" || rawQuery == "" {
+			outreq.URL.RawQuery = u.URL.RawQuery + rawQuery
+		} else {
+			outreq.URL.RawQuery = u.URL.RawQuery + "&" + rawQuery
+		}
+
+		if !lb.config.PreserveHost {
+			outreq.Host = u.URL.Host
+		}
+
+		atomic.AddInt64(&u.conns, 1)
+		done := func() { atomic.AddInt64(&u.conns, -1) }
+
+		resp, err := lb.transport.RoundTrip(outreq)
+		if err == nil && !isUpstreamFailure(resp.StatusCode) {
+			u.breaker.success()
+			resp.Body = wrapUpstreamBody(resp.Body, done)
+			return resp, nil
+		}
+
+		u.breaker.failure()
+
+		canRetry := retryable && attempts <= lb.maxRetries && req.Context().Err() == nil
+		if err != nil {
+			done()
+			lastErr = err
+			if canRetry {
+				continue
+			}
+			return nil, err
+		}
+
+		if canRetry {
+			resp.Body.Close()
+			done()
+			lastErr = errUpstreamResponse.Format(u.URL.Host, resp.StatusCode)
+			continue
+		}
+
+		resp.Body = wrapUpstreamBody(resp.Body, done)
+		return resp, nil
+	}
+}
+
+// cloneRequest returns a shallow copy of the "r" request
+// with its own URL and Header, so a retry can target another upstream.
+func cloneRequest(r *http.Request) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	u2 := *r.URL
+	r2.URL = &u2
+	r2.Header = cloneHeader(r.Header)
+	return r2
+}
+
+func cloneHeader(h http.Header) http.Header {
+	h2 := make(http.Header, len(h))
+	for k, vv := range h {
+		vv2 := make([]string, len(vv))
+		copy(vv2, vv)
+		h2[k] = vv2
+	}
+	return h2
+}
+
+// wrapUpstreamBody calls the "done" once, when the response body is closed,
+// the switching protocols (websocket) responses' bodies are writable too.
+func wrapUpstreamBody(body io.ReadCloser, done func()) io.ReadCloser {
+	b := &upstreamBody{ReadCloser: body, done: done}
+	if rw, ok := body.(io.ReadWriteCloser); ok {
+		return &upstreamRWBody{upstreamBody: b, Writer: rw}
+	}
+
+	return b
+}
+
+type upstreamBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *upstreamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+type upstreamRWBody struct {
+	*upstreamBody
+	io.Writer
+}
+
+// NewLoadBalancerProxy returns a new host (server supervisor) which
+// distributes all requests between the upstreams of the "cfg", see `NewLoadBalancer`.
+//
+// Usage:
+// cfg := LoadBalancerConfig{Upstreams: []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"}, HealthCheckPath: "/health"}
+// proxy, err := NewLoadBalancerProxy(":80", cfg)
+// proxy.ListenAndServe() // use of `proxy.Shutdown` to close the proxy server.
+func NewLoadBalancerProxy(hostAddr string, cfg LoadBalancerConfig) (*Supervisor, error) {
+	lb, err := NewLoadBalancer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := New(&http.Server{
+		Addr:    hostAddr,
+		Handler: lb,
+	})
+	proxy.RegisterOnShutdown(func() { lb.Close() })
+
+	return proxy, nil
+}
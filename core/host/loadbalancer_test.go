@@ -0,0 +1,266 @@
+// black-box testing
+package host_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	stdhttptest "net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/radiantrfid/iris"
+	"github.com/radiantrfid/iris/core/host"
+	"github.com/radiantrfid/iris/httptest"
+)
+
+func newUpstream(name string) *stdhttptest.Server {
+	return stdhttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(name + " " + r.URL.Path))
+	}))
+}
+
+func TestLoadBalancerRoundRobin(t *testing.T) {
+	up1, up2 := newUpstream("up1"), newUpstream("up2")
+	defer up1.Close()
+	defer up2.Close()
+
+	lb, err := host.NewLoadBalancer(host.LoadBalancerConfig{
+		Upstreams:       []string{up1.URL, up2.URL},
+		ResponseHeaders: host.HeaderRewrite{Set: map[string]string{"X-Proxy": "iris"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Close()
+
+	app := iris.New()
+	app.Party("/api").HandleProxy("/users", lb)
+
+	e := httptest.New(t, app)
+	got := map[string]int{}
+	for i := 0; i < 4; i++ {
+		resp := e.GET("/api/users/42").Expect().Status(iris.StatusOK)
+		resp.Header("X-Proxy").Equal("iris")
+		got[resp.Body().Raw()]++
+	}
+
+	if got["up1 /42"] != 2 || got["up2 /42"] != 2 {
+		t.Fatalf("expected the requests to be distributed equally but got: %v", got)
+	}
+}
+
+func TestLoadBalancerDynamicParty(t *testing.T) {
+	up := newUpstream("up")
+	defer up.Close()
+
+	lb, err := host.NewLoadBalancer(host.LoadBalancerConfig{Upstreams: []string{up.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Close()
+
+	app := iris.New()
+	app.Party("/tenants/{id:uint64}").HandleProxy("/users", lb)
+
+	e := httptest.New(t, app)
+	e.GET("/tenants/1/users/42").Expect().Status(iris.StatusOK).Body().Equal("up /42")
+	e.GET("/tenants/2/users/42/posts").Expect().Status(iris.StatusOK).Body().Equal("up /42/posts")
+	e.GET("/tenants/other/users/42").Expect().Status(iris.StatusNotFound)
+}
+
+func TestLoadBalancerRetryAndBreaker(t *testing.T) {
+	up := newUpstream("up")
+	defer up.Close()
+
+	down := newUpstream("down")
+	down.Close() // connection refused.
+
+	lb, err := host.NewLoadBalancer(host.LoadBalancerConfig{
+		Upstreams:        []string{down.URL, up.URL},
+		BreakerThreshold: 2,
+		BreakerTimeout:   time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Close()
+
+	app := iris.New()
+	app.HandleProxy("/", lb)
+
+	e := httptest.New(t, app)
+	for i := 0; i < 4; i++ {
+		e.GET("/").Expect().Status(iris.StatusOK).Body().Equal("up /")
+	}
+
+	// not idempotent, no retries.
+	e.POST("/").WithText("body").Expect().Status(iris.StatusOK).Body().Equal("up /")
+
+	if lb.Upstreams()[0].Healthy() {
+		t.Fatalf("expected the circuit of the unreachable upstream to be open")
+	}
+
+	if !lb.Upstreams()[1].Healthy() {
+		t.Fatalf("expected the reachable upstream to be healthy")
+	}
+}
+
+func TestLoadBalancerHealthCheck(t *testing.T) {
+	up := newUpstream("up")
+	defer up.Close()
+
+	sick := stdhttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("sick"))
+	}))
+	defer sick.Close()
+
+	lb, err := host.NewLoadBalancer(host.LoadBalancerConfig{
+		Upstreams:           []string{sick.URL, up.URL},
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for lb.Upstreams()[0].Healthy() {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the upstream to be marked as unhealthy")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	app := iris.New()
+	app.HandleProxy("/", lb)
+
+	e := httptest.New(t, app)
+	for i := 0; i < 3; i++ {
+		e.GET("/").Expect().Status(iris.StatusOK).Body().Equal("up /")
+	}
+}
+
+func TestLoadBalancerConsistentHash(t *testing.T) {
+	var upstreams []string
+	for _, name := range []string{"up1", "up2", "up3"} {
+		up := newUpstream(name)
+		defer up.Close()
+		upstreams = append(upstreams, up.URL)
+	}
+
+	lb, err := host.NewLoadBalancer(host.LoadBalancerConfig{
+		Upstreams: upstreams,
+		Balancer: host.ConsistentHash(func(r *http.Request) string {
+			return r.Header.Get("X-User")
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Close()
+
+	app := iris.New()
+	app.HandleProxy("/", lb)
+
+	e := httptest.New(t, app)
+	for _, user := range []string{"a", "b", "c", "d"} {
+		expected := e.GET("/").WithHeader("X-User", user).Expect().Status(iris.StatusOK).Body().Raw()
+		for i := 0; i < 3; i++ {
+			e.GET("/").WithHeader("X-User", user).Expect().Status(iris.StatusOK).Body().Equal(expected)
+		}
+	}
+}
+
+func TestLoadBalancerNoUpstream(t *testing.T) {
+	if _, err := host.NewLoadBalancer(host.LoadBalancerConfig{}); err == nil {
+		t.Fatalf("expected an error when no upstreams are given")
+	}
+
+	down := newUpstream("down")
+	down.Close()
+
+	lb, err := host.NewLoadBalancer(host.LoadBalancerConfig{Upstreams: []string{down.URL}, BreakerThreshold: 1, BreakerTimeout: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Close()
+
+	app := iris.New()
+	app.HandleProxy("/", lb)
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(iris.StatusBadGateway)
+	e.GET("/").Expect().Status(iris.StatusServiceUnavailable)
+}
+
+func TestLoadBalancerUpgrade(t *testing.T) {
+	// an echo server after a connection upgrade.
+	up := stdhttptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "echo" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		brw.Flush()
+		io.Copy(conn, brw)
+	}))
+	defer up.Close()
+
+	lb, err := host.NewLoadBalancer(host.LoadBalancerConfig{Upstreams: []string{up.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Close()
+
+	app := iris.New()
+	app.HandleProxy("/ws", lb)
+	if err = app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := stdhttptest.NewServer(app)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	io.WriteString(conn, "GET /ws/echo HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected status %d but got %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+
+	io.WriteString(conn, "ping\n")
+	line, err := br.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "ping\n"; line != expected {
+		t.Fatalf("expected %q to be echoed but got %q", expected, line)
+	}
+}
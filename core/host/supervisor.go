@@ -12,6 +12,7 @@ import (
 
 	"golang.org/x/crypto/acme/autocert"
 
+	"github.com/radiantrfid/iris/core/acme"
 	"github.com/radiantrfid/iris/core/errors"
 	"github.com/radiantrfid/iris/core/netutil"
 )
@@ -344,6 +345,37 @@ func (su *Supervisor) ListenAndServeAutoTLS(domain string, email string, cacheDi
 	return su.ListenAndServeTLS("", "")
 }
 
+// ListenAndServeACME acts identically to ListenAndServe, except that it
+// expects HTTPS connections. Server's certificates are obtained, cached and renewed
+// by the "certManager" from any ACME directory, i.e Let's Encrypt or a local Pebble CA.
+//
+// The "http-01" challenges are served by a new server on port 80,
+// which will redirect all other http requests to their https version,
+// unless the manager's "http-01" challenge is disabled.
+// The certificates are checked for renewal every hour.
+func (su *Supervisor) ListenAndServeACME(certManager *acme.Manager) error {
+	if certManager.HTTPChallengeEnabled() {
+		srv2 := &http.Server{
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 60 * time.Second,
+			Addr:         ":http",
+			Handler:      certManager.HTTPHandler(nil), // nil for redirect.
+		}
+
+		su.RegisterOnShutdown(func() {
+			timeout := 5 * time.Second
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			srv2.Shutdown(ctx)
+		})
+		go srv2.ListenAndServe()
+	}
+
+	su.RegisterOnShutdown(certManager.Watch(time.Hour))
+	su.Server.TLSConfig = certManager.TLSConfig()
+	return su.ListenAndServeTLS("", "")
+}
+
 // RegisterOnShutdown registers a function to call on Shutdown.
 // This can be used to gracefully shutdown connections that have
 // undergone NPN/ALPN protocol upgrade or that have been hijacked.
@@ -578,6 +578,11 @@ func (e Entry) BoolDefault(def bool) (bool, error) {
 	return def, errFindParse.Format("bool", e.Key)
 }
 
+// Immutable reports whether the entry was saved as immutable, i.e with `SetImmutable`.
+func (e Entry) Immutable() bool {
+	return e.immutable
+}
+
 // Value returns the value of the entry,
 // respects the immutable.
 func (e Entry) Value() interface{} {
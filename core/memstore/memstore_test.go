@@ -97,6 +97,9 @@ func TestImmutableSetOnlyWithSetImmutable(t *testing.T) {
 	var p Store
 
 	p.SetImmutable("objp", &myTestObject{"value"})
+	if e, _ := p.GetEntry("objp"); !e.Immutable() {
+		t.Fatalf("expected the entry to be immutable")
+	}
 
 	p.Set("objp", &myTestObject{"modified"})
 	vObjP := p.Get("objp").(myTestObject)
@@ -0,0 +1,339 @@
+package netutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/radiantrfid/iris/core/errors"
+)
+
+var (
+	errCertManagerEmpty   = errors.New("certificate manager: no certificates")
+	errCertManagerParse   = errors.New("certificate manager: couldn't parse the leaf of certFile=%q: %v")
+	errCertManagerCA      = errors.New("certificate manager: couldn't load client CA %q: %v")
+	errCertManagerNoMatch = errors.New("certificate manager: no certificate for server name %q")
+)
+
+type (
+	// CertManager serves multiple certificates selected by the
+	// client's requested server name (SNI) and reloads them,
+	// atomically, when their files are changed on disk, see `Watch`.
+	// It can also verify client certificates (mutual TLS) against one or more CA files.
+	//
+	// Use its `TLSConfig` on a custom server or pass it to the `ManagedTLS` runner of an iris Application,
+	// a `CertManager` can be shared between servers.
+	CertManager struct {
+		// ClientAuth is the policy the server will follow for
+		// TLS Client Authentication, see `AddClientCA`.
+		//
+		// Defaults to tls.NoClientCert, if client CAs added then to tls.RequireAndVerifyClientCert.
+		ClientAuth tls.ClientAuthType
+		// Strict when true the handshake fails if no certificate
+		// matches the requested server name,
+		// otherwise the first added certificate is used.
+		//
+		// Defaults to false.
+		Strict bool
+		// OnReload, if not nil, is called after each reload of changed files,
+		// the "err" is nil on success, otherwise the previous certificates are kept.
+		// It should be set before the `Watch`.
+		OnReload func(err error)
+
+		mu        sync.Mutex // protects the files and the reloads.
+		pairs     []certKeyPair
+		clientCAs []string
+		stamps    map[string]fileStamp
+
+		state atomic.Value // *certState.
+	}
+
+	certKeyPair struct {
+		certFile string
+		keyFile  string
+	}
+
+	fileStamp struct {
+		modTime time.Time
+		size    int64
+	}
+
+	certState struct {
+		byName    map[string]*tls.Certificate
+		fallback  *tls.Certificate
+		clientCAs *x509.CertPool
+	}
+)
+
+// NewCertManager returns a new certificate manager,
+// use its `AddPair` to load one or more certificates.
+func NewCertManager() *CertManager {
+	m := &CertManager{stamps: make(map[string]fileStamp)}
+	m.state.Store(&certState{byName: make(map[string]*tls.Certificate)})
+	return m
+}
+
+// AddPair loads a certificate and its private key,
+// the certificate is selected based on its DNS names (including wildcards) and its subject's common name.
+// The first added pair is the default one.
+func (m *CertManager) AddPair(certFile, keyFile string) error {
+	if certFile == "" || keyFile == "" {
+		return errCertKeyMissing
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pairs := append(m.pairs[0:len(m.pairs):len(m.pairs)], certKeyPair{certFile, keyFile})
+	if err := m.load(pairs, m.clientCAs); err != nil {
+		return err
+	}
+
+	m.pairs = pairs
+	return nil
+}
+
+// AddClientCA loads the PEM encoded certificate authorities from "caFile"
+// that client certificates are verified against (mutual TLS).
+// See `CertManager.ClientAuth` and `Context.ClientCertificate` too.
+func (m *CertManager) AddClientCA(caFile string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clientCAs := append(m.clientCAs[0:len(m.clientCAs):len(m.clientCAs)], caFile)
+	if err := m.load(m.pairs, clientCAs); err != nil {
+		return err
+	}
+
+	m.clientCAs = clientCAs
+	if m.ClientAuth == tls.NoClientCert {
+		m.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return nil
+}
+
+// Reload reloads all certificates and client CAs from disk.
+// If one of them fails to load then the previous certificates are kept.
+func (m *CertManager) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.load(m.pairs, m.clientCAs)
+}
+
+// load reads the files and swaps the state, the caller should hold the lock.
+func (m *CertManager) load(pairs []certKeyPair, clientCAs []string) error {
+	state := &certState{byName: make(map[string]*tls.Certificate)}
+	stamps := make(map[string]fileStamp)
+
+	for _, pair := range pairs {
+		cert, err := tls.LoadX509KeyPair(pair.certFile, pair.keyFile)
+		if err != nil {
+			return errParseTLS.Format(pair.certFile, pair.keyFile, err)
+		}
+
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return errCertManagerParse.Format(pair.certFile, err)
+		}
+
+		if state.fallback == nil {
+			state.fallback = &cert
+		}
+
+		for _, name := range certificateNames(cert.Leaf) {
+			if _, exists := state.byName[name]; !exists {
+				state.byName[name] = &cert
+			}
+		}
+
+		stamps[pair.certFile] = statFile(pair.certFile)
+		stamps[pair.keyFile] = statFile(pair.keyFile)
+	}
+
+	if len(clientCAs) > 0 {
+		state.clientCAs = x509.NewCertPool()
+		for _, caFile := range clientCAs {
+			b, err := ioutil.ReadFile(caFile)
+			if err != nil {
+				return errCertManagerCA.Format(caFile, err)
+			}
+
+			if !state.clientCAs.AppendCertsFromPEM(b) {
+				return errCertManagerCA.Format(caFile, "no valid PEM certificates")
+			}
+
+			stamps[caFile] = statFile(caFile)
+		}
+	}
+
+	m.stamps = stamps
+	m.state.Store(state)
+	return nil
+}
+
+func certificateNames(leaf *x509.Certificate) []string {
+	names := make([]string, 0, len(leaf.DNSNames)+len(leaf.IPAddresses)+1)
+	for _, name := range leaf.DNSNames {
+		names = append(names, strings.ToLower(name))
+	}
+
+	for _, ip := range leaf.IPAddresses {
+		names = append(names, ip.String())
+	}
+
+	if cn := leaf.Subject.CommonName; cn != "" && len(leaf.DNSNames) == 0 {
+		names = append(names, strings.ToLower(cn))
+	}
+
+	return names
+}
+
+func statFile(filename string) fileStamp {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fileStamp{}
+	}
+
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+func (m *CertManager) getState() *certState {
+	return m.state.Load().(*certState)
+}
+
+// GetCertificate returns the certificate that matches the client's requested server name,
+// it's the `tls.Config.GetCertificate` of the `TLSConfig`.
+func (m *CertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	state := m.getState()
+
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if name == "" {
+		// clients that don't send the SNI extension, i.e connected by IP.
+		if hello.Conn != nil {
+			if host, _, err := net.SplitHostPort(hello.Conn.LocalAddr().String()); err == nil {
+				name = host
+			}
+		}
+	}
+
+	if cert, ok := state.byName[name]; ok {
+		return cert, nil
+	}
+
+	// try the wildcard, i.e "*.example.com" for "www.example.com".
+	if dotIdx := strings.IndexByte(name, '.'); dotIdx > 0 {
+		if cert, ok := state.byName["*"+name[dotIdx:]]; ok {
+			return cert, nil
+		}
+	}
+
+	if state.fallback == nil {
+		return nil, errCertManagerEmpty
+	}
+
+	if m.Strict {
+		return nil, errCertManagerNoMatch.Format(name)
+	}
+
+	return state.fallback, nil
+}
+
+// TLSConfig returns a new `tls.Config` which serves the manager's certificates
+// and verifies the client certificates, if client CAs were added.
+func (m *CertManager) TLSConfig() *tls.Config {
+	cfg := &tls.Config{
+		GetCertificate:           m.GetCertificate,
+		PreferServerCipherSuites: true,
+		NextProtos:               []string{"h2", "http/1.1"},
+	}
+
+	if m.ClientAuth != tls.NoClientCert {
+		cfg.ClientAuth = m.ClientAuth
+		// a per-handshake config in order to always use the latest loaded client CAs.
+		cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c := cfg.Clone()
+			c.GetConfigForClient = nil
+			c.ClientCAs = m.getState().clientCAs
+			return c, nil
+		}
+	}
+
+	return cfg
+}
+
+// Watch checks the certificate, key and client CA files for changes
+// every "interval" and reloads them, see `OnReload` too.
+// It returns a function which stops the watcher.
+func (m *CertManager) Watch(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if !m.changed() {
+					continue
+				}
+
+				err := m.Reload()
+				if err != nil {
+					// keep the new stamps in order to not retry until the next change,
+					// i.e the key file was not written yet.
+					m.mu.Lock()
+					for filename := range m.stamps {
+						m.stamps[filename] = statFile(filename)
+					}
+					m.mu.Unlock()
+				}
+
+				if m.OnReload != nil {
+					m.OnReload(err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+func (m *CertManager) changed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for filename, stamp := range m.stamps {
+		if current := statFile(filename); current.size != stamp.size || !current.modTime.Equal(stamp.modTime) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CertManagerTLS returns a new TLS Listener which serves the certificates of the "m" `CertManager`.
+func CertManagerTLS(addr string, m *CertManager) (net.Listener, error) {
+	l, err := TCP(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return tls.NewListener(l, m.TLSConfig()), nil
+}
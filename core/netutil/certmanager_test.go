@@ -0,0 +1,153 @@
+package netutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCertificate(t *testing.T, dir, name string, serial int64, dnsNames ...string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+
+	if err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestCertManagerSNI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certmanager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := NewCertManager()
+	if _, err = m.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"}); err == nil {
+		t.Fatalf("expected an error when no certificates are loaded")
+	}
+
+	if err = m.AddPair(writeTestCertificate(t, dir, "default", 1, "default.com")); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.AddPair(writeTestCertificate(t, dir, "example", 2, "example.com", "*.example.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		serverName string
+		serial     int64
+	}{
+		{"default.com", 1},
+		{"example.com", 2},
+		{"EXAMPLE.com.", 2},
+		{"www.example.com", 2},
+		{"a.b.example.com", 1}, // wildcards match a single label only.
+		{"other.com", 1},
+		{"", 1},
+	}
+
+	for i, tt := range tests {
+		cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: tt.serverName})
+		if err != nil {
+			t.Fatalf("[%d] %v", i, err)
+		}
+
+		if expected, got := tt.serial, cert.Leaf.SerialNumber.Int64(); expected != got {
+			t.Fatalf("[%d] expected certificate %d for %q but got %d", i, expected, tt.serverName, got)
+		}
+	}
+
+	m.Strict = true
+	if _, err = m.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.com"}); err == nil {
+		t.Fatalf("expected an error on strict mode")
+	}
+}
+
+func TestCertManagerReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certmanager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := NewCertManager()
+	if err = m.AddPair(writeTestCertificate(t, dir, "example", 1, "example.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := make(chan error, 1)
+	m.OnReload = func(err error) {
+		reloaded <- err
+	}
+
+	stop := m.Watch(10 * time.Millisecond)
+	defer stop()
+
+	// make sure the modification time differs on file systems with low resolution.
+	time.Sleep(20 * time.Millisecond)
+	certFile, keyFile := writeTestCertificate(t, dir, "example", 2, "example.com")
+	future := time.Now().Add(time.Second)
+	os.Chtimes(certFile, future, future)
+	os.Chtimes(keyFile, future, future)
+
+	timeout := time.After(5 * time.Second)
+	for err = errCertManagerEmpty; err != nil; {
+		select {
+		case err = <-reloaded:
+			// the watcher may catch the certificate file before the key one is written,
+			// it should retry on the next change.
+		case <-timeout:
+			t.Fatalf("expected the certificates to be reloaded, last error: %v", err)
+		}
+	}
+
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := int64(2), cert.Leaf.SerialNumber.Int64(); expected != got {
+		t.Fatalf("expected the reloaded certificate %d but got %d", expected, got)
+	}
+}
@@ -0,0 +1,140 @@
+package netutil
+
+import (
+	"net"
+	"strings"
+)
+
+// ForwardedElement is a single, comma separated, element of the RFC 7239 "Forwarded" header,
+// each proxy appends one of these.
+type ForwardedElement struct {
+	// For is the client's (or the previous proxy's) node, i.e "192.0.2.60", "[2001:db8:cafe::17]:4711" or "unknown".
+	For string
+	// By is the node of the proxy that received the request.
+	By string
+	// Host is the "Host" request header as received by the proxy.
+	Host string
+	// Proto is the protocol the proxy received the request with, "http" or "https".
+	Proto string
+}
+
+// ParseForwarded parses the "Forwarded" header's values as described by the RFC 7239,
+// https://tools.ietf.org/html/rfc7239#section-4.
+// It accepts the header's values as they're received,
+// multiple header lines are treated as a single comma separated list.
+// Malformed parameters are skipped.
+func ParseForwarded(values []string) []ForwardedElement {
+	var elements []ForwardedElement
+
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			var fe ForwardedElement
+			for _, pair := range splitQuoted(element, ';') {
+				eqIdx := strings.IndexByte(pair, '=')
+				if eqIdx <= 0 {
+					continue
+				}
+
+				key := strings.ToLower(strings.TrimSpace(pair[:eqIdx]))
+				val := unquote(strings.TrimSpace(pair[eqIdx+1:]))
+
+				switch key {
+				case "for":
+					fe.For = val
+				case "by":
+					fe.By = val
+				case "host":
+					fe.Host = val
+				case "proto":
+					fe.Proto = strings.ToLower(val)
+				}
+			}
+
+			elements = append(elements, fe)
+		}
+	}
+
+	return elements
+}
+
+// splitQuoted splits "s" by "sep" outside of quoted-strings.
+func splitQuoted(s string, sep byte) []string {
+	var (
+		parts    []string
+		start    int
+		inQuotes bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if inQuotes {
+				i++ // skip the escaped character.
+			}
+		case '"':
+			inQuotes = !inQuotes
+		case sep:
+			if !inQuotes {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+
+	s = s[1 : len(s)-1]
+	if strings.IndexByte(s, '\\') == -1 {
+		return s
+	}
+
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b = append(b, s[i])
+	}
+
+	return string(b)
+}
+
+// ForwardedNodeIP returns the IP of a "Forwarded" header's node, i.e the "for" parameter,
+// without the brackets and the port, if any.
+// It returns nil for "unknown" and obfuscated identifiers.
+func ForwardedNodeIP(node string) net.IP {
+	if strings.HasPrefix(node, "[") {
+		// "[2001:db8:cafe::17]:4711" or "[2001:db8:cafe::17]".
+		if endIdx := strings.IndexByte(node, ']'); endIdx > 0 {
+			return net.ParseIP(node[1:endIdx])
+		}
+		return nil
+	}
+
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+
+	return net.ParseIP(node)
+}
+
+// IPInNets reports whether the "ip" is contained by any of the "nets".
+func IPInNets(ip net.IP, nets []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,65 @@
+package netutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseForwarded(t *testing.T) {
+	tests := []struct {
+		values   []string
+		expected []ForwardedElement
+	}{
+		{
+			[]string{`for="_gazonk"`},
+			[]ForwardedElement{{For: "_gazonk"}},
+		},
+		{
+			[]string{`For="[2001:db8:cafe::17]:4711"`},
+			[]ForwardedElement{{For: "[2001:db8:cafe::17]:4711"}},
+		},
+		{
+			[]string{`for=192.0.2.60;proto=HTTP;by=203.0.113.43`},
+			[]ForwardedElement{{For: "192.0.2.60", Proto: "http", By: "203.0.113.43"}},
+		},
+		{
+			[]string{`for=192.0.2.43, for=198.51.100.17;host="example.com;a,b"`, `for=unknown`},
+			[]ForwardedElement{{For: "192.0.2.43"}, {For: "198.51.100.17", Host: "example.com;a,b"}, {For: "unknown"}},
+		},
+		{
+			[]string{`for="\"escaped\""; invalid; =empty`},
+			[]ForwardedElement{{For: `"escaped"`}},
+		},
+	}
+
+	for i, tt := range tests {
+		if got := ParseForwarded(tt.values); !reflect.DeepEqual(tt.expected, got) {
+			t.Fatalf("[%d] expected %#+v but got %#+v", i, tt.expected, got)
+		}
+	}
+}
+
+func TestForwardedNodeIP(t *testing.T) {
+	tests := []struct {
+		node     string
+		expected string
+	}{
+		{"192.0.2.60", "192.0.2.60"},
+		{"192.0.2.60:8080", "192.0.2.60"},
+		{"[2001:db8:cafe::17]:4711", "2001:db8:cafe::17"},
+		{"[2001:db8:cafe::17]", "2001:db8:cafe::17"},
+		{"unknown", ""},
+		{"_hidden", ""},
+	}
+
+	for i, tt := range tests {
+		got := ""
+		if ip := ForwardedNodeIP(tt.node); ip != nil {
+			got = ip.String()
+		}
+
+		if got != tt.expected {
+			t.Fatalf("[%d] expected %q but got %q", i, tt.expected, got)
+		}
+	}
+}
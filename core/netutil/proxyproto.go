@@ -0,0 +1,609 @@
+package netutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/radiantrfid/iris/core/errors"
+)
+
+// The PROXY protocol, as documented at:
+// https://www.haproxy.org/download/2.0/doc/proxy-protocol.txt
+var (
+	proxyProtoV1Prefix    = []byte("PROXY ")
+	proxyProtoV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+const (
+	// the maximum length of a v1 header, including the CRLF.
+	proxyProtoV1MaxLength = 107
+	// signature(12) + version/command(1) + family/transport(1) + length(2).
+	proxyProtoV2HeaderLength = 16
+
+	proxyProtoV2CommandLocal = 0x0
+	proxyProtoV2CommandProxy = 0x1
+
+	proxyProtoV2FamilyUnspec = 0x0
+	proxyProtoV2FamilyInet   = 0x1
+	proxyProtoV2FamilyInet6  = 0x2
+	proxyProtoV2FamilyUnix   = 0x3
+
+	proxyProtoV2TransportStream = 0x1
+	proxyProtoV2TransportDgram  = 0x2
+)
+
+// Type-Length-Value types of a PROXY protocol v2 header.
+const (
+	ProxyTLVTypeALPN      byte = 0x01
+	ProxyTLVTypeAuthority byte = 0x02
+	ProxyTLVTypeCRC32C    byte = 0x03
+	ProxyTLVTypeNoop      byte = 0x04
+	ProxyTLVTypeUniqueID  byte = 0x05
+	ProxyTLVTypeSSL       byte = 0x20
+	ProxyTLVTypeNetNS     byte = 0x30
+
+	ProxyTLVSubTypeSSLVersion byte = 0x21
+	ProxyTLVSubTypeSSLCN      byte = 0x22
+	ProxyTLVSubTypeSSLCipher  byte = 0x23
+	ProxyTLVSubTypeSSLSigAlg  byte = 0x24
+	ProxyTLVSubTypeSSLKeyAlg  byte = 0x25
+)
+
+// Client flags of the PROXY protocol v2 SSL TLV.
+const (
+	ProxyClientSSL      byte = 0x01
+	ProxyClientCertConn byte = 0x02
+	ProxyClientCertSess byte = 0x04
+)
+
+var (
+	errProxyProtoMalformed  = errors.New("proxy protocol: malformed %s header: %s")
+	errProxyProtoRequired   = errors.New("proxy protocol: header is required from %s")
+	errInvalidCIDR          = errors.New("invalid CIDR %q: %v")
+	errProxyProtoUnknownVer = errors.New("proxy protocol: unknown version %d")
+)
+
+type (
+	// ProxyProtocolConfig holds the options for the `ProxyProtocol` listener wrapper.
+	ProxyProtocolConfig struct {
+		// TrustedCIDRs is a list of CIDR ranges (e.g. "10.0.0.0/8" or "::1/128")
+		// or single IPs that are allowed to send a PROXY protocol header.
+		// Connections from other sources are served as they are and
+		// their (possible) PROXY header is NOT parsed.
+		//
+		// Defaults to empty, no source is trusted,
+		// use "0.0.0.0/0" and "::/0" to trust all of them.
+		TrustedCIDRs []string
+		// HeaderTimeout is the maximum amount of time to wait
+		// for the PROXY protocol header to be received.
+		//
+		// Defaults to 5 seconds, a negative value disables the deadline.
+		HeaderTimeout time.Duration
+		// Required when true, connections from trusted sources
+		// that don't send a PROXY protocol header are rejected.
+		//
+		// Defaults to false.
+		Required bool
+	}
+
+	// ProxyTLV is a single Type-Length-Value entry of a PROXY protocol v2 header.
+	ProxyTLV struct {
+		Type  byte
+		Value []byte
+	}
+
+	// ProxyTLSInfo contains the TLS information
+	// the PROXY protocol v2 SSL TLV carries, if any.
+	ProxyTLSInfo struct {
+		// Client is the bit field of `ProxyClientSSL`, `ProxyClientCertConn` and `ProxyClientCertSess`.
+		Client byte
+		// Verify is zero when the client presented a certificate that was successfully verified.
+		Verify uint32
+		// Version is the TLS version, i.e "TLSv1.3".
+		Version string
+		// CommonName is the Common Name of the client certificate's subject, if any.
+		CommonName string
+		Cipher     string
+		SigAlg     string
+		KeyAlg     string
+	}
+
+	// ProxyInfo contains the information a PROXY protocol header has carried.
+	ProxyInfo struct {
+		// Version is 1 or 2.
+		Version int
+		// Local is true when the proxy sent a v2 LOCAL command
+		// or a v1 UNKNOWN one, the connection's addresses are kept as they are.
+		Local bool
+		// Source is the real client's address.
+		Source net.Addr
+		// Destination is the address the client connected to.
+		Destination net.Addr
+		// TLVs contains the additional v2 Type-Length-Value entries.
+		TLVs []ProxyTLV
+		// TLS is not nil when the proxy terminated a TLS connection,
+		// see `ProxyTLSInfo`.
+		TLS *ProxyTLSInfo
+	}
+)
+
+// Authority returns the host name (SNI) the client used, if the proxy sent it.
+func (info *ProxyInfo) Authority() string {
+	for _, tlv := range info.TLVs {
+		if tlv.Type == ProxyTLVTypeAuthority {
+			return string(tlv.Value)
+		}
+	}
+
+	return ""
+}
+
+// ALPN returns the application protocol negotiated by the proxy, if any.
+func (info *ProxyInfo) ALPN() string {
+	for _, tlv := range info.TLVs {
+		if tlv.Type == ProxyTLVTypeALPN {
+			return string(tlv.Value)
+		}
+	}
+
+	return ""
+}
+
+type proxyListener struct {
+	net.Listener
+	trusted       []*net.IPNet
+	headerTimeout time.Duration
+	required      bool
+}
+
+// ProxyProtocol wraps the "ln" listener with one which
+// parses the HAProxy PROXY protocol v1 and v2 headers of the accepted connections,
+// the connections' `RemoteAddr` reports the real client's address,
+// which ends up on the `http.Request.RemoteAddr` and `Context.RemoteAddr()`.
+//
+// The header is read on the first `Read` or `RemoteAddr` call of the connection,
+// never inside `Accept`, so a slow client cannot block the rest of them.
+//
+// Use `GetProxyInfo` to retrieve the rest of the header's information, i.e TLS, from a request.
+//
+// Example Code:
+// l, err := netutil.TCPKeepAlive(":8080")
+// l, err = netutil.ProxyProtocol(l, netutil.ProxyProtocolConfig{TrustedCIDRs: []string{"10.0.0.0/8"}})
+// app.Run(iris.Listener(l))
+func ProxyProtocol(ln net.Listener, cfg ProxyProtocolConfig) (net.Listener, error) {
+	trusted, err := ParseCIDRs(cfg.TrustedCIDRs...)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.HeaderTimeout == 0 {
+		cfg.HeaderTimeout = 5 * time.Second
+	}
+
+	return &proxyListener{
+		Listener:      ln,
+		trusted:       trusted,
+		headerTimeout: cfg.HeaderTimeout,
+		required:      cfg.Required,
+	}, nil
+}
+
+// ParseCIDRs parses a list of CIDR ranges,
+// a single IP is accepted too and it's converted to a /32 (or /128 for IPv6) range.
+func ParseCIDRs(cidrs ...string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, s := range cidrs {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, errInvalidCIDR.Format(s, "not an IP")
+			}
+
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+				bits = 8 * net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, errInvalidCIDR.Format(s, err)
+		}
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+// Accept waits for and returns the next, PROXY protocol aware, connection to the listener.
+func (l *proxyListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProxyConn{
+		Conn:          c,
+		reader:        bufio.NewReader(c),
+		trusted:       l.isTrusted(c.RemoteAddr()),
+		headerTimeout: l.headerTimeout,
+		required:      l.required,
+	}, nil
+}
+
+func (l *proxyListener) isTrusted(addr net.Addr) bool {
+	// fails closed, an empty list trusts no one.
+	return IPInNets(addrIP(addr), l.trusted)
+}
+
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	case nil:
+		return nil
+	default:
+		host, _, err := net.SplitHostPort(a.String())
+		if err != nil {
+			host = a.String()
+		}
+		return net.ParseIP(host)
+	}
+}
+
+// ProxyConn is the connection that the `ProxyProtocol` listener accepts.
+type ProxyConn struct {
+	net.Conn
+	reader        *bufio.Reader
+	trusted       bool
+	headerTimeout time.Duration
+	required      bool
+
+	once sync.Once
+	info *ProxyInfo
+	err  error
+}
+
+// Read reads data from the connection, after the PROXY protocol header.
+func (c *ProxyConn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the real client's address
+// if a PROXY protocol header was received, otherwise the connection's remote address.
+func (c *ProxyConn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.info != nil && !c.info.Local && c.info.Source != nil {
+		return c.info.Source
+	}
+
+	return c.Conn.RemoteAddr()
+}
+
+// LocalAddr returns the address the client connected to
+// if a PROXY protocol header was received, otherwise the connection's local address.
+func (c *ProxyConn) LocalAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.info != nil && !c.info.Local && c.info.Destination != nil {
+		return c.info.Destination
+	}
+
+	return c.Conn.LocalAddr()
+}
+
+// ProxyInfo returns the parsed PROXY protocol header
+// or nil if the connection didn't send any
+// and the header's parse error, if any.
+func (c *ProxyConn) ProxyInfo() (*ProxyInfo, error) {
+	c.once.Do(c.readHeader)
+	return c.info, c.err
+}
+
+func (c *ProxyConn) readHeader() {
+	if !c.trusted {
+		return
+	}
+
+	if c.headerTimeout > 0 {
+		c.Conn.SetReadDeadline(time.Now().Add(c.headerTimeout))
+		defer c.Conn.SetReadDeadline(time.Time{})
+	}
+
+	c.info, c.err = ReadProxyHeader(c.reader)
+	if c.err == nil && c.info == nil && c.required {
+		c.err = errProxyProtoRequired.Format(c.Conn.RemoteAddr())
+	}
+
+	if c.err != nil {
+		c.Conn.Close()
+	}
+}
+
+// ReadProxyHeader reads a PROXY protocol v1 or v2 header from "r".
+// It returns a nil `ProxyInfo` and a nil error if the data does not start with a PROXY header,
+// in that case nothing is consumed from the reader.
+func ReadProxyHeader(r *bufio.Reader) (*ProxyInfo, error) {
+	b, err := r.Peek(len(proxyProtoV1Prefix))
+	switch {
+	case bytes.Equal(b, proxyProtoV1Prefix):
+		return readProxyHeaderV1(r)
+	case !bytes.HasPrefix(proxyProtoV1Prefix, b) && !bytes.HasPrefix(proxyProtoV2Signature, b):
+		return nil, nil // not a PROXY header.
+	case err != nil:
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	b, err = r.Peek(len(proxyProtoV2Signature))
+	if bytes.Equal(b, proxyProtoV2Signature) {
+		return readProxyHeaderV2(r)
+	}
+
+	if err != nil && err != io.EOF && bytes.HasPrefix(proxyProtoV2Signature, b) {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func readProxyHeaderV1(r *bufio.Reader) (*ProxyInfo, error) {
+	var line []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, errProxyProtoMalformed.Format("v1", err)
+		}
+		line = append(line, c)
+		if c == '\n' {
+			break
+		}
+		if len(line) >= proxyProtoV1MaxLength {
+			return nil, errProxyProtoMalformed.Format("v1", "header too long")
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errProxyProtoMalformed.Format("v1", "missing CRLF")
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	info := &ProxyInfo{Version: 1}
+	if len(fields) < 2 {
+		return nil, errProxyProtoMalformed.Format("v1", "missing protocol")
+	}
+
+	switch fields[1] {
+	case "UNKNOWN":
+		info.Local = true
+		return info, nil
+	case "TCP4", "TCP6":
+	default:
+		return nil, errProxyProtoMalformed.Format("v1", "unknown protocol "+fields[1])
+	}
+
+	if len(fields) != 6 {
+		return nil, errProxyProtoMalformed.Format("v1", "wrong number of fields")
+	}
+
+	src, err := parseProxyV1Addr(fields[1], fields[2], fields[4])
+	if err != nil {
+		return nil, err
+	}
+	dst, err := parseProxyV1Addr(fields[1], fields[3], fields[5])
+	if err != nil {
+		return nil, err
+	}
+
+	info.Source = src
+	info.Destination = dst
+	return info, nil
+}
+
+func parseProxyV1Addr(protocol, host, port string) (*net.TCPAddr, error) {
+	ip := net.ParseIP(host)
+	if ip == nil || (protocol == "TCP4") != (ip.To4() != nil) {
+		return nil, errProxyProtoMalformed.Format("v1", "invalid address "+host)
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || (len(port) > 1 && port[0] == '0') {
+		return nil, errProxyProtoMalformed.Format("v1", "invalid port "+port)
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(p)}, nil
+}
+
+func readProxyHeaderV2(r *bufio.Reader) (*ProxyInfo, error) {
+	header := make([]byte, proxyProtoV2HeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errProxyProtoMalformed.Format("v2", err)
+	}
+
+	if version := header[12] >> 4; version != 2 {
+		return nil, errProxyProtoUnknownVer.Format(version)
+	}
+
+	length := int(binary.BigEndian.Uint16(header[14:16]))
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, errProxyProtoMalformed.Format("v2", err)
+	}
+
+	info := &ProxyInfo{Version: 2}
+	switch command := header[12] & 0x0F; command {
+	case proxyProtoV2CommandLocal:
+		info.Local = true
+		return info, nil
+	case proxyProtoV2CommandProxy:
+	default:
+		return nil, errProxyProtoMalformed.Format("v2", "unknown command "+strconv.Itoa(int(command)))
+	}
+
+	family, transport := header[13]>>4, header[13]&0x0F
+	var addrLength int
+	switch family {
+	case proxyProtoV2FamilyUnspec:
+		info.Local = true
+	case proxyProtoV2FamilyInet:
+		addrLength = 2*net.IPv4len + 4
+	case proxyProtoV2FamilyInet6:
+		addrLength = 2*net.IPv6len + 4
+	case proxyProtoV2FamilyUnix:
+		addrLength = 2 * 108
+	default:
+		return nil, errProxyProtoMalformed.Format("v2", "unknown address family "+strconv.Itoa(int(family)))
+	}
+
+	if len(payload) < addrLength {
+		return nil, errProxyProtoMalformed.Format("v2", "short address block")
+	}
+
+	addrs := payload[:addrLength]
+	switch family {
+	case proxyProtoV2FamilyInet, proxyProtoV2FamilyInet6:
+		ipLen := net.IPv4len
+		if family == proxyProtoV2FamilyInet6 {
+			ipLen = net.IPv6len
+		}
+
+		srcIP := net.IP(append([]byte(nil), addrs[:ipLen]...))
+		dstIP := net.IP(append([]byte(nil), addrs[ipLen:2*ipLen]...))
+		srcPort := int(binary.BigEndian.Uint16(addrs[2*ipLen:]))
+		dstPort := int(binary.BigEndian.Uint16(addrs[2*ipLen+2:]))
+
+		if transport == proxyProtoV2TransportDgram {
+			info.Source = &net.UDPAddr{IP: srcIP, Port: srcPort}
+			info.Destination = &net.UDPAddr{IP: dstIP, Port: dstPort}
+		} else {
+			info.Source = &net.TCPAddr{IP: srcIP, Port: srcPort}
+			info.Destination = &net.TCPAddr{IP: dstIP, Port: dstPort}
+		}
+	case proxyProtoV2FamilyUnix:
+		network := "unix"
+		if transport == proxyProtoV2TransportDgram {
+			network = "unixgram"
+		}
+		info.Source = &net.UnixAddr{Net: network, Name: cString(addrs[:108])}
+		info.Destination = &net.UnixAddr{Net: network, Name: cString(addrs[108:])}
+	}
+
+	tlvs, err := parseProxyTLVs(payload[addrLength:])
+	if err != nil {
+		return nil, err
+	}
+	info.TLVs = tlvs
+
+	for _, tlv := range tlvs {
+		if tlv.Type == ProxyTLVTypeSSL {
+			if info.TLS, err = parseProxyTLSInfo(tlv.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func parseProxyTLVs(b []byte) ([]ProxyTLV, error) {
+	var tlvs []ProxyTLV
+	for len(b) > 0 {
+		if len(b) < 3 {
+			return nil, errProxyProtoMalformed.Format("v2", "truncated TLV")
+		}
+
+		length := int(binary.BigEndian.Uint16(b[1:3]))
+		if len(b) < 3+length {
+			return nil, errProxyProtoMalformed.Format("v2", "truncated TLV value")
+		}
+
+		if b[0] != ProxyTLVTypeNoop {
+			tlvs = append(tlvs, ProxyTLV{Type: b[0], Value: b[3 : 3+length]})
+		}
+		b = b[3+length:]
+	}
+
+	return tlvs, nil
+}
+
+func parseProxyTLSInfo(b []byte) (*ProxyTLSInfo, error) {
+	if len(b) < 5 {
+		return nil, errProxyProtoMalformed.Format("v2", "short SSL TLV")
+	}
+
+	info := &ProxyTLSInfo{
+		Client: b[0],
+		Verify: binary.BigEndian.Uint32(b[1:5]),
+	}
+
+	subs, err := parseProxyTLVs(b[5:])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sub := range subs {
+		switch sub.Type {
+		case ProxyTLVSubTypeSSLVersion:
+			info.Version = string(sub.Value)
+		case ProxyTLVSubTypeSSLCN:
+			info.CommonName = string(sub.Value)
+		case ProxyTLVSubTypeSSLCipher:
+			info.Cipher = string(sub.Value)
+		case ProxyTLVSubTypeSSLSigAlg:
+			info.SigAlg = string(sub.Value)
+		case ProxyTLVSubTypeSSLKeyAlg:
+			info.KeyAlg = string(sub.Value)
+		}
+	}
+
+	return info, nil
+}
+
+func cString(b []byte) string {
+	if idx := bytes.IndexByte(b, 0); idx >= 0 {
+		b = b[:idx]
+	}
+	return string(b)
+}
+
+type proxyConnContextKey struct{}
+
+// GetProxyInfo returns the PROXY protocol information of the request's connection, if any.
+// See `ProxyProtocol` and `ProxyConnContext` (go1.13+) too.
+func GetProxyInfo(r *http.Request) (*ProxyInfo, bool) {
+	pc, ok := r.Context().Value(proxyConnContextKey{}).(*ProxyConn)
+	if !ok {
+		return nil, false
+	}
+
+	info, err := pc.ProxyInfo()
+	if err != nil || info == nil {
+		return nil, false
+	}
+
+	return info, true
+}
@@ -0,0 +1,21 @@
+// +build go1.13
+
+package netutil
+
+import (
+	"context"
+	"net"
+)
+
+// ProxyConnContext can be used as the `http.Server.ConnContext`
+// in order to make the PROXY protocol information available to the `GetProxyInfo`.
+// The iris `Application.NewHost` registers it automatically.
+//
+// The PROXY protocol information of the TLS connections is available on go1.18+ only.
+func ProxyConnContext(ctx context.Context, c net.Conn) context.Context {
+	if pc, ok := underlyingConn(c).(*ProxyConn); ok {
+		return context.WithValue(ctx, proxyConnContextKey{}, pc)
+	}
+
+	return ctx
+}
@@ -0,0 +1,17 @@
+// +build go1.18
+
+package netutil
+
+import (
+	"crypto/tls"
+	"net"
+)
+
+// underlyingConn returns the connection which is wrapped by a TLS connection, if any.
+func underlyingConn(c net.Conn) net.Conn {
+	if tlsConn, ok := c.(*tls.Conn); ok {
+		return tlsConn.NetConn()
+	}
+
+	return c
+}
@@ -0,0 +1,11 @@
+// +build !go1.18
+
+package netutil
+
+import "net"
+
+// underlyingConn returns the "c" as it is,
+// the `tls.Conn` does not expose its underlying connection before go1.18.
+func underlyingConn(c net.Conn) net.Conn {
+	return c
+}
@@ -0,0 +1,236 @@
+package netutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadProxyHeaderV1(t *testing.T) {
+	tests := []struct {
+		header string
+		source string
+		local  bool
+		err    bool
+	}{
+		{"PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n", "192.168.0.1:56324", false, false},
+		{"PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n", "[2001:db8::1]:56324", false, false},
+		{"PROXY UNKNOWN\r\n", "", true, false},
+		{"PROXY UNKNOWN ffff:f...f:ffff ffff:f...f:ffff 65535 65535\r\n", "", true, false},
+		{"PROXY TCP4 192.168.0.1 192.168.0.11 56324\r\n", "", false, true},
+		{"PROXY TCP4 2001:db8::1 192.168.0.11 56324 443\r\n", "", false, true},
+		{"PROXY TCP4 192.168.0.1 192.168.0.11 056324 443\r\n", "", false, true},
+		{"PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\n", "", false, true},
+		{"PROXY UDP4 192.168.0.1 192.168.0.11 56324 443\r\n", "", false, true},
+	}
+
+	for i, tt := range tests {
+		r := bufio.NewReader(strings.NewReader(tt.header + "GET / HTTP/1.1\r\n"))
+		info, err := ReadProxyHeader(r)
+		if tt.err {
+			if err == nil {
+				t.Fatalf("[%d] expected an error but got nil", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+
+		if info.Version != 1 || info.Local != tt.local {
+			t.Fatalf("[%d] expected version 1 and local %t but got %d and %t", i, tt.local, info.Version, info.Local)
+		}
+
+		if !tt.local {
+			if got := info.Source.String(); got != tt.source {
+				t.Fatalf("[%d] expected source %s but got %s", i, tt.source, got)
+			}
+		}
+
+		rest, _ := ioutil.ReadAll(r)
+		if expected, got := "GET / HTTP/1.1\r\n", string(rest); expected != got {
+			t.Fatalf("[%d] expected the rest of the data to be %q but got %q", i, expected, got)
+		}
+	}
+}
+
+func newProxyHeaderV2(command byte, src, dst *net.TCPAddr, tlvs []byte) []byte {
+	var addrs []byte
+	family := byte(proxyProtoV2FamilyInet)
+	srcIP, dstIP := []byte(src.IP.To4()), []byte(dst.IP.To4())
+	if srcIP == nil {
+		family = proxyProtoV2FamilyInet6
+		srcIP, dstIP = src.IP.To16(), dst.IP.To16()
+	}
+
+	addrs = append(addrs, srcIP...)
+	addrs = append(addrs, dstIP...)
+	ports := make([]byte, 4)
+	binary.BigEndian.PutUint16(ports, uint16(src.Port))
+	binary.BigEndian.PutUint16(ports[2:], uint16(dst.Port))
+	addrs = append(addrs, ports...)
+	addrs = append(addrs, tlvs...)
+
+	header := append([]byte(nil), proxyProtoV2Signature...)
+	header = append(header, 0x20|command, family<<4|proxyProtoV2TransportStream, 0, 0)
+	binary.BigEndian.PutUint16(header[14:], uint16(len(addrs)))
+	return append(header, addrs...)
+}
+
+func newProxyTLV(typ byte, value []byte) []byte {
+	tlv := []byte{typ, 0, 0}
+	binary.BigEndian.PutUint16(tlv[1:], uint16(len(value)))
+	return append(tlv, value...)
+}
+
+func TestReadProxyHeaderV2(t *testing.T) {
+	src := &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 4000}
+	dst := &net.TCPAddr{IP: net.ParseIP("10.1.2.4"), Port: 443}
+
+	ssl := []byte{ProxyClientSSL | ProxyClientCertConn, 0, 0, 0, 0}
+	ssl = append(ssl, newProxyTLV(ProxyTLVSubTypeSSLVersion, []byte("TLSv1.3"))...)
+	ssl = append(ssl, newProxyTLV(ProxyTLVSubTypeSSLCN, []byte("client.example.com"))...)
+
+	var tlvs []byte
+	tlvs = append(tlvs, newProxyTLV(ProxyTLVTypeAuthority, []byte("example.com"))...)
+	tlvs = append(tlvs, newProxyTLV(ProxyTLVTypeNoop, make([]byte, 3))...)
+	tlvs = append(tlvs, newProxyTLV(ProxyTLVTypeSSL, ssl)...)
+
+	data := append(newProxyHeaderV2(proxyProtoV2CommandProxy, src, dst, tlvs), "GET / HTTP/1.1\r\n"...)
+	r := bufio.NewReader(bytes.NewReader(data))
+
+	info, err := ReadProxyHeader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := src.String(), info.Source.String(); expected != got {
+		t.Fatalf("expected source %s but got %s", expected, got)
+	}
+
+	if expected, got := dst.String(), info.Destination.String(); expected != got {
+		t.Fatalf("expected destination %s but got %s", expected, got)
+	}
+
+	if expected, got := "example.com", info.Authority(); expected != got {
+		t.Fatalf("expected authority %s but got %s", expected, got)
+	}
+
+	if info.TLS == nil {
+		t.Fatalf("expected TLS information")
+	}
+
+	if info.TLS.Version != "TLSv1.3" || info.TLS.CommonName != "client.example.com" || info.TLS.Client&ProxyClientCertConn == 0 {
+		t.Fatalf("unexpected TLS information: %#+v", info.TLS)
+	}
+
+	rest, _ := ioutil.ReadAll(r)
+	if expected, got := "GET / HTTP/1.1\r\n", string(rest); expected != got {
+		t.Fatalf("expected the rest of the data to be %q but got %q", expected, got)
+	}
+
+	// LOCAL command, i.e health checks of the proxy.
+	data = newProxyHeaderV2(proxyProtoV2CommandLocal, src, dst, nil)
+	info, err = ReadProxyHeader(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.Local {
+		t.Fatalf("expected a local connection")
+	}
+}
+
+func TestReadProxyHeaderNone(t *testing.T) {
+	for _, data := range []string{"GET / HTTP/1.1\r\n", "PRO", "\r\n\r\nGET"} {
+		r := bufio.NewReader(strings.NewReader(data))
+		info, err := ReadProxyHeader(r)
+		if err != nil || info != nil {
+			t.Fatalf("expected no header and no error for %q but got %v and %v", data, info, err)
+		}
+
+		rest, _ := ioutil.ReadAll(r)
+		if string(rest) != data {
+			t.Fatalf("expected nothing to be consumed from %q but got %q", data, rest)
+		}
+	}
+}
+
+func testProxyProtocolListener(t *testing.T, cfg ProxyProtocolConfig, send string) (net.Addr, string, error) {
+	ln, err := TCP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	ln, err = ProxyProtocol(ln, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			return
+		}
+		c.Write([]byte(send))
+		c.Close()
+	}()
+
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	remoteAddr := c.RemoteAddr()
+	body, err := ioutil.ReadAll(c)
+	return remoteAddr, string(body), err
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	header := "PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n"
+
+	remoteAddr, body, err := testProxyProtocolListener(t, ProxyProtocolConfig{TrustedCIDRs: []string{"127.0.0.0/8"}}, header+"body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, got := "192.168.0.1:56324", remoteAddr.String(); expected != got {
+		t.Fatalf("expected remote address %s but got %s", expected, got)
+	}
+	if body != "body" {
+		t.Fatalf("expected body %q but got %q", "body", body)
+	}
+
+	// untrusted source, header is not parsed.
+	remoteAddr, body, err = testProxyProtocolListener(t, ProxyProtocolConfig{TrustedCIDRs: []string{"10.0.0.1"}}, header+"body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip := addrIP(remoteAddr); !ip.IsLoopback() {
+		t.Fatalf("expected the connection's remote address but got %s", remoteAddr)
+	}
+	if expected := header + "body"; body != expected {
+		t.Fatalf("expected body %q but got %q", expected, body)
+	}
+
+	// no trusted sources by default.
+	if _, body, err = testProxyProtocolListener(t, ProxyProtocolConfig{}, header+"body"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := header + "body"; body != expected {
+		t.Fatalf("expected body %q but got %q", expected, body)
+	}
+
+	// trusted source without a header, required.
+	_, _, err = testProxyProtocolListener(t, ProxyProtocolConfig{TrustedCIDRs: []string{"0.0.0.0/0", "::/0"}, Required: true, HeaderTimeout: time.Second}, "body")
+	if err == nil {
+		t.Fatalf("expected an error when the header is missing and it's required")
+	}
+}
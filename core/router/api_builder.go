@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"reflect"
 	"strings"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/radiantrfid/iris/core/errors"
 	"github.com/radiantrfid/iris/macro"
 	macroHandler "github.com/radiantrfid/iris/macro/handler"
+	"github.com/radiantrfid/iris/macro/interpreter/ast"
 )
 
 // MethodNone is a Virtual method
@@ -301,6 +303,8 @@ func (api *APIBuilder) createRoutes(methods []string, relativePath string, handl
 		return nil
 	}
 
+	api.reportInvalidHandlers("route "+strings.Join(methods, ", ")+": "+fullpath, handlers)
+
 	// note: this can not change the caller's handlers as they're but the entry values(handlers)
 	// of `middleware`, `doneHandlers` and `handlers` can.
 	// So if we just put `api.middleware` or `api.doneHandlers`
@@ -340,6 +344,8 @@ func (api *APIBuilder) createRoutes(methods []string, relativePath string, handl
 			continue
 		}
 
+		api.reportParamsMismatch(route, handlers)
+
 		// Add UseGlobal & DoneGlobal Handlers
 		route.Use(api.beginGlobalHandlers...)
 		route.Done(api.doneGlobalHandlers...)
@@ -350,6 +356,76 @@ func (api *APIBuilder) createRoutes(methods []string, relativePath string, handl
 	return routes
 }
 
+// reportInvalidHandlers reports the errors of the handlers which could not be created,
+// i.e the hero handlers with unresolved input arguments, see `context.InvalidHandler`.
+func (api *APIBuilder) reportInvalidHandlers(where string, handlers context.Handlers) {
+	for _, h := range handlers {
+		if err := context.HandlerError(h); err != nil {
+			api.reporter.Describe(strings.Replace(where, "%", "%%", -1)+": %v", err)
+		}
+	}
+}
+
+// reportParamsMismatch reports the path parameters which the handlers expect by name, see `context.ParamsHandler`,
+// but the route's path does not declare or it declares them of a different type.
+func (api *APIBuilder) reportParamsMismatch(route *Route, handlers context.Handlers) {
+	for _, h := range handlers {
+		for _, expected := range context.HandlerParams(h) {
+			param, ok := findTemplateParam(route.tmpl, expected.Name)
+			if !ok {
+				if !expected.Optional {
+					api.reporter.Add("route %s: field '%s' expects the path parameter '%s' which is not declared by the path, use a pointer for an optional parameter",
+						route.String(), expected.Field, expected.Name)
+				}
+				continue
+			}
+
+			typ := expected.Type
+			if typ.Kind() == reflect.Ptr {
+				typ = typ.Elem()
+			}
+
+			// a parameter of any type can be bound to a string.
+			if kind := paramKindOf(param); kind != reflect.Invalid && kind != typ.Kind() && typ.Kind() != reflect.String {
+				api.reporter.Add("route %s: field '%s' expects the path parameter '%s' of type '%s' but the path declares it as '%s'",
+					route.String(), expected.Field, expected.Name, expected.Type.String(), param.Type.Indent())
+			}
+		}
+	}
+}
+
+func findTemplateParam(tmpl macro.Template, name string) (macro.TemplateParam, bool) {
+	for _, p := range tmpl.Params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return macro.TemplateParam{}, false
+}
+
+// paramKindOf returns the Go kind of the values of a path parameter,
+// the macros are named after the kind of their values, i.e "int64", except the string ones, i.e "alphabetical".
+// It returns reflect.Invalid for a custom macro.
+func paramKindOf(param macro.TemplateParam) reflect.Kind {
+	if ast.IsMaster(param.Type) || ast.IsTrailing(param.Type) {
+		return reflect.String
+	}
+
+	switch indent := param.Type.Indent(); indent {
+	case macro.Alphabetical.Indent(), macro.File.Indent():
+		return reflect.String
+	default:
+		for kind := reflect.Bool; kind <= reflect.Uint64; kind++ {
+			if kind.String() == indent {
+				return kind
+			}
+		}
+
+		return reflect.Invalid
+	}
+}
+
 // Handle registers a route to the server's api.
 // if empty method is passed then handler(s) are being registered to all methods, same as .Any.
 //
@@ -460,6 +536,35 @@ func (api *APIBuilder) HandleDir(requestPath, directory string, opts ...DirOptio
 	return getRoute
 }
 
+// HandleProxy registers a handler for all methods that proxies the requests
+// of the "requestPath" and its sub paths to the "proxy", i.e a `host.LoadBalancer`.
+// The "requestPath" is removed from the proxied request's path,
+// so "/users/42" of the `HandleProxy("/users", lb)` is proxied as "/42".
+//
+//     lb, _ := host.NewLoadBalancer(host.LoadBalancerConfig{Upstreams: []string{"http://10.0.0.1:8080"}})
+//     api.HandleProxy("/users", lb)
+//
+// Returns the registered routes.
+func (api *APIBuilder) HandleProxy(requestPath string, proxy http.Handler) (routes []*Route) {
+	h := func(ctx context.Context) {
+		// the party's path may contain dynamic parameters,
+		// so the proxied path is the matched wildcard instead of a stripped prefix.
+		req := ctx.Request()
+		req.URL.Path = "/" + ctx.Params().Get("proxypath")
+		req.URL.RawPath = ""
+		proxy.ServeHTTP(ctx.ResponseWriter(), req)
+	}
+
+	requestPath = joinPath(requestPath, "/{proxypath:path}")
+	routes = api.createRoutes(AllMethods, requestPath, h)
+	for _, route := range routes {
+		route.MainHandlerName = "HandleProxy"
+		api.routes.register(route)
+	}
+
+	return
+}
+
 // Party groups routes which may have the same prefix and share same handlers,
 // returns that new rich subrouter.
 //
@@ -648,6 +753,7 @@ func (api *APIBuilder) GetRouteReadOnlyByPath(tmplPath string) context.RouteRead
 // Use `UseGlobal` if you want to register begin handlers(middleware)
 // that should be always run before all application's routes.
 func (api *APIBuilder) Use(handlers ...context.Handler) {
+	api.reportInvalidHandlers("Use", handlers)
 	api.middleware = append(api.middleware, handlers...)
 }
 
@@ -661,6 +767,7 @@ func (api *APIBuilder) Use(handlers ...context.Handler) {
 // Use of `ctx.Next()` of those handler(s) is necessary to call the main handler or the next middleware.
 // It's always a good practise to call it right before the `Application#Run` function.
 func (api *APIBuilder) UseGlobal(handlers ...context.Handler) {
+	api.reportInvalidHandlers("UseGlobal", handlers)
 	for _, r := range api.routes.routes {
 		r.Use(handlers...) // prepend the handlers to the existing routes
 	}
@@ -674,6 +781,7 @@ func (api *APIBuilder) UseGlobal(handlers ...context.Handler) {
 //
 // The difference from .Use is that this/or these Handler(s) are being always running last.
 func (api *APIBuilder) Done(handlers ...context.Handler) {
+	api.reportInvalidHandlers("Done", handlers)
 	api.doneHandlers = append(api.doneHandlers, handlers...)
 }
 
@@ -687,6 +795,7 @@ func (api *APIBuilder) Done(handlers ...context.Handler) {
 // Use of `ctx.Next()` at the previous handler is necessary.
 // It's always a good practise to call it right before the `Application#Run` function.
 func (api *APIBuilder) DoneGlobal(handlers ...context.Handler) {
+	api.reportInvalidHandlers("DoneGlobal", handlers)
 	for _, r := range api.routes.routes {
 		r.Done(handlers...) // append the handlers to the existing routes
 	}
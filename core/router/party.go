@@ -1,6 +1,8 @@
 package router
 
 import (
+	"net/http"
+
 	"github.com/radiantrfid/iris/context"
 	"github.com/radiantrfid/iris/core/errors"
 	"github.com/radiantrfid/iris/macro"
@@ -135,6 +137,15 @@ type Party interface {
 	//
 	// Examples can be found at: https://github.com/radiantrfid/iris/tree/master/_examples/file-server
 	HandleDir(requestPath, directory string, opts ...DirOptions) *Route
+	// HandleProxy registers a handler for all methods that proxies the requests
+	// of the "requestPath" and its sub paths to the "proxy", i.e a `host.LoadBalancer`.
+	// The "requestPath" is removed from the proxied request's path.
+	//
+	//     lb, _ := host.NewLoadBalancer(host.LoadBalancerConfig{Upstreams: []string{"http://10.0.0.1:8080"}})
+	//     api.HandleProxy("/users", lb)
+	//
+	// Returns the registered routes.
+	HandleProxy(requestPath string, proxy http.Handler) []*Route
 	// StaticWeb is DEPRECATED. Use HandleDir(requestPath, directory) instead.
 	StaticWeb(requestPath string, directory string) *Route
 	// StaticHandler is DEPRECATED.
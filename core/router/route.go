@@ -223,8 +223,12 @@ func formatPath(path string) string {
 				continue
 			}
 			if part[0] == startRune || part[0] == wildcardStartRune {
-				// is param or wildcard param
-				part = "%v"
+				// is param or wildcard param, keep its custom verb, if any, i.e :id:archive.
+				if verbIdx := strings.LastIndex(part, ParamStart); verbIdx > 0 {
+					part = "%v" + part[verbIdx:]
+				} else {
+					part = "%v"
+				}
 			}
 			formattedParts = append(formattedParts, part)
 		}
@@ -39,3 +39,18 @@ func TestRouteExists(t *testing.T) {
 	// run the tests
 	httptest.New(t, app, httptest.Debug(false)).Request("GET", "/route-test").Expect().Status(iris.StatusOK)
 }
+
+func TestRouteCustomVerb(t *testing.T) {
+	app := iris.New()
+	app.Get("/items/{id:uint64}", func(ctx context.Context) {
+		ctx.Writef("item %d", ctx.Params().GetUint64Default("id", 0))
+	})
+	app.Post("/items/{id:uint64}:archive", func(ctx context.Context) {
+		ctx.Writef("archived %d", ctx.Params().GetUint64Default("id", 0))
+	})
+
+	e := httptest.New(t, app, httptest.Debug(false))
+	e.GET("/items/42").Expect().Status(iris.StatusOK).Body().Equal("item 42")
+	e.POST("/items/42:archive").Expect().Status(iris.StatusOK).Body().Equal("archived 42")
+	e.POST("/items/42:unarchive").Expect().Status(iris.StatusNotFound)
+}
@@ -22,6 +22,7 @@ type trieNode struct {
 	hasDynamicChild        bool     // does one of the children contains a parameter or wildcard?
 	childNamedParameter    bool     // is the child a named parameter (single segmnet)
 	childWildcardParameter bool     // or it is a wildcard (can be more than one path segments) ?
+	childVerbParameter     bool     // or it is a named parameter followed by a custom verb, i.e :id:archive ?
 	paramKeys              []string // the param keys without : or *.
 	end                    bool     // it is a complete node, here we stop and we can say that the node is valid.
 	key                    string   // if end == true then key is filled with the original value of the insertion's key.
@@ -64,6 +65,21 @@ func (tn *trieNode) addChild(s string, n *trieNode) {
 	tn.children[s] = n
 }
 
+// getVerbChild returns the child of a named parameter followed by the custom verb of the "segment",
+// i.e the "42:archive" matches the :id:archive, and the parameter's value.
+func (tn *trieNode) getVerbChild(segment string) (*trieNode, string) {
+	if !tn.childVerbParameter {
+		return nil, ""
+	}
+
+	verbIdx := strings.LastIndex(segment, ParamStart)
+	if verbIdx <= 0 {
+		return nil, ""
+	}
+
+	return tn.getChild(ParamStart + segment[verbIdx:]), segment[:verbIdx]
+}
+
 func (tn *trieNode) findClosestParentWildcardNode() *trieNode {
 	tn = tn.parent
 	for tn != nil {
@@ -133,8 +149,16 @@ func (tr *trie) insert(path, routeName string, handlers context.Handlers) {
 
 			// if node has already a wildcard, don't force a value, check for true only.
 			if isParam {
-				n.childNamedParameter = true
-				s = ParamStart
+				if verbIdx := strings.Index(s[1:], ParamStart) + 1; verbIdx > 1 {
+					// a named parameter with a custom verb, i.e :id:archive,
+					// the child's key is the verb, i.e ::archive.
+					paramKeys[len(paramKeys)-1] = s[1:verbIdx]
+					n.childVerbParameter = true
+					s = ParamStart + s[verbIdx:]
+				} else {
+					n.childNamedParameter = true
+					s = ParamStart
+				}
 			}
 
 			if isWildcard {
@@ -195,6 +219,9 @@ func (tr *trie) search(q string, params *context.RequestParams) *trieNode {
 		if i == end || q[i] == pathSepB {
 			if child := n.getChild(q[start:i]); child != nil {
 				n = child
+			} else if child, paramValue := n.getVerbChild(q[start:i]); child != nil {
+				n = child
+				paramValues = append(paramValues, paramValue)
 			} else if n.childNamedParameter {
 				n = n.getChild(ParamStart)
 				if ln := len(paramValues); cap(paramValues) > ln {
@@ -0,0 +1,17 @@
+// +build go1.13
+
+package iris
+
+import (
+	"net/http"
+
+	"github.com/radiantrfid/iris/core/netutil"
+)
+
+// registerProxyConnContext sets the `netutil.ProxyConnContext` as the "srv"'s `ConnContext`,
+// if it's not already set.
+func registerProxyConnContext(srv *http.Server) {
+	if srv.ConnContext == nil {
+		srv.ConnContext = netutil.ProxyConnContext
+	}
+}
@@ -63,6 +63,15 @@ type (
 	//
 	// It is an alias of the `context#ProblemOptions` type.
 	ProblemOptions = context.ProblemOptions
+	// ErrorMapper maps errors to status codes and Problem responses,
+	// see `Application.ErrorMapper`.
+	//
+	// It is an alias of the `context#ErrorMapper` type.
+	ErrorMapper = context.ErrorMapper
+	// ProblemFunc builds the Problem of an error which matches an `ErrorMapper` mapping.
+	//
+	// It is an alias of the `context#ProblemFunc` type.
+	ProblemFunc = context.ProblemFunc
 	// JSON the optional settings for JSON renderer.
 	//
 	// It is an alias of the `context#JSON` type.
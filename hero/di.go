@@ -3,9 +3,15 @@ package hero
 import (
 	"reflect"
 
+	"github.com/radiantrfid/iris/context"
 	"github.com/radiantrfid/iris/hero/di"
+
+	"github.com/kataras/golog"
 )
 
+// requestScopeContextKey is the context's values key of the `di.RequestScope` of a request.
+const requestScopeContextKey = "_iris_hero_request_scope"
+
 func init() {
 	di.DefaultHijacker = func(fieldOrFuncInput reflect.Type) (*di.BindObject, bool) {
 		// if IsExpectingStore(fieldOrFuncInput) {
@@ -19,6 +25,25 @@ func init() {
 		// 	}, true
 		// }
 
+		if s, ok := paramsStructOf(fieldOrFuncInput); ok {
+			// bind the path parameters by their names.
+			return &di.BindObject{
+				Type:     fieldOrFuncInput,
+				BindType: di.Dynamic,
+				ReturnValue: func(ctxValue []reflect.Value) reflect.Value {
+					ctx := ctxValue[0].Interface().(context.Context)
+					v, err := s.bind(ctx)
+					if err != nil {
+						// i.e a value of a custom macro which can not be converted to the field's type.
+						DispatchErr(ctx, DefaultErrStatusCode, err)
+						ctx.StopExecution()
+					}
+
+					return v
+				},
+			}, true
+		}
+
 		if !IsContext(fieldOrFuncInput) {
 			return nil, false
 		}
@@ -35,6 +60,29 @@ func init() {
 		}, true
 	}
 
+	// the request-scoped dependencies are cached to the context's values
+	// and they are disposed when the context is released.
+	di.DefaultRequestScope = func(ctxValue reflect.Value) *di.RequestScope {
+		ctx, ok := ctxValue.Interface().(context.Context)
+		if !ok {
+			return nil
+		}
+
+		if scope, ok := ctx.Values().Get(requestScopeContextKey).(*di.RequestScope); ok {
+			return scope
+		}
+
+		scope := di.NewRequestScope()
+		ctx.Values().Set(requestScopeContextKey, scope)
+		ctx.OnEndRequest(func() {
+			if err := scope.Dispose(); err != nil {
+				golog.Debugf("hero: dispose request-scoped dependencies: %v", err)
+			}
+		})
+
+		return scope
+	}
+
 	di.DefaultTypeChecker = func(fn reflect.Type) bool {
 		// valid if that single input arg is a typeof context.Context
 		// or first argument is context.Context and second argument is a variadic, which is ignored (i.e new sessions#Start).
@@ -47,8 +47,13 @@ func (s *FuncInjector) miss(index int) {
 // MakeFuncInjector returns a new func injector, which will be the object
 // that the caller should use to bind input arguments of the "fn" function.
 //
-// The hijack and the goodFunc are optional, the "values" is the dependencies collection.
+// The hijack and the goodFunc are optional, the "values" is the dependencies collection,
+// its constructors are resolved, see `Resolve`.
 func MakeFuncInjector(fn reflect.Value, hijack Hijacker, goodFunc TypeChecker, values ...reflect.Value) *FuncInjector {
+	return makeFuncInjector(fn, hijack, goodFunc, resolveValues(values, hijack, goodFunc)...)
+}
+
+func makeFuncInjector(fn reflect.Value, hijack Hijacker, goodFunc TypeChecker, values ...reflect.Value) *FuncInjector {
 	typ := IndirectType(fn.Type())
 	s := &FuncInjector{
 		fn:       fn,
@@ -171,6 +176,18 @@ func (s *FuncInjector) Retry(retryFn func(inIndex int, inTyp reflect.Type) (refl
 	return s.Length == s.typ.NumIn()
 }
 
+// Missing returns the indexes of the input arguments which are not bound to any value,
+// even after a `Retry`.
+func (s *FuncInjector) Missing() (indexes []int) {
+	for _, missing := range s.lost {
+		if !missing.found {
+			indexes = append(indexes, missing.index)
+		}
+	}
+
+	return
+}
+
 // String returns a debug trace text.
 func (s *FuncInjector) String() (trace string) {
 	for i, in := range s.inputs {
@@ -179,12 +196,31 @@ func (s *FuncInjector) String() (trace string) {
 		// remember: on methods that are part of a struct (i.e controller)
 		// the input index  = 1 is the begggining instead of the 0,
 		// because the 0 is the controller receiver pointer of the method.
-		trace += fmt.Sprintf("[%d] %s binding: '%s' for input position: %d and type: '%s'\n",
-			i+1, bindmethodTyp, in.Object.Type.String(), in.InputIndex, typIn.String())
+		trace += fmt.Sprintf("[%d] %s binding: '%s'%s for input position: %d and type: '%s'\n",
+			i+1, bindmethodTyp, in.Object.Type.String(), in.Object.sourceTrace(), in.InputIndex, typIn.String())
 	}
 	return
 }
 
+// bindObject returns the dynamic bind object of a constructor,
+// its value is the first result of the function which is called with the injected input arguments.
+func (s *FuncInjector) bindObject() BindObject {
+	typ := s.typ.Out(0)
+	zero := reflect.New(typ).Elem()
+
+	returnValue := func(ctxValue []reflect.Value) (reflect.Value, bool) {
+		return returnValueOf(s.Call(ctxValue...), ctxValue, zero)
+	}
+
+	return BindObject{
+		Type:        typ,
+		BindType:    Dynamic,
+		ReturnValue: withoutOK(returnValue),
+		returnValue: returnValue,
+		source:      "constructor " + funcName(s.fn),
+	}
+}
+
 // Inject accepts an already created slice of input arguments
 // and fills them, the "ctx" is optional and it's used
 // on the dependencies that depends on one or more input arguments, these are the "ctx".
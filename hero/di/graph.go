@@ -0,0 +1,253 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// IsConstructor returns true if the "v" value is a constructor of a dependency:
+// a function registered with an explicit lifetime, see `WithLifetime`,
+// which returns a value, or a value and an error,
+// and its input arguments are resolved from other dependencies, see `Resolve`.
+//
+// A plain function value is never a constructor, the registration of a constructor is explicit.
+// The functions that pass the "goodFunc" type checker, i.e `func(ctx) T`,
+// are the simple dynamic dependencies instead. There are no constructors without a type checker.
+func IsConstructor(v reflect.Value, goodFunc TypeChecker) bool {
+	dep, ok := dependencyOf(v)
+	if !ok || goodFunc == nil || !IsFunc(dep.Value) {
+		return false
+	}
+
+	fn := dep.Value
+
+	typ := fn.Type()
+	n := typ.NumOut()
+	if !(n == 1 || (n == 2 && IsError(typ.Out(1)))) {
+		return false
+	}
+
+	return !goodFunc(typ)
+}
+
+const (
+	unresolved uint8 = iota
+	resolving
+	resolved
+)
+
+type graphResolver struct {
+	values   []reflect.Value
+	hijack   Hijacker
+	goodFunc TypeChecker
+
+	states   []uint8
+	resolved []reflect.Value
+	errs     []error
+	path     []int // the constructors being resolved, for the cycle errors.
+}
+
+// Resolve binds the constructors of the "values" to the dependencies of their input arguments,
+// which are resolved from the rest of the "values", recursively,
+// so a dependency can depend on other dependencies, registered in any order.
+// The input arguments that the "hijack" accepts, i.e the Context, are resolved on serve-time as usual.
+//
+// It returns the resolved values, in the same order, without the constructors that could not be resolved,
+// and the first error: a dependency cycle or a missing dependency of a constructor's input argument.
+//
+// The injectors resolve their values automatically, it's useful to validate the dependencies at startup.
+func Resolve(values []reflect.Value, hijack Hijacker, goodFunc TypeChecker) (Values, error) {
+	r := newGraphResolver(values, hijack, goodFunc)
+
+	var err error
+	for i := range values {
+		if resolveErr := r.resolve(i); resolveErr != nil && err == nil {
+			err = resolveErr
+		}
+	}
+
+	result := make(Values, 0, len(values))
+	for _, v := range r.resolved {
+		if v.IsValid() {
+			result = append(result, v)
+		}
+	}
+
+	return result, err
+}
+
+// Validate resolves the constructors of the "values" which the "types" depend on,
+// i.e the input arguments of a handler or the fields of a controller,
+// and returns the first error: a dependency cycle or a missing dependency of a constructor's input argument.
+// The rest of the constructors are not checked, so an unused one does not fail its consumers.
+func Validate(values []reflect.Value, types []reflect.Type, hijack Hijacker, goodFunc TypeChecker) error {
+	r := newGraphResolver(values, hijack, goodFunc)
+
+	for _, typ := range types {
+		if hijack != nil {
+			if b, ok := hijack(typ); ok && b != nil {
+				continue
+			}
+		}
+
+		if k := r.lookup(-1, typ); k != -1 {
+			if err := r.resolve(k); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func newGraphResolver(values []reflect.Value, hijack Hijacker, goodFunc TypeChecker) *graphResolver {
+	return &graphResolver{
+		values:   values,
+		hijack:   hijack,
+		goodFunc: goodFunc,
+		states:   make([]uint8, len(values)),
+		resolved: make([]reflect.Value, len(values)),
+		errs:     make([]error, len(values)),
+	}
+}
+
+// resolveValues is used by the injectors, the errors are reported by the caller, if needed, see `Resolve`.
+func resolveValues(values []reflect.Value, hijack Hijacker, goodFunc TypeChecker) []reflect.Value {
+	for _, v := range values {
+		if IsConstructor(v, goodFunc) {
+			resolvedValues, _ := Resolve(values, hijack, goodFunc)
+			return resolvedValues
+		}
+	}
+
+	// fast path, no constructors.
+	return values
+}
+
+func (r *graphResolver) resolve(i int) error {
+	switch r.states[i] {
+	case resolved:
+		return r.errs[i]
+	case resolving:
+		return r.cycleError(i)
+	}
+
+	v := r.values[i]
+	dep, _ := dependencyOf(v)
+	// a constructor is always a dependency, an already bound one is kept as it is.
+	if !IsConstructor(v, r.goodFunc) || dep.constructor != nil {
+		r.resolved[i] = v
+		r.states[i] = resolved
+		return nil
+	}
+
+	fn := dep.Value
+
+	r.states[i] = resolving
+	r.path = append(r.path, i)
+	injector, err := r.bind(i, fn)
+	r.path = r.path[:len(r.path)-1]
+	r.states[i] = resolved
+
+	if err != nil {
+		r.errs[i] = err
+		return err
+	}
+
+	r.resolved[i] = reflect.ValueOf(&Dependency{Value: fn, Lifetime: dep.Lifetime, constructor: injector, origin: dep})
+	return nil
+}
+
+// bind returns the injector of the input arguments of the "fn" constructor of the "i" value.
+func (r *graphResolver) bind(i int, fn reflect.Value) (*FuncInjector, error) {
+	typ := fn.Type()
+
+	var inputs []reflect.Value
+	for in := 0; in < typ.NumIn(); in++ {
+		inTyp := typ.In(in)
+
+		if r.hijack != nil {
+			if b, ok := r.hijack(inTyp); ok && b != nil {
+				continue
+			}
+		}
+
+		k := r.lookup(i, inTyp)
+		if k == -1 {
+			return nil, fmt.Errorf("di: no dependency of type '%s' for the input argument #%d of the constructor '%s'",
+				inTyp.String(), in, funcName(fn))
+		}
+
+		if err := r.resolve(k); err != nil {
+			return nil, err
+		}
+
+		inputs = append(inputs, r.resolved[k])
+	}
+
+	injector := makeFuncInjector(fn, r.hijack, r.goodFunc, inputs...)
+	if injector.Length != typ.NumIn() {
+		return nil, fmt.Errorf("di: unable to bind the input arguments of the constructor '%s'", funcName(fn))
+	}
+
+	return injector, nil
+}
+
+// lookup returns the index of the first value, except the "i" one, which can be bound to the "typ",
+// or -1 if there is no such value.
+func (r *graphResolver) lookup(i int, typ reflect.Type) int {
+	for k, v := range r.values {
+		if k == i {
+			continue
+		}
+
+		if valueTyp, ok := r.typeOf(v); ok && equalTypes(valueTyp, typ) {
+			return k
+		}
+	}
+
+	return -1
+}
+
+func (r *graphResolver) typeOf(v reflect.Value) (reflect.Type, bool) {
+	return typeOf(v, r.goodFunc)
+}
+
+// typeOf returns the type that the "v" value binds.
+func typeOf(v reflect.Value, goodFunc TypeChecker) (reflect.Type, bool) {
+	if IsConstructor(v, goodFunc) {
+		return unwrapValue(v).Type().Out(0), true
+	}
+
+	b, err := MakeBindObject(v, goodFunc)
+	if err != nil {
+		return nil, false
+	}
+
+	return b.Type, true
+}
+
+func (r *graphResolver) cycleError(i int) error {
+	var names []string
+	for j := len(r.path) - 1; j >= 0; j-- {
+		names = append([]string{funcName(unwrapValue(r.values[r.path[j]]))}, names...)
+		if r.path[j] == i {
+			break
+		}
+	}
+
+	names = append(names, funcName(unwrapValue(r.values[i])))
+	return fmt.Errorf("di: dependency cycle: %s", strings.Join(names, " -> "))
+}
+
+// funcName returns the name of the "fn" function for debug info,
+// i.e "main.newService func(main.Config) *main.Service".
+func funcName(fn reflect.Value) string {
+	if f := runtime.FuncForPC(fn.Pointer()); f != nil {
+		return f.Name() + " " + fn.Type().String()
+	}
+
+	return fn.Type().String()
+}
@@ -0,0 +1,210 @@
+package di
+
+import (
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Lifetime is the lifetime of the value of a registered dependency,
+// see `WithLifetime`.
+type Lifetime uint8
+
+const (
+	// TransientLifetime is the lifetime of a dependency which is resolved on each binding,
+	// it's the default lifetime of the dynamic dependencies, the `func(ctx) T` ones.
+	TransientLifetime Lifetime = iota
+	// RequestLifetime is the lifetime of a dependency which is resolved once per request,
+	// even if several handlers, controllers or fields ask for it.
+	// Its value is disposed at the end of the request if it's an `io.Closer`, see `RequestScope`.
+	RequestLifetime
+	// SingletonLifetime is the lifetime of a dependency which is resolved once,
+	// on its first binding, and then shared between all requests.
+	SingletonLifetime
+)
+
+// read-only on runtime.
+var lifetimeNames = map[Lifetime]string{
+	TransientLifetime: "Transient",
+	RequestLifetime:   "Request",
+	SingletonLifetime: "Singleton",
+}
+
+// Return "Transient", "Request" or "Singleton".
+func (lifetime Lifetime) String() string {
+	name, ok := lifetimeNames[lifetime]
+	if !ok {
+		return "Unknown"
+	}
+
+	return name
+}
+
+// Dependency is a dependency value with an explicit `Lifetime`.
+// It can be registered to the `Values` as any other dependency, see `WithLifetime`.
+//
+// The lifetime applies to the functions that return the dependency's value,
+// a static value is always the same between bindings.
+type Dependency struct {
+	Value    reflect.Value
+	Lifetime Lifetime
+
+	mu        sync.Mutex // protects the resolution of the singleton.
+	resolved  uint32     // 1 when the singleton is resolved.
+	singleton reflect.Value
+
+	// set if the Value is a constructor which is bound to its input arguments, see `Resolve`.
+	constructor *FuncInjector
+	// the registered dependency of the bound constructor, it keeps the resolved values.
+	origin *Dependency
+}
+
+// WithLifetime returns a dependency of the "v" value or function
+// which is resolved based on the "lifetime".
+//
+// Example: `Values.Add(WithLifetime(func(ctx iris.Context) *sql.Tx {...}, RequestLifetime))`.
+func WithLifetime(v interface{}, lifetime Lifetime) *Dependency {
+	return &Dependency{Value: ValueOf(v), Lifetime: lifetime}
+}
+
+var dependencyTyp = reflect.TypeOf((*Dependency)(nil))
+
+// dependencyOf returns the `Dependency` of "v" if "v" is registered with an explicit lifetime.
+func dependencyOf(v reflect.Value) (*Dependency, bool) {
+	if !v.IsValid() || v.Type() != dependencyTyp || v.IsNil() {
+		return nil, false
+	}
+
+	return v.Interface().(*Dependency), true
+}
+
+// unwrapValue returns the actual value of "v", if it's a `Dependency`.
+func unwrapValue(v reflect.Value) reflect.Value {
+	if dep, ok := dependencyOf(v); ok {
+		return dep.Value
+	}
+
+	return v
+}
+
+// key returns the registered dependency, it's the key of the singleton and the request-scoped values.
+func (dep *Dependency) key() *Dependency {
+	if dep.origin != nil {
+		return dep.origin
+	}
+
+	return dep
+}
+
+// resolver returns the binder function which resolves the dynamic "b" based on the dependency's lifetime.
+func (dep *Dependency) resolver(b BindObject) func([]reflect.Value) reflect.Value {
+	dep = dep.key()
+
+	switch dep.Lifetime {
+	case SingletonLifetime:
+		return func(ctxValue []reflect.Value) reflect.Value {
+			if atomic.LoadUint32(&dep.resolved) == 1 {
+				return dep.singleton
+			}
+
+			dep.mu.Lock()
+			defer dep.mu.Unlock()
+			if dep.resolved == 1 {
+				return dep.singleton
+			}
+
+			// only a successful result is kept, a failed one is retried on the next binding.
+			v, ok := b.resolve(ctxValue)
+			if ok {
+				dep.singleton = v
+				atomic.StoreUint32(&dep.resolved, 1)
+			}
+
+			return v
+		}
+	case RequestLifetime:
+		return func(ctxValue []reflect.Value) reflect.Value {
+			var scope *RequestScope
+			if DefaultRequestScope != nil && len(ctxValue) > 0 {
+				scope = DefaultRequestScope(ctxValue[0])
+			}
+
+			if scope == nil {
+				// not a request, act as transient.
+				return b.ReturnValue(ctxValue)
+			}
+
+			return scope.resolve(dep, func() reflect.Value {
+				return b.ReturnValue(ctxValue)
+			})
+		}
+	default:
+		return b.ReturnValue
+	}
+}
+
+// DefaultRequestScope returns the `RequestScope` of a "ctx" input argument,
+// the dependencies of `RequestLifetime` are resolved as transient ones if it's nil or it returns nil.
+// The "hero" package sets it to the scope of the `context.Context`, which is disposed on its `EndRequest`.
+var DefaultRequestScope func(ctx reflect.Value) *RequestScope
+
+// RequestScope keeps the resolved values of the `RequestLifetime` dependencies of a request.
+type RequestScope struct {
+	mu       sync.Mutex
+	values   map[*Dependency]reflect.Value
+	resolved []reflect.Value // in order of resolution, for disposal.
+}
+
+// NewRequestScope returns a new, empty, request scope.
+func NewRequestScope() *RequestScope {
+	return &RequestScope{values: make(map[*Dependency]reflect.Value)}
+}
+
+func (s *RequestScope) resolve(dep *Dependency, resolve func() reflect.Value) reflect.Value {
+	s.mu.Lock()
+	v, ok := s.values[dep]
+	s.mu.Unlock()
+	if ok {
+		return v
+	}
+
+	// not locked, a constructor may resolve other request-scoped dependencies.
+	v = resolve()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.values[dep]; ok {
+		return existing
+	}
+
+	s.values[dep] = v
+	s.resolved = append(s.resolved, v)
+	return v
+}
+
+// Dispose closes the resolved values which implement the `io.Closer`,
+// in the reverse order of their resolution.
+// It returns the first error, if any, all values are closed anyway.
+func (s *RequestScope) Dispose() (err error) {
+	s.mu.Lock()
+	resolved := s.resolved
+	s.values = make(map[*Dependency]reflect.Value)
+	s.resolved = nil
+	s.mu.Unlock()
+
+	for i := len(resolved) - 1; i >= 0; i-- {
+		v := resolved[i]
+		if !v.IsValid() || IsNil(v) || !v.CanInterface() {
+			continue
+		}
+
+		if closer, ok := v.Interface().(io.Closer); ok {
+			if closeErr := closer.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	}
+
+	return
+}
@@ -36,6 +36,10 @@ type BindObject struct {
 
 	BindType    BindType
 	ReturnValue func([]reflect.Value) reflect.Value
+
+	// same as the ReturnValue but it reports false if the function returned an error, if not nil.
+	returnValue func([]reflect.Value) (reflect.Value, bool)
+	source      string // the function which returns the dynamic value, for debug info.
 }
 
 // MakeBindObject accepts any "v" value, struct, pointer or a function
@@ -43,10 +47,31 @@ type BindObject struct {
 // or the input arguments (if "v.elem()" is func)
 // are valid to be included as the final object's dependencies, even if the caller added more
 // the "di" is smart enough to select what each "v" needs and what not before serve time.
+//
+// A `Dependency` "v" is resolved based on its `Lifetime`.
 func MakeBindObject(v reflect.Value, goodFunc TypeChecker) (b BindObject, err error) {
+	if dep, ok := dependencyOf(v); ok {
+		if dep.constructor != nil {
+			b = dep.constructor.bindObject()
+		} else {
+			b, err = MakeBindObject(dep.Value, goodFunc)
+		}
+
+		if err == nil && b.BindType == Dynamic {
+			b.ReturnValue = dep.resolver(b)
+			b.returnValue = nil
+		}
+
+		return
+	}
+
 	if IsFunc(v) {
 		b.BindType = Dynamic
-		b.ReturnValue, b.Type, err = MakeReturnValue(v, goodFunc)
+		b.returnValue, b.Type, err = makeReturnValue(v, goodFunc)
+		if err == nil {
+			b.ReturnValue = withoutOK(b.returnValue)
+		}
+		b.source = funcName(v)
 	} else {
 		b.BindType = Static
 		b.Type = v.Type()
@@ -70,6 +95,17 @@ var errBad = errors.New("bad")
 // The return type of the "fn" should be a value instance, not a pointer, for your own protection.
 // The binder function should return only one value.
 func MakeReturnValue(fn reflect.Value, goodFunc TypeChecker) (func([]reflect.Value) reflect.Value, reflect.Type, error) {
+	bf, typ, err := makeReturnValue(fn, goodFunc)
+	if err != nil {
+		return nil, typ, err
+	}
+
+	return withoutOK(bf), typ, nil
+}
+
+// makeReturnValue same as `MakeReturnValue` but its binder function
+// reports false if the "fn" returned an error, see `returnValueOf`.
+func makeReturnValue(fn reflect.Value, goodFunc TypeChecker) (func([]reflect.Value) (reflect.Value, bool), reflect.Type, error) {
 	typ := IndirectType(fn.Type())
 
 	// invalid if not a func.
@@ -93,42 +129,76 @@ func MakeReturnValue(fn reflect.Value, goodFunc TypeChecker) (func([]reflect.Val
 	firstOutTyp := typ.Out(0)
 	firstZeroOutVal := reflect.New(firstOutTyp).Elem()
 
-	bf := func(ctxValue []reflect.Value) reflect.Value {
-		results := fn.Call(ctxValue)
+	bf := func(ctxValue []reflect.Value) (reflect.Value, bool) {
+		return returnValueOf(fn.Call(ctxValue), ctxValue, firstZeroOutVal)
+	}
 
-		v := results[0]
-		if !v.IsValid() { // check the first value, second is error.
-			return firstZeroOutVal
-		}
+	return bf, firstOutTyp, nil
+}
 
-		if n == 2 {
-			// two, second is always error.
-			errVal := results[1]
-			if !errVal.IsNil() {
-				// error is not nil, do something with it.
-				if ctx, ok := ctxValue[0].Interface().(interface {
-					StatusCode(int)
-					WriteString(string) (int, error)
-					StopExecution()
-				}); ok {
-					ctx.StatusCode(400)
-					ctx.WriteString(errVal.Interface().(error).Error())
-					ctx.StopExecution()
-				}
-
-				return firstZeroOutVal
+// withoutOK converts a binder function which reports its success to a `BindObject.ReturnValue`.
+func withoutOK(bf func([]reflect.Value) (reflect.Value, bool)) func([]reflect.Value) reflect.Value {
+	return func(ctxValue []reflect.Value) reflect.Value {
+		v, _ := bf(ctxValue)
+		return v
+	}
+}
+
+// returnValueOf returns the first of the "results" of a binder function or a constructor.
+// If the second one is a non-nil error then the error is written to the "ctxValue"
+// and the "zero" value is returned instead, it reports false then.
+func returnValueOf(results []reflect.Value, ctxValue []reflect.Value, zero reflect.Value) (reflect.Value, bool) {
+	v := results[0]
+	if !v.IsValid() { // check the first value, second is error.
+		return zero, true
+	}
+
+	if len(results) == 2 {
+		// two, second is always error.
+		errVal := results[1]
+		if !errVal.IsNil() {
+			// error is not nil, do something with it.
+			if len(ctxValue) == 0 {
+				return zero, false
+			}
+
+			// the mapped errors are dispatched through the application's error mapper,
+			// see `context.StopWithError`.
+			if ctx, ok := ctxValue[0].Interface().(interface {
+				StopWithError(int, error)
+			}); ok {
+				ctx.StopWithError(400, errVal.Interface().(error))
 			}
+
+			return zero, false
 		}
+	}
 
-		// if v.String() == "<interface {} Value>" {
-		// 	println("di/object.go: " + v.String())
-		// 	// println("di/object.go: because it's interface{} it should be returned as: " + v.Elem().Type().String() + " and its value: " + v.Elem().Interface().(string))
-		// 	return v.Elem()
-		// }
-		return v
+	// if v.String() == "<interface {} Value>" {
+	// 	println("di/object.go: " + v.String())
+	// 	// println("di/object.go: because it's interface{} it should be returned as: " + v.Elem().Type().String() + " and its value: " + v.Elem().Interface().(string))
+	// 	return v.Elem()
+	// }
+	return v, true
+}
+
+// sourceTrace returns the debug info of the function which returns the dynamic value, if any.
+func (b *BindObject) sourceTrace() string {
+	if b.source == "" {
+		return ""
 	}
 
-	return bf, firstOutTyp, nil
+	return " by '" + b.source + "'"
+}
+
+// resolve calls the dynamic value's function,
+// it reports false if the function returned an error.
+func (b *BindObject) resolve(ctxValue []reflect.Value) (reflect.Value, bool) {
+	if b.returnValue != nil {
+		return b.returnValue(ctxValue)
+	}
+
+	return b.ReturnValue(ctxValue), true
 }
 
 // IsAssignable checks if "to" type can be used as "b.Value/ReturnValue".
@@ -186,6 +186,18 @@ func NumFields(elemTyp reflect.Type, skipUnexported bool) int {
 	return len(lookupFields(elemTyp, skipUnexported, nil))
 }
 
+// FieldTypes returns the types of the fields, and the embedded ones, of the "elemTyp" struct type,
+// i.e to validate the dependencies of a controller, see `Validate`.
+func FieldTypes(elemTyp reflect.Type, skipUnexported bool) []reflect.Type {
+	fields := lookupFields(elemTyp, skipUnexported, nil)
+	types := make([]reflect.Type, len(fields))
+	for i, f := range fields {
+		types[i] = f.Type
+	}
+
+	return types
+}
+
 func lookupFields(elemTyp reflect.Type, skipUnexported bool, parentIndex []int) (fields []field) {
 	if elemTyp.Kind() != reflect.Struct {
 		return
@@ -100,8 +100,10 @@ var SortByNumMethods Sorter = func(t1 reflect.Type, t2 reflect.Type) bool {
 // embedded unexported fields that contain exported fields
 // of the "v" struct value or pointer.
 //
-// The hijack and the goodFunc are optional, the "values" is the dependencies collection.
+// The hijack and the goodFunc are optional, the "values" is the dependencies collection,
+// its constructors are resolved, see `Resolve`.
 func MakeStructInjector(v reflect.Value, hijack Hijacker, goodFunc TypeChecker, sorter Sorter, values ...reflect.Value) *StructInjector {
+	values = resolveValues(values, hijack, goodFunc)
 	s := &StructInjector{
 		initRef:        v,
 		initRefAsSlice: []reflect.Value{v},
@@ -269,14 +271,17 @@ func (s *StructInjector) String() (trace string) {
 			format += "\n"
 		}
 
-		if !f.Object.Value.IsValid() {
-			continue // probably a Context.
-		}
-
-		valuePresent := f.Object.Value.Interface()
-
+		var valuePresent interface{}
 		if f.Object.BindType == Dynamic {
-			valuePresent = f.Object.Type.String()
+			if f.Object.source == "" {
+				continue // probably a Context.
+			}
+
+			valuePresent = f.Object.Type.String() + f.Object.sourceTrace()
+		} else if f.Object.Value.IsValid() {
+			valuePresent = f.Object.Value.Interface()
+		} else {
+			continue
 		}
 
 		trace += fmt.Sprintf(format, i+1, bindTypeString(f.Object.BindType), valuePresent, elemField.Name, elemField.Type.String())
@@ -0,0 +1,143 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DescribeUnresolved returns a description of the "index" input argument of the "typ" type
+// which can not be resolved by the "values",
+// with the closest types of the "values" as suggestions, see `Suggest`.
+func DescribeUnresolved(index int, typ reflect.Type, values []reflect.Value, goodFunc TypeChecker) string {
+	desc := fmt.Sprintf("unresolved input argument #%d of type '%s'", index, typ.String())
+
+	suggestions := Suggest(values, typ, goodFunc)
+	if len(suggestions) == 0 {
+		return desc + ", there is no registered dependency of a similar type"
+	}
+
+	names := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		names[i] = "'" + suggestion.String() + "'"
+	}
+
+	return desc + ", did you mean " + strings.Join(names, " or ") + "?"
+}
+
+// maxSuggestions is the maximum number of the types returned by the `Suggest`.
+const maxSuggestions = 3
+
+// Suggest returns the types which are bound by the "values" and they are close to the "typ",
+// the closest first: the `T` of a `*T` and the other way around or a type of a similar name.
+func Suggest(values []reflect.Value, typ reflect.Type, goodFunc TypeChecker) []reflect.Type {
+	type candidate struct {
+		typ      reflect.Type
+		distance int
+	}
+
+	var (
+		candidates []candidate
+		visited    = make(map[reflect.Type]struct{})
+	)
+
+	for _, v := range values {
+		valueTyp, ok := typeOf(v, goodFunc)
+		if !ok {
+			continue
+		}
+
+		if _, ok := visited[valueTyp]; ok {
+			continue
+		}
+		visited[valueTyp] = struct{}{}
+
+		if distance, ok := typeDistance(typ, valueTyp); ok {
+			candidates = append(candidates, candidate{valueTyp, distance})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	if len(candidates) > maxSuggestions {
+		candidates = candidates[:maxSuggestions]
+	}
+
+	types := make([]reflect.Type, len(candidates))
+	for i, c := range candidates {
+		types[i] = c.typ
+	}
+
+	return types
+}
+
+// typeDistance returns the edit distance of the names of the "expected" and "got" types,
+// it's zero if they differ only by a pointer.
+// It reports false if they are not similar at all, i.e one name does not contain the other.
+func typeDistance(expected, got reflect.Type) (int, bool) {
+	expected, got = elemType(expected), elemType(got)
+	if expected == got {
+		return 0, true
+	}
+
+	expectedName, gotName := strings.ToLower(typeName(expected)), strings.ToLower(typeName(got))
+	distance := levenshtein(expectedName, gotName)
+
+	max := len(expectedName) / 3
+	if max < 2 {
+		max = 2
+	}
+
+	similar := distance <= max || strings.Contains(expectedName, gotName) || strings.Contains(gotName, expectedName)
+	return distance, similar
+}
+
+func elemType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	return typ
+}
+
+func typeName(typ reflect.Type) string {
+	if name := typ.Name(); name != "" {
+		return name
+	}
+
+	return typ.String()
+}
+
+// levenshtein returns the number of the single-character edits which are required to change the "a" to "b".
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = prev[j-1] + cost
+			if deletion := prev[j] + 1; deletion < curr[j] {
+				curr[j] = deletion
+			}
+			if insertion := curr[j-1] + 1; insertion < curr[j] {
+				curr[j] = insertion
+			}
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -84,7 +84,7 @@ func (bv *Values) Remove(value interface{}, n int) bool {
 func (bv *Values) remove(typ reflect.Type, n int) (ok bool) {
 	input := *bv
 	for i, in := range input {
-		if equalTypes(in.Type(), typ) {
+		if equalTypes(unwrapValue(in).Type(), typ) {
 			ok = true
 			input = input[:i+copy(input[i:], input[i+1:])]
 			if n > 1 {
@@ -107,7 +107,7 @@ func (bv Values) Has(value interface{}) bool {
 
 func (bv Values) valueTypeExists(typ reflect.Type) bool {
 	for _, in := range bv {
-		if equalTypes(in.Type(), typ) {
+		if equalTypes(unwrapValue(in).Type(), typ) {
 			return true
 		}
 	}
@@ -125,7 +125,7 @@ func (bv *Values) AddOnce(value interface{}) bool {
 
 func (bv *Values) addIfNotExists(v reflect.Value) bool {
 
-	typ := v.Type() // no element, raw things here.
+	typ := unwrapValue(v).Type() // no element, raw things here.
 
 	if !goodVal(v) {
 		return false
@@ -80,7 +80,12 @@ type compatibleErr interface {
 var DefaultErrStatusCode = 400
 
 // DispatchErr writes the error to the response.
+// The errors that are mapped to the application's `ErrorMapper` are written as a Problem instead.
 func DispatchErr(ctx context.Context, status int, err error) {
+	if ctx.Application().ErrorMapper().Dispatch(ctx, err) {
+		return
+	}
+
 	if status < 400 {
 		status = DefaultErrStatusCode
 	}
@@ -129,12 +134,16 @@ func DispatchCommon(ctx context.Context,
 			return
 		}
 
-		if strings.HasPrefix(contentType, context.ContentJavascriptHeaderValue) {
+		if contentType == "" {
+			// content type is missing, respond based on the client's accepted content types,
+			// defaults to json.
+			err = DispatchNegotiated(ctx, v)
+		} else if strings.HasPrefix(contentType, context.ContentJavascriptHeaderValue) {
 			_, err = ctx.JSONP(v)
 		} else if strings.HasPrefix(contentType, context.ContentXMLHeaderValue) {
 			_, err = ctx.XML(v, context.XML{Indent: " "})
 		} else {
-			// defaults to json if content type is missing or its application/json.
+			// defaults to json if content type is application/json or unknown.
 			_, err = ctx.JSON(v, context.JSON{Indent: " "})
 		}
 
@@ -192,7 +201,7 @@ func DispatchFuncResult(ctx context.Context, errorHandler ErrorHandler, values [
 		// Except when found == false, then the status code is 404.
 		statusCode int
 		// if not empty then use that as content type,
-		// if empty and custom != nil then set it to application/json.
+		// if empty and custom != nil then it's negotiated, see `DispatchNegotiated`.
 		contentType string
 		// if len > 0 then write that to the response writer as raw bytes,
 		// except when found == false or err != nil or custom != nil.
@@ -348,6 +357,12 @@ func DispatchFuncResult(ctx context.Context, errorHandler ErrorHandler, values [
 					continue
 				}
 
+				// channels, iterators and readers are written as they are produced.
+				if stream, ok := streamOf(v); ok {
+					custom = stream
+					continue
+				}
+
 				if value != nil {
 					custom = value // content type will be take care later on.
 				}
@@ -370,8 +385,9 @@ type Response struct {
 	// if not empty then content type is the text/plain
 	// and content is the text as []byte.
 	Text string
-	// If not nil then it will fire that as "application/json" or the
-	// "ContentType" if not empty.
+	// If not nil then it will fire that based on the "ContentType",
+	// JSON, JSONP or XML, or, if it's empty, based on the client's accepted content types,
+	// i.e XML or YAML, and defaults to "application/json", see `DispatchNegotiated`.
 	Object interface{}
 
 	// If Path is not empty then it will redirect
@@ -1,8 +1,16 @@
 package hero_test
 
 import (
+	"bufio"
+	stdContext "context"
+	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
+	nethttptest "net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/radiantrfid/iris"
 	"github.com/radiantrfid/iris/context"
@@ -204,3 +212,238 @@ func TestFuncResult(t *testing.T) {
 	e.GET("/custom/nil/struct").Expect().
 		Status(iris.StatusOK).ContentType(context.ContentJSONHeaderValue).Body().Empty()
 }
+
+var errNotFound = errors.New("not found")
+
+type (
+	wrappedErr struct {
+		err error
+	}
+
+	validationErr struct {
+		Fields []string
+	}
+)
+
+func (e wrappedErr) Error() string { return "wrapped: " + e.err.Error() }
+func (e wrappedErr) Unwrap() error { return e.err }
+
+func (e *validationErr) Error() string { return "validation failed" }
+
+func TestErrorMapper(t *testing.T) {
+	app := iris.New()
+	app.ErrorMapper().
+		Map(errNotFound, iris.StatusNotFound).
+		Map((*validationErr)(nil), iris.StatusUnprocessableEntity, func(ctx iris.Context, err error) iris.Problem {
+			return iris.NewProblem().Detail(err.Error()).Key("fields", err.(*validationErr).Fields)
+		})
+
+	app.Get("/not-found", Handler(func() error { return wrappedErr{errNotFound} }))
+	app.Get("/validation", Handler(func() (string, error) {
+		return "", wrappedErr{&validationErr{Fields: []string{"name"}}}
+	}))
+	app.Get("/unmapped", Handler(func() error { return errors.New("unmapped") }))
+	app.Get("/plain", func(ctx iris.Context) {
+		ctx.StopWithError(iris.StatusInternalServerError, errNotFound)
+	})
+	// a failed dependency.
+	app.Get("/dependency", New().Register(func(ctx iris.Context) (testNegotiated, error) {
+		return testNegotiated{}, wrappedErr{errNotFound}
+	}).Handler(func(v testNegotiated) string { return v.Name }))
+
+	e := httptest.New(t, app)
+	expectProblem := func(path string, status int, expected iris.Map) {
+		t.Helper()
+
+		body := e.GET(path).Expect().Status(status).
+			ContentType(context.ContentJSONProblemHeaderValue, "utf-8").Body().Raw()
+		var got iris.Map
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatal(err)
+		}
+		if expectedBody := mustMarshal(t, expected); string(expectedBody) != string(mustMarshal(t, got)) {
+			t.Fatalf("[%s] expected problem: %s but got: %s", path, expectedBody, body)
+		}
+	}
+
+	expectProblem("/not-found", iris.StatusNotFound,
+		iris.Map{"status": iris.StatusNotFound, "title": "Not Found", "detail": "wrapped: not found"})
+	expectProblem("/validation", iris.StatusUnprocessableEntity, iris.Map{
+		"status": iris.StatusUnprocessableEntity,
+		"title":  "Unprocessable Entity",
+		"detail": "validation failed",
+		"fields": []string{"name"},
+	})
+	expectProblem("/plain", iris.StatusNotFound,
+		iris.Map{"status": iris.StatusNotFound, "title": "Not Found", "detail": "not found"})
+	expectProblem("/dependency", iris.StatusNotFound,
+		iris.Map{"status": iris.StatusNotFound, "title": "Not Found", "detail": "wrapped: not found"})
+	e.GET("/unmapped").Expect().Status(DefaultErrStatusCode).Body().Equal("unmapped")
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+type testNegotiated struct {
+	Name string `json:"name" xml:"name" yaml:"name"`
+}
+
+func TestNegotiatedResult(t *testing.T) {
+	app := iris.New()
+	get := func() testNegotiated { return testNegotiated{Name: "kataras"} }
+	app.Get("/", Handler(get))
+	app.Get("/custom", WithNegotiation(Negotiation{
+		MIMEs: []string{ContentMsgPackHeaderValue, context.ContentJSONHeaderValue},
+		Renderers: map[string]Renderer{
+			ContentMsgPackHeaderValue: func(ctx iris.Context, v interface{}) error {
+				_, err := ctx.Writef("msgpack:%s", v.(testNegotiated).Name)
+				return err
+			},
+		},
+	}), Handler(get))
+	app.Get("/response", Handler(func() Result {
+		return Response{Code: iris.StatusCreated, Object: get()}
+	}))
+	app.Get("/response/xml", Handler(func() Result {
+		return Response{ContentType: context.ContentXMLHeaderValue, Object: get()}
+	}))
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(iris.StatusOK).
+		JSON().Equal(iris.Map{"name": "kataras"})
+	e.GET("/").WithHeader("Accept", "text/html, text/xml;q=0.9").Expect().Status(iris.StatusOK).
+		ContentType(context.ContentXMLHeaderValue, "utf-8").
+		Body().Contains("<name>kataras</name>")
+	e.GET("/").WithHeader("Accept", context.ContentYAMLHeaderValue).Expect().Status(iris.StatusOK).
+		ContentType(context.ContentYAMLHeaderValue, "utf-8").
+		Body().Equal("name: kataras\n")
+	// not acceptable, fallback to the preferred one.
+	e.GET("/").WithHeader("Accept", "image/png").Expect().Status(iris.StatusOK).
+		JSON().Equal(iris.Map{"name": "kataras"})
+
+	e.GET("/response").WithHeader("Accept", context.ContentYAMLHeaderValue).Expect().Status(iris.StatusCreated).
+		ContentType(context.ContentYAMLHeaderValue, "utf-8").
+		Body().Equal("name: kataras\n")
+	e.GET("/response").Expect().Status(iris.StatusCreated).
+		JSON().Equal(iris.Map{"name": "kataras"})
+	// an explicit content type is not negotiated.
+	e.GET("/response/xml").WithHeader("Accept", context.ContentYAMLHeaderValue).Expect().Status(iris.StatusOK).
+		ContentType(context.ContentXMLHeaderValue, "utf-8").
+		Body().Contains("<name>kataras</name>")
+
+	e.GET("/custom").Expect().Status(iris.StatusOK).
+		ContentType(ContentMsgPackHeaderValue, "utf-8").
+		Body().Equal("msgpack:kataras")
+	e.GET("/custom").WithHeader("Accept", "application/json").Expect().Status(iris.StatusOK).
+		JSON().Equal(iris.Map{"name": "kataras"})
+}
+
+func TestStreamResults(t *testing.T) {
+	app := iris.New()
+	app.Get("/chan", Handler(func() <-chan testNegotiated {
+		ch := make(chan testNegotiated)
+		go func() {
+			defer close(ch)
+			ch <- testNegotiated{Name: "a"}
+			ch <- testNegotiated{Name: "b"}
+		}()
+		return ch
+	}))
+	app.Get("/iterator", Handler(func() Iterator {
+		return func(yield func(interface{}) bool) error {
+			for _, name := range []string{"a", "b"} {
+				if !yield(name) {
+					break
+				}
+			}
+			return nil
+		}
+	}))
+	app.Get("/text", Handler(func() Stream {
+		return Stream{Format: StreamText, Values: Iterator(func(yield func(interface{}) bool) error {
+			yield("a")
+			yield(1)
+			return nil
+		})}
+	}))
+	app.Get("/reader", Handler(func() (io.Reader, string) {
+		return strings.NewReader("name\na\nb\n"), "text/csv"
+	}))
+	app.Get("/failure", Handler(func() Iterator {
+		return func(yield func(interface{}) bool) error {
+			return errors.New("export failed")
+		}
+	}))
+	app.Get("/unsupported", Handler(func() Iterator {
+		return func(yield func(interface{}) bool) error {
+			yield(func() {})
+			return nil
+		}
+	}))
+
+	e := httptest.New(t, app)
+	e.GET("/chan").Expect().Status(iris.StatusOK).
+		ContentType(ContentNDJSONHeaderValue, "utf-8").
+		Body().Equal("{\"name\":\"a\"}\n{\"name\":\"b\"}\n")
+	e.GET("/chan").WithHeader("Accept", "application/json").Expect().Status(iris.StatusOK).
+		JSON().Equal([]iris.Map{{"name": "a"}, {"name": "b"}})
+	e.GET("/iterator").WithHeader("Accept", ContentEventStreamHeaderValue).Expect().Status(iris.StatusOK).
+		ContentType(ContentEventStreamHeaderValue, "utf-8").
+		Body().Equal("data: a\n\ndata: b\n\n")
+	e.GET("/text").Expect().Status(iris.StatusOK).
+		ContentType(context.ContentTextHeaderValue, "utf-8").
+		Body().Equal("a1")
+	e.GET("/reader").Expect().Status(iris.StatusOK).
+		ContentType("text/csv", "utf-8").
+		Body().Equal("name\na\nb\n")
+	e.GET("/failure").Expect().Status(DefaultErrStatusCode).
+		Body().Equal("export failed")
+	// the first value can not be encoded, the array is not started.
+	e.GET("/unsupported").WithHeader("Accept", "application/json").Expect().Status(DefaultErrStatusCode).
+		Body().Equal("json: unsupported type: func()")
+}
+
+func TestStreamCancel(t *testing.T) {
+	app := iris.New()
+	stopped := make(chan int, 1)
+	app.Get("/", Handler(func() Iterator {
+		return func(yield func(interface{}) bool) error {
+			n := 0
+			for yield(n) {
+				n++
+			}
+			stopped <- n
+			return nil
+		}
+	}))
+
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+	srv := nethttptest.NewServer(app)
+	defer srv.Close()
+
+	reqCtx, cancel := stdContext.WithCancel(stdContext.Background())
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req.WithContext(reqCtx))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if line, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil || line != "0\n" {
+		t.Fatalf("expected the first value of the stream but got: %q: %v", line, err)
+	}
+	cancel()
+	resp.Body.Close()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the stream to stop when the client is disconnected")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"runtime"
 
 	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/core/errors"
 	"github.com/radiantrfid/iris/hero/di"
 
 	"github.com/kataras/golog"
@@ -63,6 +64,11 @@ func makeHandler(handler interface{}, values ...reflect.Value) (context.Handler,
 		return h, nil
 	}
 
+	// report the missing dependencies and the dependency cycles of the handler's constructors at startup.
+	if err := di.Validate(values, funcInputs(fn.Type()), di.DefaultHijacker, di.DefaultTypeChecker); err != nil {
+		return nil, err
+	}
+
 	funcInjector := di.Func(fn, values...)
 	valid := funcInjector.Length == n
 
@@ -74,23 +80,53 @@ func makeHandler(handler interface{}, values ...reflect.Value) (context.Handler,
 		// We don't have access to the path, so neither to the macros here,
 		// but in mvc. So we have to do it here.
 		if valid = funcInjector.Retry(new(params).resolve); !valid {
-			pc := fn.Pointer()
-			fpc := runtime.FuncForPC(pc)
-			callerFileName, callerLineNumber := fpc.FileLine(pc)
-			callerName := fpc.Name()
-
-			err := fmt.Errorf("input arguments length(%d) and valid binders length(%d) are not equal for typeof '%s' which is defined at %s:%d by %s",
-				n, funcInjector.Length, fn.Type().String(), callerFileName, callerLineNumber, callerName)
-			return nil, err
+			return nil, unresolvedInputsError(fn, funcInjector.Missing(), values)
 		}
 	}
 
+	if funcInjector.Has {
+		golog.Debugf("hero handler '%s' dependencies:\n%s", runtime.FuncForPC(fn.Pointer()).Name(), funcInjector.String())
+	}
+
 	h := func(ctx context.Context) {
-		// in := make([]reflect.Value, n, n)
-		// funcInjector.Inject(&in, reflect.ValueOf(ctx))
-		// DispatchFuncResult(ctx, fn.Call(in))
-		DispatchFuncResult(ctx, nil, funcInjector.Call(reflect.ValueOf(ctx)))
+		in := make([]reflect.Value, n, n)
+		funcInjector.Inject(&in, reflect.ValueOf(ctx))
+		if ctx.IsStopped() {
+			return // a dependency failed, i.e a constructor returned an error.
+		}
+
+		DispatchFuncResult(ctx, nil, fn.Call(in))
+	}
+
+	if expected := ExpectedParams(funcInputs(fn.Type())...); len(expected) > 0 {
+		// the router checks the named path parameters against the route's path.
+		return context.ParamsHandler(h, expected), nil
 	}
 
 	return h, nil
 }
+
+// funcInputs returns the types of the input arguments of the "fn" function type.
+func funcInputs(fn reflect.Type) []reflect.Type {
+	types := make([]reflect.Type, fn.NumIn())
+	for i := range types {
+		types[i] = fn.In(i)
+	}
+
+	return types
+}
+
+// unresolvedInputsError returns an error for each one of the "missing" input arguments of the "fn" handler,
+// with the handler's name, source and the closest types of the "values" as suggestions.
+func unresolvedInputsError(fn reflect.Value, missing []int, values []reflect.Value) error {
+	fpc := runtime.FuncForPC(fn.Pointer())
+	callerFileName, callerLineNumber := fpc.FileLine(fn.Pointer())
+
+	rp := errors.NewReporter()
+	for _, index := range missing {
+		rp.Add("hero: handler '%s' at %s:%d: %s", fpc.Name(), callerFileName, callerLineNumber,
+			di.DescribeUnresolved(index, fn.Type().In(index), values, di.DefaultTypeChecker))
+	}
+
+	return rp.Return()
+}
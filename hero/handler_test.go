@@ -4,9 +4,14 @@ package hero_test
 
 import (
 	"fmt"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/radiantrfid/iris"
+	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/hero/di"
 	"github.com/radiantrfid/iris/httptest"
 
 	. "github.com/radiantrfid/iris/hero"
@@ -126,3 +131,239 @@ func TestBindFunctionAsFunctionInputArgument(t *testing.T) {
 	e.POST("/").WithFormField("username", expectedUsername).
 		Expect().Status(iris.StatusOK).Body().Equal(expectedUsername)
 }
+
+type (
+	testTransaction struct {
+		ID     int
+		closed chan int
+	}
+
+	testConfig struct {
+		Name string
+	}
+)
+
+func (tx *testTransaction) Close() error {
+	tx.closed <- tx.ID
+	return nil
+}
+
+func TestDependencyLifetimes(t *testing.T) {
+	var (
+		transactions int32
+		configs      int32
+		transients   int32
+		closed       = make(chan int, 2)
+	)
+
+	h := New().Register(
+		RequestScoped(func(ctx iris.Context) *testTransaction {
+			return &testTransaction{ID: int(atomic.AddInt32(&transactions, 1)), closed: closed}
+		}),
+		Singleton(func(ctx iris.Context) testConfig {
+			return testConfig{Name: fmt.Sprintf("config%d", atomic.AddInt32(&configs, 1))}
+		}),
+		Transient(func(ctx iris.Context) int {
+			return int(atomic.AddInt32(&transients, 1))
+		}),
+	)
+
+	app := iris.New()
+	app.Get("/", h.Handler(func(ctx iris.Context, tx *testTransaction, n int) {
+		ctx.Next()
+	}), h.Handler(func(tx *testTransaction, cfg testConfig, n int) string {
+		return fmt.Sprintf("%d %s %d", tx.ID, cfg.Name, n)
+	}))
+
+	e := httptest.New(t, app)
+	for i, expected := range []string{"1 config1 2", "2 config1 4"} {
+		e.GET("/").Expect().Status(iris.StatusOK).Body().Equal(expected)
+
+		// the request-scoped transaction is closed at the end of its request.
+		select {
+		case id := <-closed:
+			if id != i+1 {
+				t.Fatalf("expected the transaction %d to be closed but got %d", i+1, id)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected the transaction %d to be closed", i+1)
+		}
+	}
+}
+
+func TestDependencySingletonRetry(t *testing.T) {
+	var calls int32
+
+	h := New().Register(
+		Singleton(func(ctx iris.Context) (testConfig, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return testConfig{}, fmt.Errorf("config is not ready")
+			}
+			return testConfig{Name: fmt.Sprintf("config%d", calls)}, nil
+		}),
+	)
+
+	app := iris.New()
+	app.Get("/", h.Handler(func(cfg testConfig) string {
+		return cfg.Name
+	}))
+
+	e := httptest.New(t, app)
+	// the failed resolution is not kept.
+	e.GET("/").Expect().Status(iris.StatusBadRequest).Body().Equal("config is not ready")
+	e.GET("/").Expect().Status(iris.StatusOK).Body().Equal("config2")
+	e.GET("/").Expect().Status(iris.StatusOK).Body().Equal("config2")
+}
+
+type (
+	testRepository struct {
+		cfg testConfig
+	}
+
+	testUserService struct {
+		repo *testRepository
+		path string
+	}
+)
+
+func TestDependencyConstructors(t *testing.T) {
+	// registered before their dependencies, with any input arguments.
+	newService := func(ctx iris.Context, repo *testRepository) (*testUserService, error) {
+		if ctx.URLParam("fail") != "" {
+			return nil, fmt.Errorf("service failure")
+		}
+		return &testUserService{repo: repo, path: ctx.Path()}, nil
+	}
+	newRepository := func(cfg testConfig) *testRepository {
+		return &testRepository{cfg: cfg}
+	}
+
+	h := New().Register(Transient(newService), Singleton(newRepository), testConfig{Name: "dev"})
+
+	app := iris.New()
+	app.Get("/", h.Handler(func(service *testUserService) string {
+		return service.repo.cfg.Name + " " + service.path
+	}))
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(iris.StatusOK).Body().Equal("dev /")
+	e.GET("/").WithQuery("fail", true).Expect().Status(iris.StatusBadRequest).Body().Equal("service failure")
+}
+
+func TestDependencyConstructorsErrors(t *testing.T) {
+	var (
+		newA = func(b testConfig) *testRepository { return nil }
+		newB = func(c *testUserService) testConfig { return testConfig{} }
+		newC = func(a *testRepository) *testUserService { return nil }
+	)
+
+	handler := func(a *testRepository) {}
+
+	if _, err := di.Resolve(di.ValuesOf([]interface{}{Transient(newA), Transient(newB), Transient(newC)}), di.DefaultHijacker, di.DefaultTypeChecker); err == nil ||
+		!strings.HasPrefix(err.Error(), "di: dependency cycle: ") || strings.Count(err.Error(), "->") != 3 {
+		t.Fatalf("expected a dependency cycle error but got: %v", err)
+	}
+
+	_, err := di.Resolve(di.ValuesOf([]interface{}{Transient(newA)}), di.DefaultHijacker, di.DefaultTypeChecker)
+	if expected := "di: no dependency of type 'hero_test.testConfig' for the input argument #0 of the constructor"; err == nil || !strings.HasPrefix(err.Error(), expected) {
+		t.Fatalf("expected an error of a missing dependency but got: %v", err)
+	}
+
+	if h := New().Register(Transient(newA), Transient(newB), Transient(newC)).Handler(handler); context.HandlerError(h) == nil {
+		t.Fatalf("expected an invalid handler because of the dependency cycle")
+	}
+
+	// only the constructors of the handler's input arguments are checked.
+	h := New().Register(Transient(newA), &testUserService{path: "/users"}).Handler(func(service *testUserService) string {
+		return service.path
+	})
+	if err := context.HandlerError(h); err != nil {
+		t.Fatalf("expected a valid handler, the failed constructor is not used but got: %v", err)
+	}
+
+	// a plain function with other input arguments is not a constructor.
+	if _, err := di.Resolve(di.ValuesOf([]interface{}{newA}), di.DefaultHijacker, di.DefaultTypeChecker); err != nil {
+		t.Fatalf("expected the plain function to be ignored by the resolver but got: %v", err)
+	}
+}
+
+type (
+	testUserRepository struct{}
+	testUserRepo       struct{}
+	testService        struct{}
+)
+
+func TestUnresolvedInputs(t *testing.T) {
+	app := iris.New()
+	h := New().Register(&testUserRepository{})
+	app.Get("/", h.Handler(func(ctx iris.Context, repo *testUserRepo, svc testService) {}))
+
+	err := app.Build()
+	if err == nil {
+		t.Fatalf("expected the build to fail because of the unresolved input arguments")
+	}
+
+	for _, expected := range []string{
+		"unresolved input argument #1 of type '*hero_test.testUserRepo', did you mean '*hero_test.testUserRepository'?",
+		"unresolved input argument #2 of type 'hero_test.testService', there is no registered dependency of a similar type",
+		"handler_test.go:",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected the error to contain:\n%s\nbut got:\n%v", expected, err)
+		}
+	}
+}
+
+type testMembershipParams struct {
+	OrgID  int64  `param:"org"`
+	UserID int64  `param:"user"`
+	Role   string `param:"role"`
+	Page   *int   `param:"page"`
+}
+
+func TestNamedPathParams(t *testing.T) {
+	app := iris.New()
+	h := New().Register(&testUserRepository{})
+	handler := h.Handler(func(repo *testUserRepository, p testMembershipParams) string {
+		page := "none"
+		if p.Page != nil {
+			page = fmt.Sprintf("%d", *p.Page)
+		}
+
+		return fmt.Sprintf("org: %d, user: %d, role: %s, page: %s", p.OrgID, p.UserID, p.Role, page)
+	})
+	// the same type of parameters, in a different order than the struct fields.
+	app.Get("/users/{user:int64}/orgs/{org:int64}/{role}", handler)
+	app.Get("/users/{user:int64}/orgs/{org:int64}/{role}/{page:int}", handler)
+
+	e := httptest.New(t, app)
+	e.GET("/users/2/orgs/1/admin").Expect().Status(iris.StatusOK).
+		Body().Equal("org: 1, user: 2, role: admin, page: none")
+	e.GET("/users/2/orgs/1/admin/3").Expect().Status(iris.StatusOK).
+		Body().Equal("org: 1, user: 2, role: admin, page: 3")
+}
+
+func TestNamedPathParamsMismatch(t *testing.T) {
+	app := iris.New()
+	handler := Handler(func(p testMembershipParams) string { return "" })
+	app.Get("/users/{user:int64}/orgs/{org:string}/{role}", handler)
+	app.Get("/users/{user:int64}/{role}", handler)
+
+	err := app.Build()
+	if err == nil {
+		t.Fatalf("expected the build to fail because of the mismatched path parameters")
+	}
+
+	for _, expected := range []string{
+		"field 'hero_test.testMembershipParams.OrgID' expects the path parameter 'org' of type 'int64' but the path declares it as 'string'",
+		"route GET /users/{user:int64}/{role}: field 'hero_test.testMembershipParams.OrgID' expects the path parameter 'org' which is not declared by the path",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected the error to contain:\n%s\nbut got:\n%v", expected, err)
+		}
+	}
+
+	if strings.Contains(err.Error(), "'page'") {
+		t.Fatalf("expected the optional parameter to not be reported but got:\n%v", err)
+	}
+}
@@ -64,11 +64,43 @@ func Register(values ...interface{}) *Hero {
 // will be binded to the handler's input argument, if matching.
 //
 // Example: `.Register(loggerService{prefix: "dev"}, func(ctx iris.Context) User {...})`.
+//
+// See `Singleton`, `RequestScoped` and `Transient` to register a function with an explicit lifetime,
+// a function with other input arguments, i.e `func(cfg *Config) *Service`, is a constructor
+// which is resolved from the rest of the dependencies only when registered with one of them.
 func (h *Hero) Register(values ...interface{}) *Hero {
 	h.values.Add(values...)
 	return h
 }
 
+// Singleton returns a dependency of the "v" function which is called once, on its first binding,
+// its result is shared between all requests. Pass the result to the `Register`.
+//
+// Example: `.Register(Singleton(func(ctx iris.Context) *Config {...}))`.
+func Singleton(v interface{}) *di.Dependency {
+	return di.WithLifetime(v, di.SingletonLifetime)
+}
+
+// RequestScoped returns a dependency of the "v" function which is called once per request,
+// its result is shared between the handlers, controllers and fields of the same request.
+// If the result implements the `io.Closer` then it's closed at the end of the request,
+// after the response is sent. Pass the result to the `Register`.
+//
+// Example:
+//
+//	Register(RequestScoped(func(ctx iris.Context) (*sql.Tx, error) {
+//		return db.BeginTx(ctx.Request().Context(), nil)
+//	}))
+func RequestScoped(v interface{}) *di.Dependency {
+	return di.WithLifetime(v, di.RequestLifetime)
+}
+
+// Transient returns a dependency of the "v" function which is called on each binding,
+// it's the default behavior of the functions which are registered as they are. Pass the result to the `Register`.
+func Transient(v interface{}) *di.Dependency {
+	return di.WithLifetime(v, di.TransientLifetime)
+}
+
 // Clone creates and returns a new hero with the default Dependencies.
 // It copies the default's dependencies and returns a new hero.
 func Clone() *Hero {
@@ -101,6 +133,8 @@ func (h *Hero) Handler(fn interface{}) context.Handler {
 	handler, err := makeHandler(fn, h.values.Clone()...)
 	if err != nil {
 		golog.Errorf("hero handler: %v", err)
+		// the router reports it and the application fails to build.
+		return context.InvalidHandler(err)
 	}
 	return handler
 }
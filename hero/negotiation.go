@@ -0,0 +1,136 @@
+package hero
+
+import (
+	"github.com/radiantrfid/iris/context"
+)
+
+// ContentMsgPackHeaderValue is the content type of the MessagePack format,
+// it can be served through a `Negotiation.Renderers` entry.
+const ContentMsgPackHeaderValue = "application/msgpack"
+
+// Renderer writes the "v" value, which is returned by a handler,
+// as a response of a content type, i.e MessagePack, see `Negotiation.Renderers`.
+type Renderer func(ctx context.Context, v interface{}) error
+
+// Negotiation describes the representations of the custom values, i.e structs,
+// which are returned by the hero handlers and the mvc methods without a content type.
+// The representation is selected based on the request's "Accept" header, through the `Context.Negotiate`,
+// the first one of the `MIMEs` is used when the client does not accept any of them.
+//
+// See `DefaultNegotiation`, `WithNegotiation` and `mvc/BeforeActivation#Negotiate` too.
+type Negotiation struct {
+	// MIMEs are the content types that the values are rendered to, in order of priority.
+	// The JSON, JSONP, XML and YAML are rendered by the `Context`, the rest by the `Renderers`.
+	MIMEs []string
+	// View is the template file which renders the value, as its view model,
+	// when the client accepts the "text/html" content type.
+	// It's ignored if empty, the "text/html" should be registered to the `MIMEs` too.
+	View string
+	// Renderers are the custom renderers of content types, i.e the `ContentMsgPackHeaderValue`.
+	Renderers map[string]Renderer
+}
+
+// DefaultNegotiation is the `Negotiation` of the handlers without a `WithNegotiation` one,
+// the values are rendered as JSON unless the client accepts XML or YAML only.
+var DefaultNegotiation = Negotiation{
+	MIMEs: []string{
+		context.ContentJSONHeaderValue,
+		context.ContentXMLHeaderValue,
+		context.ContentXMLUnreadableHeaderValue,
+		context.ContentYAMLHeaderValue,
+	},
+}
+
+const negotiationContextKey = "_iris_hero_negotiation"
+
+// WithNegotiation returns a middleware which sets the "n" as the negotiation of the next handlers of the chain.
+//
+// Example:
+//
+//	app.Get("/user", hero.WithNegotiation(hero.Negotiation{
+//		MIMEs: []string{"application/json", "text/xml", "text/html"},
+//		View:  "user.html",
+//	}), hero.Handler(getUser))
+func WithNegotiation(n Negotiation) context.Handler {
+	return func(ctx context.Context) {
+		SetNegotiation(ctx, n)
+		ctx.Next()
+	}
+}
+
+// SetNegotiation sets the "n" as the negotiation of the values of the current request.
+func SetNegotiation(ctx context.Context, n Negotiation) {
+	ctx.Values().Set(negotiationContextKey, n)
+}
+
+// GetNegotiation returns the negotiation of the values of the current request,
+// defaults to the `DefaultNegotiation`.
+func GetNegotiation(ctx context.Context) Negotiation {
+	if n, ok := ctx.Values().Get(negotiationContextKey).(Negotiation); ok {
+		return n
+	}
+
+	return DefaultNegotiation
+}
+
+// negotiated completes the `context.ContentNegotiator`,
+// it renders a value based on the negotiated content type.
+type negotiated struct {
+	n Negotiation
+	v interface{}
+}
+
+// DispatchNegotiated writes the "v" value based on the negotiation of the request, see `GetNegotiation`.
+func DispatchNegotiated(ctx context.Context, v interface{}) error {
+	n := GetNegotiation(ctx)
+	if len(n.MIMEs) == 0 {
+		n = DefaultNegotiation
+	}
+
+	// the handlers can set their prioritized content types too.
+	builder := ctx.Negotiation()
+	for _, mime := range n.MIMEs {
+		builder.MIME(mime, nil)
+	}
+
+	if contentType, _, _, _ := builder.Build(); contentType == "" {
+		// the client does not accept any of them, respond with the preferred one.
+		ctx.ContentType(n.MIMEs[0])
+		return n.render(ctx, n.MIMEs[0], v)
+	}
+
+	_, err := ctx.Negotiate(negotiated{n, v})
+	return err
+}
+
+func (n negotiated) Negotiate(ctx context.Context) (int, error) {
+	contentType, _, _, _ := ctx.Negotiation().Build()
+	return 0, n.n.render(ctx, contentType, n.v)
+}
+
+func (n Negotiation) render(ctx context.Context, contentType string, v interface{}) (err error) {
+	if renderer, ok := n.Renderers[contentType]; ok {
+		return renderer(ctx, v)
+	}
+
+	switch contentType {
+	case context.ContentHTMLHeaderValue:
+		if n.View == "" {
+			return context.ErrContentNotSupported
+		}
+
+		return ctx.View(n.View, v)
+	case context.ContentJSONHeaderValue:
+		_, err = ctx.JSON(v, context.JSON{Indent: " "})
+	case context.ContentJavascriptHeaderValue:
+		_, err = ctx.JSONP(v)
+	case context.ContentXMLHeaderValue, context.ContentXMLUnreadableHeaderValue:
+		_, err = ctx.XML(v, context.XML{Indent: " "})
+	case context.ContentYAMLHeaderValue:
+		_, err = ctx.YAML(v)
+	default:
+		return context.ErrContentNotSupported
+	}
+
+	return
+}
@@ -1,9 +1,12 @@
 package hero
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
 
 	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/hero/di"
 )
 
 // weak because we don't have access to the path, neither
@@ -24,3 +27,166 @@ func (p *params) resolve(index int, typ reflect.Type) (reflect.Value, bool) {
 	p.next = p.next + 1
 	return v, ok
 }
+
+// the struct tag key of the path parameters of a parameters struct, see `paramsStructOf`.
+const paramTag = "param"
+
+// paramsStruct binds the path parameters, by their names, to the tagged fields
+// of a struct input argument, i.e:
+//
+//	type userParams struct {
+//		OrgID  int64 `param:"org"`
+//		UserID int64 `param:"user"`
+//		// optional, nil if the route's path does not declare it or the request misses it.
+//		Page *int `param:"page"`
+//	}
+//
+//	app.Get("/{org:int64}/users/{user:int64}", hero.Handler(func(p userParams) string {...}))
+//
+// Unlike the positional path parameters, they don't depend on the order of the input arguments
+// and the router checks them against the route's path on the route registration, see `ExpectedParams`.
+type paramsStruct struct {
+	typ    reflect.Type // the struct or a pointer to it.
+	fields []paramField
+}
+
+type paramField struct {
+	index    int
+	name     string
+	typ      reflect.Type
+	optional bool
+}
+
+// paramsStructOf returns the parameters struct of the "typ",
+// if it's a struct, or a pointer to a struct, with one or more fields tagged as `param:"name"`.
+func paramsStructOf(typ reflect.Type) (*paramsStruct, bool) {
+	elemTyp := typ
+	if elemTyp.Kind() == reflect.Ptr {
+		elemTyp = elemTyp.Elem()
+	}
+
+	if elemTyp.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	var fields []paramField
+	for i := 0; i < elemTyp.NumField(); i++ {
+		f := elemTyp.Field(i)
+		name := f.Tag.Get(paramTag)
+		if name == "" || f.PkgPath != "" { // untagged or unexported.
+			continue
+		}
+
+		fields = append(fields, paramField{
+			index:    i,
+			name:     name,
+			typ:      f.Type,
+			optional: f.Type.Kind() == reflect.Ptr,
+		})
+	}
+
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	return &paramsStruct{typ: typ, fields: fields}, true
+}
+
+// ExpectedParams returns the path parameters which are bound by name
+// to the parameters structs of the "types", i.e the input arguments of a handler.
+// Its result is used to check them against the route's path, see `context.ParamsHandler`.
+func ExpectedParams(types ...reflect.Type) (params []context.ExpectedParam) {
+	for _, typ := range types {
+		s, ok := paramsStructOf(typ)
+		if !ok {
+			continue
+		}
+
+		elemTyp := di.IndirectType(typ)
+		for _, f := range s.fields {
+			params = append(params, context.ExpectedParam{
+				Name:     f.name,
+				Type:     f.typ,
+				Optional: f.optional,
+				Field:    elemTyp.String() + "." + elemTyp.Field(f.index).Name,
+			})
+		}
+	}
+
+	return
+}
+
+// bind returns a new value of the struct with the path parameters of the request.
+func (s *paramsStruct) bind(ctx context.Context) (reflect.Value, error) {
+	ptr := reflect.New(di.IndirectType(s.typ))
+	elem := ptr.Elem()
+
+	for _, f := range s.fields {
+		entry, ok := ctx.Params().Store.GetEntry(f.name)
+		if !ok {
+			// the optional ones are nil and the rest are checked on the route registration.
+			continue
+		}
+
+		field := elem.Field(f.index)
+		if f.optional {
+			field.Set(reflect.New(f.typ.Elem()))
+			field = field.Elem()
+		}
+
+		if err := setParamValue(field, entry.ValueRaw); err != nil {
+			return ptr, fmt.Errorf("path parameter '%s': %v", f.name, err)
+		}
+	}
+
+	if s.typ.Kind() == reflect.Ptr {
+		return ptr, nil
+	}
+
+	return elem, nil
+}
+
+// setParamValue sets the "raw" value of a path parameter, as it's resolved by its macro, to the "field",
+// i.e the int64 of the "{id:int64}".
+// It is converted to the field's type if they don't match, i.e a custom macro's value.
+func setParamValue(field reflect.Value, raw interface{}) error {
+	v := reflect.ValueOf(raw)
+	if v.IsValid() && v.Type().AssignableTo(field.Type()) {
+		field.Set(v)
+		return nil
+	}
+
+	s := fmt.Sprintf("%v", raw)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type '%s'", field.Type().String())
+	}
+
+	return nil
+}
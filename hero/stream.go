@@ -0,0 +1,306 @@
+package hero
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/radiantrfid/iris/context"
+)
+
+// StreamFormat is the format of the values of a `Stream`.
+type StreamFormat uint8
+
+const (
+	// StreamAuto selects the format based on the request's "Accept" header:
+	// the `StreamSSE` for "text/event-stream", the `StreamJSONArray` for "application/json"
+	// and the `StreamNDJSON` otherwise.
+	StreamAuto StreamFormat = iota
+	// StreamNDJSON writes each value as a JSON line, the content type is "application/x-ndjson".
+	StreamNDJSON
+	// StreamJSONArray writes the values as the elements of a JSON array, chunk by chunk.
+	// The array is not closed if the stream fails, so the client can not parse it as a complete one.
+	StreamJSONArray
+	// StreamSSE writes each value as the data of a server-sent event, the content type is "text/event-stream".
+	StreamSSE
+	// StreamText writes each value as text, chunk by chunk, the content type defaults to "text/plain".
+	StreamText
+)
+
+// ContentNDJSONHeaderValue is the content type of the `StreamNDJSON` format.
+const ContentNDJSONHeaderValue = "application/x-ndjson"
+
+// ContentEventStreamHeaderValue is the content type of the `StreamSSE` format.
+const ContentEventStreamHeaderValue = "text/event-stream"
+
+// Iterator is a function which produces the values of a `Stream`,
+// it calls the "yield" for each value and it should return as soon as the "yield" returns false,
+// which happens when the client is disconnected or the write failed.
+// A non-nil error is written to the response if no value is yielded.
+type Iterator func(yield func(v interface{}) bool) error
+
+// Stream completes the `Result` interface.
+// It writes its values to the client as they are produced, one chunk per value,
+// without keeping them in memory, i.e for large exports.
+//
+// The handlers can return a `Stream`, an `Iterator`, a receive channel or an `io.Reader` directly,
+// they are streamed in the `StreamAuto` format, the readers as they are.
+//
+// The writes block until the connection accepts them and the stream stops when the client is disconnected.
+// The producers of a channel should stop on the `Context.Request().Context().Done()` too.
+type Stream struct {
+	Format StreamFormat
+	// Values is a receive channel, an `Iterator`, a `func(yield func(interface{}) bool) error` or an `io.Reader`.
+	Values interface{}
+}
+
+var _ Result = Stream{}
+
+// streamOf returns the `Stream` of the "v" value, if it's streamable.
+func streamOf(v reflect.Value) (Stream, bool) {
+	switch values := v.Interface().(type) {
+	case Result:
+		// including the Stream itself.
+		return Stream{}, false
+	case Iterator, func(func(interface{}) bool) error, io.Reader:
+		return Stream{Values: values}, true
+	}
+
+	if v.Kind() == reflect.Chan && v.Type().ChanDir()&reflect.RecvDir != 0 {
+		return Stream{Values: v.Interface()}, true
+	}
+
+	return Stream{}, false
+}
+
+// Dispatch writes the stream to the client.
+func (s Stream) Dispatch(ctx context.Context) {
+	if r, ok := s.Values.(io.Reader); ok {
+		s.dispatchReader(ctx, r)
+		return
+	}
+
+	format := s.Format
+	if format == StreamAuto {
+		format = negotiateStreamFormat(ctx)
+	}
+
+	w := &streamWriter{ctx: ctx, format: format}
+
+	var err error
+	switch values := s.Values.(type) {
+	case Iterator:
+		err = values(w.write)
+	case func(func(interface{}) bool) error:
+		err = values(w.write)
+	default:
+		ch := reflect.ValueOf(values)
+		if ch.Kind() != reflect.Chan {
+			err = fmt.Errorf("hero: stream: unexpected values of type '%T'", values)
+			break
+		}
+
+		done := reflect.ValueOf(ctx.Request().Context().Done())
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: ch},
+			{Dir: reflect.SelectRecv, Chan: done},
+		}
+
+		for {
+			chosen, v, ok := reflect.Select(cases)
+			if chosen == 1 || !ok || !w.write(v.Interface()) {
+				break
+			}
+		}
+	}
+
+	if err == nil {
+		err = w.err
+	}
+
+	if err != nil && !w.begun {
+		// nothing is written yet.
+		DispatchErr(ctx, 0, err)
+		return
+	}
+
+	w.close(err)
+}
+
+func (s Stream) dispatchReader(ctx context.Context, r io.Reader) {
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if ctx.GetContentType() == "" {
+		ctx.ContentType(context.ContentBinaryHeaderValue)
+	}
+
+	buf := make([]byte, 32*1024)
+	done := ctx.Request().Context().Done()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, writeErr := ctx.Write(buf[:n]); writeErr != nil {
+				return
+			}
+			ctx.ResponseWriter().Flush()
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func negotiateStreamFormat(ctx context.Context) StreamFormat {
+	accept := ctx.GetHeader("Accept")
+	switch {
+	case strings.Contains(accept, ContentEventStreamHeaderValue):
+		return StreamSSE
+	case strings.Contains(accept, context.ContentJSONHeaderValue):
+		return StreamJSONArray
+	default:
+		return StreamNDJSON
+	}
+}
+
+// streamWriter writes the values of a stream, it reports false when the stream should stop.
+type streamWriter struct {
+	ctx    context.Context
+	format StreamFormat
+	n      int // the number of written values.
+	begun  bool
+	failed bool
+	err    error // the encoding error of a value, if any.
+}
+
+func (w *streamWriter) begin() {
+	if w.begun {
+		return
+	}
+	w.begun = true
+
+	contentType := ""
+	switch w.format {
+	case StreamNDJSON:
+		contentType = ContentNDJSONHeaderValue
+	case StreamJSONArray:
+		contentType = context.ContentJSONHeaderValue
+	case StreamSSE:
+		contentType = ContentEventStreamHeaderValue
+		w.ctx.Header("Cache-Control", "no-cache")
+	case StreamText:
+		if contentType = w.ctx.GetContentType(); contentType == "" {
+			contentType = context.ContentTextHeaderValue
+		}
+	}
+
+	w.ctx.ContentType(contentType)
+	if w.format == StreamJSONArray {
+		w.ctx.WriteString("[")
+	}
+}
+
+func (w *streamWriter) write(v interface{}) bool {
+	if w.failed {
+		return false
+	}
+
+	select {
+	case <-w.ctx.Request().Context().Done():
+		w.failed = true
+		return false
+	default:
+	}
+
+	// encode first, the response is not started if the first value fails.
+	chunk, err := w.encode(v)
+	if err != nil {
+		w.failed = true
+		w.err = err
+		return false
+	}
+
+	w.begin()
+
+	if _, err = w.ctx.Write(chunk); err != nil {
+		w.failed = true
+		return false
+	}
+
+	w.n++
+	w.ctx.ResponseWriter().Flush()
+	return true
+}
+
+func (w *streamWriter) encode(v interface{}) ([]byte, error) {
+	switch w.format {
+	case StreamText:
+		return textOf(v), nil
+	case StreamSSE:
+		var data []byte
+		switch value := v.(type) {
+		case []byte:
+			data = value
+		case string:
+			data = []byte(value)
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			data = b
+		}
+
+		lines := strings.Split(string(data), "\n")
+		return []byte("data: " + strings.Join(lines, "\ndata: ") + "\n\n"), nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.format == StreamJSONArray {
+		if w.n > 0 {
+			b = append([]byte{','}, b...)
+		}
+
+		return b, nil
+	}
+
+	return append(b, '\n'), nil
+}
+
+func (w *streamWriter) close(err error) {
+	w.begin()
+
+	if err != nil || w.failed {
+		// the client should not take the stream as completed.
+		return
+	}
+
+	if w.format == StreamJSONArray {
+		w.ctx.WriteString("]")
+	}
+}
+
+func textOf(v interface{}) []byte {
+	switch value := v.(type) {
+	case []byte:
+		return value
+	case string:
+		return []byte(value)
+	default:
+		return []byte(fmt.Sprint(value))
+	}
+}
@@ -18,6 +18,7 @@ import (
 	// context for the handlers
 	"github.com/radiantrfid/iris/context"
 	// core packages, needed to build the application
+	"github.com/radiantrfid/iris/core/acme"
 	"github.com/radiantrfid/iris/core/errors"
 	"github.com/radiantrfid/iris/core/host"
 	"github.com/radiantrfid/iris/core/netutil"
@@ -144,6 +145,8 @@ type Application struct {
 
 	// view engine
 	view view.View
+	// the errors to responses registry.
+	errorMapper *context.ErrorMapper
 	// used for build
 	once sync.Once
 
@@ -165,10 +168,11 @@ func New() *Application {
 	config := DefaultConfiguration()
 
 	app := &Application{
-		config:     &config,
-		logger:     golog.Default,
-		APIBuilder: router.NewAPIBuilder(),
-		Router:     router.NewRouter(),
+		config:      &config,
+		logger:      golog.Default,
+		errorMapper: context.NewErrorMapper(),
+		APIBuilder:  router.NewAPIBuilder(),
+		Router:      router.NewRouter(),
 	}
 
 	app.ContextPool = context.New(func() context.Context {
@@ -286,6 +290,22 @@ func (app *Application) Logger() *golog.Logger {
 	return app.logger
 }
 
+// ErrorMapper returns the application's errors to responses registry.
+// The errors, or the wrappers of errors, which are mapped to it are written as a
+// Problem (RFC 7807) of the mapped status code by the `Context.StopWithError`
+// and by the hero and mvc handlers that return them.
+//
+// Example:
+//
+//	app.ErrorMapper().
+//		Map(ErrNotFound, iris.StatusNotFound).
+//		Map((*ValidationError)(nil), iris.StatusUnprocessableEntity)
+//
+// Look `context.ErrorMapper#Map` for more.
+func (app *Application) ErrorMapper() *context.ErrorMapper {
+	return app.errorMapper
+}
+
 var (
 	// HTML view engine.
 	// Shortcut of the kataras/iris/view.HTML.
@@ -567,6 +587,10 @@ func (app *Application) NewHost(srv *http.Server) *host.Supervisor {
 	}
 	app.logger.Debugf("Host: addr is %s", srv.Addr)
 
+	// make the PROXY protocol information, if any, available to the request handlers,
+	// see `netutil.ProxyProtocol` and `netutil.GetProxyInfo`.
+	registerProxyConnContext(srv)
+
 	// create the new host supervisor
 	// bind the constructed server and return it
 	su := host.New(srv)
@@ -740,6 +764,78 @@ func TLS(addr string, certFile, keyFile string, hostConfigs ...host.Configurator
 	}
 }
 
+// ManagedTLS can be used as an argument for the `Run` method.
+// It will start the Application's secure server which serves the certificates
+// of the "certManager", selected by the client's requested server name (SNI).
+// The certificate files are watched and reloaded, without a restart, when they change on disk,
+// client certificates (mutual TLS) are verified if the manager has client CAs,
+// see `Context.ClientCertificate`.
+//
+// Addr should have the form of [host]:port, i.e localhost:443 or :443.
+//
+// Second argument is optional, it accepts one or more
+// `func(*host.Configurator)` that are being executed
+// on that specific host that this function will create to start the server.
+// Look at the `ConfigureHost` too.
+//
+// Usage:
+// m := netutil.NewCertManager()
+// m.AddPair("mydomain.com.crt", "mydomain.com.key")
+// m.AddPair("otherdomain.com.crt", "otherdomain.com.key")
+// app.Run(iris.ManagedTLS(":443", m))
+//
+// See `Run` and `core/netutil#CertManager` for more.
+func ManagedTLS(addr string, certManager *netutil.CertManager, hostConfigs ...host.Configurator) Runner {
+	return func(app *Application) error {
+		// set before the Watch, its goroutine reads it.
+		if certManager.OnReload == nil {
+			certManager.OnReload = func(err error) {
+				if err != nil {
+					app.logger.Errorf("Host: certificates reload: %v", err)
+					return
+				}
+				app.logger.Infof("Host: certificates reloaded")
+			}
+		}
+
+		su := app.NewHost(&http.Server{Addr: addr, TLSConfig: certManager.TLSConfig()})
+		su.RegisterOnShutdown(certManager.Watch(time.Minute))
+
+		return su.Configure(hostConfigs...).ListenAndServeTLS("", "")
+	}
+}
+
+// ACME can be used as an argument for the `Run` method.
+// It will start the Application's secure server using certificates
+// obtained, cached and renewed by the "certManager" from any ACME directory,
+// i.e Let's Encrypt or a local test CA.
+//
+// Addr should have the form of [host]:port, i.e mydomain.com:443.
+//
+// Note: `ACME` will start a new server on port 80 for the "http-01" challenges
+// which will redirect all other http requests to their https version.
+//
+// Certificate errors are logged unless the "certManager" has registered error listeners.
+//
+// Example:
+// m := acme.New(acme.Config{DirectoryURL: "https://localhost:14000/dir", Domains: []string{"example.com"}})
+// app.Run(iris.ACME(":443", m))
+//
+// Look at the `ConfigureHost` too.
+func ACME(addr string, certManager *acme.Manager, hostConfigs ...host.Configurator) Runner {
+	return func(app *Application) error {
+		if !certManager.HasErrorListeners() {
+			certManager.RegisterOnError(func(domain string, err error) {
+				app.logger.Errorf("Host: certificate of %s: %v", domain, err)
+			})
+		}
+
+		return app.NewHost(&http.Server{Addr: addr}).
+			Configure(hostConfigs...).
+			ListenAndServeACME(certManager)
+	}
+}
+
 // AutoTLS can be used as an argument for the `Run` method.
 // It will start the Application's secure server using
 // certifications created on the fly by the "autocert" golang/x package,
@@ -808,6 +904,7 @@ func (app *Application) Build() error {
 	rp := errors.NewReporter()
 
 	app.once.Do(func() {
+		rp.Describe("configuration: %v", app.config.parseTrustedProxies())
 		rp.Describe("api builder: %v", app.APIBuilder.GetReport())
 
 		if !app.Router.Downgraded() {
@@ -26,6 +26,14 @@ func Parse(fullpath string, paramTypes []ast.ParamType) ([]*ast.ParamStatement,
 			continue
 		}
 
+		// a named path parameter can be followed by a custom verb, i.e {id:uint64}:archive.
+		verb := ""
+		if s[0] == lexer.Begin {
+			if verbIdx := strings.LastIndex(s, string(lexer.End)+":"); verbIdx > 0 && isVerb(s[verbIdx+2:]) {
+				s, verb = s[:verbIdx+1], s[verbIdx+2:]
+			}
+		}
+
 		// if it's not a named path parameter of the new syntax then continue to the next
 		if s[0] != lexer.Begin || s[len(s)-1] != lexer.End {
 			continue
@@ -42,12 +50,32 @@ func Parse(fullpath string, paramTypes []ast.ParamType) ([]*ast.ParamStatement,
 			return nil, fmt.Errorf("%s: parameter type \"%s\" should be registered to the very last of a path", s, stmt.Type.Indent())
 		}
 
+		if ast.IsTrailing(stmt.Type) && verb != "" {
+			return nil, fmt.Errorf("%s: parameter type \"%s\" can not be followed by the custom verb \"%s\"", s, stmt.Type.Indent(), verb)
+		}
+
 		statements = append(statements, stmt)
 	}
 
 	return statements, nil
 }
 
+// isVerb reports whether the "s" is a valid custom verb of a path parameter,
+// i.e the "archive" of the {id:uint64}:archive.
+func isVerb(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+
+	return true
+}
+
 // ParamParser is the parser
 // which is being used by the Parse function
 // to parse path segments one by one
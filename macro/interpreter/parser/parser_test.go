@@ -378,6 +378,28 @@ func TestParse(t *testing.T) {
 				},
 			},
 		}, // 7
+		{
+			"/items/{id:uint64}:archive", true, // custom verb
+			[]ast.ParamStatement{
+				{
+					Src:       "{id:uint64}",
+					Name:      "id",
+					Type:      paramTypeUint64,
+					ErrorCode: 404,
+				},
+			},
+		}, // 8
+		{
+			"/files/{file:path}:archive", false, // path can not be followed by a custom verb
+			[]ast.ParamStatement{
+				{
+					Src:       "{file:path}",
+					Name:      "file",
+					Type:      paramTypePath,
+					ErrorCode: 404,
+				},
+			},
+		}, // 9
 	}
 	for i, tt := range tests {
 		statements, err := Parse(tt.path, testParamTypes)
@@ -0,0 +1,206 @@
+// Package coalesce provides a middleware which collapses the concurrent identical requests,
+// only one of them executes the handlers and the rest receive its recorded response.
+// It's used by the cache middleware too.
+package coalesce
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/radiantrfid/iris/context"
+)
+
+// New returns a new coalesce middleware,
+// the first of the concurrent requests of the same key executes the next handlers
+// and the rest wait and receive its recorded response.
+//
+// Receives an optional configuration.
+func New(cfg ...Config) context.Handler {
+	c := DefaultConfig()
+	if len(cfg) > 0 {
+		c = cfg[0]
+		if c.Key == nil {
+			c.Key = DefaultKey
+		}
+	}
+
+	g := &Group{Timeout: c.Timeout}
+	return func(ctx context.Context) {
+		key := c.Key(ctx)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		g.Do(ctx, key, next)
+	}
+}
+
+func next(ctx context.Context) {
+	ctx.Next()
+}
+
+// Response is the recorded response of a request which is shared to the waiting ones.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// RequestHeader is the header of the request which is executed.
+	RequestHeader http.Header
+}
+
+// CanShare reports whether the recorded response "r" can be written to the waiting request of the "ctx",
+// the responses which set cookies or are marked as "private" are not shared
+// and the request's values of the response's "Vary" header should match.
+// It's the default `Group.Share`.
+func CanShare(ctx context.Context, r *Response) bool {
+	if len(r.Header["Set-Cookie"]) > 0 {
+		return false
+	}
+
+	for _, value := range r.Header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			if directive == "private" || strings.HasPrefix(directive, "private=") {
+				return false
+			}
+		}
+	}
+
+	header := ctx.Request().Header
+	for _, value := range r.Header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return false
+			}
+
+			if strings.Join(header[name], ",") != strings.Join(r.RequestHeader[name], ",") {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+type call struct {
+	done     chan struct{}
+	response *Response // nil if the handler panicked.
+}
+
+// Group collapses the concurrent requests of the same key,
+// the zero value is ready to use.
+type Group struct {
+	// Timeout is the maximum time that a request waits for the response of an identical one,
+	// zero or negative means that it waits until the response is recorded.
+	Timeout time.Duration
+	// Share reports whether a recorded response can be written to a waiting request,
+	// if false the waiting request executes the handler on its own.
+	//
+	// Defaults to `CanShare`.
+	Share func(ctx context.Context, r *Response) bool
+
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do executes the "handler" for the first request of the "key" and records its response,
+// the requests of the same key which come while it's executed wait for it and write the recorded response instead.
+// If waiting exceeds the `Timeout` or the response can't be shared, the "handler" is executed for them too.
+//
+// It reports whether the response of the "ctx" is the shared one of another request.
+func (g *Group) Do(ctx context.Context, key string, handler context.Handler) (shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		if g.wait(ctx, c) {
+			return true
+		}
+
+		handler(ctx)
+		return false
+	}
+
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	recorder := ctx.Recorder()
+	handler(ctx)
+
+	c.response = &Response{
+		StatusCode:    recorder.StatusCode(),
+		Header:        cloneHeader(recorder.Header()),
+		Body:          append([]byte(nil), recorder.Body()...),
+		RequestHeader: cloneHeader(ctx.Request().Header),
+	}
+
+	return false
+}
+
+// wait waits for the response of the "c" and writes it to the "ctx",
+// it reports false if the response is not written.
+func (g *Group) wait(ctx context.Context, c *call) bool {
+	var timeout <-chan time.Time
+	if g.Timeout > 0 {
+		timer := time.NewTimer(g.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-c.done:
+	case <-timeout:
+		return false
+	case <-ctx.Request().Context().Done():
+		// the client is gone.
+		ctx.StopExecution()
+		return true
+	}
+
+	r := c.response
+	if r == nil {
+		return false
+	}
+
+	share := g.Share
+	if share == nil {
+		share = CanShare
+	}
+
+	if !share(ctx, r) {
+		return false
+	}
+
+	header := ctx.ResponseWriter().Header()
+	for k, v := range r.Header {
+		header[k] = append([]string(nil), v...)
+	}
+
+	ctx.StatusCode(r.StatusCode)
+	ctx.Write(r.Body)
+	return true
+}
+
+func cloneHeader(h http.Header) http.Header {
+	header := make(http.Header, len(h))
+	for k, v := range h {
+		header[k] = append([]string(nil), v...)
+	}
+
+	return header
+}
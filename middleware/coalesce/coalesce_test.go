@@ -0,0 +1,130 @@
+package coalesce_test
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/radiantrfid/iris"
+	"github.com/radiantrfid/iris/httptest"
+	"github.com/radiantrfid/iris/middleware/coalesce"
+
+	"github.com/gavv/httpexpect"
+)
+
+// concurrently sends "n" requests which are built by the "req",
+// the rest of them are sent while the first one is executed, until "release".
+func concurrently(n int, started <-chan struct{}, release chan<- struct{}, req func() *httpexpect.Request) []string {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		bodies []string
+	)
+
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			body := req().Expect().Status(http.StatusOK).Body().Raw()
+			mu.Lock()
+			bodies = append(bodies, body)
+			mu.Unlock()
+		}()
+
+		if i == 0 {
+			<-started
+		}
+	}
+
+	// let the rest of them to wait for the first one.
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	return bodies
+}
+
+func TestCoalesce(t *testing.T) {
+	app := iris.New()
+
+	var (
+		n       uint32
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	app.Get("/", coalesce.New(), func(ctx iris.Context) {
+		if atomic.AddUint32(&n, 1) == 1 {
+			close(started)
+			<-release
+		}
+
+		ctx.Header("X-Custom", "value")
+		ctx.Writef("response %d", atomic.LoadUint32(&n))
+	})
+
+	e := httptest.New(t, app)
+	bodies := concurrently(5, started, release, func() *httpexpect.Request {
+		return e.GET("/")
+	})
+
+	if counter := atomic.LoadUint32(&n); counter != 1 {
+		t.Fatalf("expected the handler to be executed once but executed %d times", counter)
+	}
+
+	for _, body := range bodies {
+		if body != "response 1" {
+			t.Fatalf("expected all requests to receive the first response but got: %v", bodies)
+		}
+	}
+
+	// not coalesced anymore.
+	e.GET("/").Expect().Status(http.StatusOK).Header("X-Custom").Equal("value")
+	if counter := atomic.LoadUint32(&n); counter != 2 {
+		t.Fatalf("expected the handler to be executed twice but executed %d times", counter)
+	}
+}
+
+func TestCoalesceSkipAndTimeout(t *testing.T) {
+	app := iris.New()
+
+	var (
+		n       uint32
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+
+	h := func(ctx iris.Context) {
+		if atomic.AddUint32(&n, 1) == 1 {
+			close(started)
+			<-release
+		}
+
+		ctx.WriteString("response")
+	}
+
+	app.Get("/timeout", coalesce.New(coalesce.Config{Timeout: 10 * time.Millisecond}), h)
+	app.Get("/", coalesce.New(), h)
+
+	e := httptest.New(t, app)
+	// the rest of them do not wait for the first one.
+	concurrently(3, started, release, func() *httpexpect.Request {
+		return e.GET("/timeout")
+	})
+
+	if counter := atomic.LoadUint32(&n); counter != 3 {
+		t.Fatalf("expected the handler to be executed 3 times but executed %d times", counter)
+	}
+
+	atomic.StoreUint32(&n, 0)
+	started, release = make(chan struct{}), make(chan struct{})
+	// requests with cookies are not coalesced by the default key.
+	concurrently(3, started, release, func() *httpexpect.Request {
+		return e.GET("/").WithCookie("session", "id")
+	})
+
+	if counter := atomic.LoadUint32(&n); counter != 3 {
+		t.Fatalf("expected the handler to be executed 3 times but executed %d times", counter)
+	}
+}
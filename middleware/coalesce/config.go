@@ -0,0 +1,59 @@
+package coalesce
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/radiantrfid/iris/context"
+)
+
+// DefaultTimeout is the default maximum time that a request waits
+// for the response of an identical one which is being executed.
+var DefaultTimeout = 10 * time.Second
+
+// KeyFunc returns the key of the identical requests of the "ctx",
+// an empty key means that the request should not be coalesced.
+type KeyFunc func(ctx context.Context) string
+
+// DefaultKey is the default `KeyFunc`, it coalesces the GET and HEAD requests
+// by their method and absolute url, the requests with an Authorization or a Cookie header are not coalesced.
+func DefaultKey(ctx context.Context) string {
+	r := ctx.Request()
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return ""
+	}
+
+	if r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "" {
+		return ""
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return r.Method + " " + scheme + "://" + ctx.Host() + r.URL.RequestURI()
+}
+
+// Config contains the options for the coalesce middleware
+// can be optionally be passed to the `New`.
+type Config struct {
+	// Key returns the key of the identical requests.
+	//
+	// Defaults to the `DefaultKey`.
+	Key KeyFunc
+	// Timeout is the maximum time that a request waits for the response of an identical one,
+	// after that it executes the handlers on its own.
+	//
+	// Defaults to the `DefaultTimeout`.
+	Timeout time.Duration
+}
+
+// DefaultConfig returns a default config
+// that can be used to configure the coalesce middleware.
+func DefaultConfig() Config {
+	return Config{
+		Key:     DefaultKey,
+		Timeout: DefaultTimeout,
+	}
+}
@@ -0,0 +1,71 @@
+// Package etag provides a middleware which generates ETags for the dynamic responses
+// and answers the conditional GET and HEAD requests with 304 (Not Modified) or 412 (Precondition Failed).
+//
+// For the write requests, i.e PUT, use the `Context.HandlePreconditions` with the current version of the resource
+// in order to protect them from lost updates.
+package etag
+
+import (
+	"net/http"
+
+	"github.com/radiantrfid/iris/context"
+)
+
+// Config contains the options for the etag middleware
+// can be optionally be passed to the `New`.
+type Config struct {
+	// Weak generates weak ETags, it should be true
+	// when the responses are modified by a next middleware, i.e compressed.
+	//
+	// Defaults to false.
+	Weak bool
+}
+
+// New returns a new etag middleware which records the successful responses of the GET and HEAD requests,
+// sets their "ETag" header, computed from their body,
+// unless the handler has already set one, i.e `ctx.SetETag(context.FormatETag(version, false))`,
+// and evaluates the conditional request headers, see `Context.CheckPreconditions`.
+//
+// Receives an optional configuration.
+func New(cfg ...Config) context.Handler {
+	var c Config
+	if len(cfg) > 0 {
+		c = cfg[0]
+	}
+
+	return func(ctx context.Context) {
+		if method := ctx.Method(); method != http.MethodGet && method != http.MethodHead {
+			ctx.Next()
+			return
+		}
+
+		recorder := ctx.Recorder()
+		ctx.Next()
+
+		if status := recorder.StatusCode(); status < 200 || status >= 300 {
+			return
+		}
+
+		header := recorder.Header()
+		etag := header.Get(context.ETagHeaderKey)
+		if etag == "" {
+			body := recorder.Body()
+			if len(body) == 0 {
+				return
+			}
+
+			etag = context.ComputeETag(body, c.Weak)
+			ctx.SetETag(etag)
+		}
+
+		lastModified, _ := context.ParseTime(ctx, header.Get(context.LastModifiedHeaderKey))
+		switch ctx.CheckPreconditions(etag, lastModified) {
+		case http.StatusNotModified:
+			recorder.ResetBody()
+			ctx.WriteNotModified()
+		case http.StatusPreconditionFailed:
+			recorder.ResetBody()
+			ctx.StatusCode(http.StatusPreconditionFailed)
+		}
+	}
+}
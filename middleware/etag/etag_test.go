@@ -0,0 +1,72 @@
+package etag_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/radiantrfid/iris"
+	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/httptest"
+	"github.com/radiantrfid/iris/middleware/etag"
+)
+
+func TestETag(t *testing.T) {
+	app := iris.New()
+	app.Use(etag.New())
+
+	body := "dynamic response"
+	app.Get("/", func(ctx iris.Context) {
+		ctx.WriteString(body)
+	})
+
+	app.Get("/version", func(ctx iris.Context) {
+		ctx.SetETag(context.FormatETag("v1", true))
+		ctx.WriteString(body)
+	})
+
+	e := httptest.New(t, app)
+
+	tag := e.GET("/").Expect().Status(http.StatusOK).Header("ETag").Equal(context.ComputeETag([]byte(body), false)).Raw()
+	e.GET("/").WithHeader("If-None-Match", tag).Expect().Status(http.StatusNotModified).Body().Empty()
+	e.GET("/").WithHeader("If-None-Match", `"other", `+tag).Expect().Status(http.StatusNotModified)
+	e.GET("/").WithHeader("If-None-Match", `"other"`).Expect().Status(http.StatusOK).Body().Equal(body)
+	e.GET("/").WithHeader("If-Match", `"other"`).Expect().Status(http.StatusPreconditionFailed)
+
+	e.GET("/version").Expect().Status(http.StatusOK).Header("ETag").Equal(`W/"v1"`)
+	e.GET("/version").WithHeader("If-None-Match", `"v1"`).Expect().Status(http.StatusNotModified)
+	// the strong comparison of the If-Match never matches a weak ETag.
+	e.GET("/version").WithHeader("If-Match", `W/"v1"`).Expect().Status(http.StatusPreconditionFailed)
+}
+
+func TestHandlePreconditions(t *testing.T) {
+	app := iris.New()
+
+	var (
+		version = 1
+		updated = time.Now()
+	)
+
+	app.Put("/resource", func(ctx iris.Context) {
+		if ctx.HandlePreconditions(context.FormatETag(strconv.Itoa(version), false), updated) {
+			return
+		}
+
+		version++
+		ctx.SetETag(context.FormatETag(strconv.Itoa(version), false))
+		ctx.StatusCode(http.StatusNoContent)
+	})
+
+	e := httptest.New(t, app)
+	e.PUT("/resource").WithHeader("If-Match", `"1"`).Expect().Status(http.StatusNoContent).Header("ETag").Equal(`"2"`)
+	// lost update.
+	e.PUT("/resource").WithHeader("If-Match", `"1"`).Expect().Status(http.StatusPreconditionFailed)
+	e.PUT("/resource").WithHeader("If-None-Match", "*").Expect().Status(http.StatusPreconditionFailed)
+	e.PUT("/resource").WithHeader("If-Match", "*").Expect().Status(http.StatusNoContent)
+
+	before := updated.Add(-time.Hour).UTC().Format(http.TimeFormat)
+	e.PUT("/resource").WithHeader("If-Unmodified-Since", before).Expect().Status(http.StatusPreconditionFailed)
+	after := updated.Add(time.Hour).UTC().Format(http.TimeFormat)
+	e.PUT("/resource").WithHeader("If-Unmodified-Since", after).Expect().Status(http.StatusNoContent)
+}
@@ -30,6 +30,14 @@ type Config struct {
 	// Defaults to true.
 	Path bool
 
+	// TLS displays the TLS version and the client certificate's common name, if any,
+	// the proxy terminated the connection with,
+	// see `netutil.ProxyProtocol` and `netutil.GetProxyInfo`.
+	// If the server terminates TLS itself then the request's TLS state is used instead.
+	//
+	// Defaults to false.
+	TLS bool
+
 	// Query will append the URL Query to the Path.
 	// Path should be true too.
 	//
@@ -90,6 +98,7 @@ func DefaultConfig() Config {
 		IP:         true,
 		Method:     true,
 		Path:       true,
+		TLS:        false,
 		Query:      false,
 		Columns:    false,
 		LogFunc:    nil,
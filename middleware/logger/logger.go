@@ -2,11 +2,13 @@
 package logger
 
 import (
+	"crypto/tls"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/core/netutil"
 
 	"github.com/ryanuber/columnize"
 )
@@ -61,6 +63,12 @@ func (l *requestLoggerMiddleware) ServeHTTP(ctx context.Context) {
 		ip = ctx.RemoteAddr()
 	}
 
+	if l.config.TLS {
+		if tlsInfo := getTLSInfo(ctx); tlsInfo != "" {
+			ip += " (" + tlsInfo + ")"
+		}
+	}
+
 	if l.config.Method {
 		method = ctx.Method()
 	}
@@ -123,6 +131,33 @@ func (l *requestLoggerMiddleware) ServeHTTP(ctx context.Context) {
 	ctx.Application().Logger().Info(line)
 }
 
+var tlsVersions = map[uint16]string{
+	tls.VersionTLS10: "TLSv1",
+	tls.VersionTLS11: "TLSv1.1",
+	tls.VersionTLS12: "TLSv1.2",
+	tls.VersionTLS13: "TLSv1.3",
+}
+
+// getTLSInfo returns the TLS version and the client's certificate common name
+// of the PROXY protocol header, if any, otherwise of the request's TLS state.
+func getTLSInfo(ctx context.Context) string {
+	var version, commonName string
+	if info, ok := netutil.GetProxyInfo(ctx.Request()); ok && info.TLS != nil {
+		version, commonName = info.TLS.Version, info.TLS.CommonName
+	} else if state := ctx.Request().TLS; state != nil {
+		version = tlsVersions[state.Version]
+		if len(state.PeerCertificates) > 0 {
+			commonName = state.PeerCertificates[0].Subject.CommonName
+		}
+	}
+
+	if commonName != "" {
+		return version + " CN=" + commonName
+	}
+
+	return version
+}
+
 // Columnize formats the given arguments as columns and returns the formatted output,
 // note that it appends a new line to the end.
 func Columnize(nowFormatted string, latency time.Duration, status, ip, method, path string, message interface{}, headerMessage interface{}) string {
@@ -3,9 +3,11 @@ package mvc
 import (
 	"fmt"
 	"reflect"
+	"runtime"
 	"strings"
 
 	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/core/errors"
 	"github.com/radiantrfid/iris/core/router"
 	"github.com/radiantrfid/iris/hero"
 	"github.com/radiantrfid/iris/hero/di"
@@ -42,6 +44,8 @@ type shared interface {
 type BeforeActivation interface {
 	shared
 	Dependencies() *di.Values
+	Negotiate(funcName string, n hero.Negotiation)
+	Filter(filter ActionFilter, funcNames ...string)
 }
 
 // AfterActivation is being used as the only one input argument of a
@@ -89,6 +93,14 @@ type ControllerActivator struct {
 	sorter       di.Sorter
 
 	errorHandler hero.ErrorHandler
+	// the content negotiation of the values returned by the methods, key = the controller's function name.
+	negotiations map[string]hero.Negotiation
+	// the naming convention of the methods to routes.
+	naming Naming
+	// the action filters which are registered through the `Filter`.
+	filters []methodFilter
+	// the middleware which the controller declares per method name, see `MiddlewareController`.
+	middleware map[string][]context.Handler
 
 	// initialized on the first `Handle` or immediately when "servesWebsocket" is true.
 	injector *di.StructInjector
@@ -112,6 +124,10 @@ func NameOf(v interface{}) string {
 func newControllerActivator(router router.Party, controller interface{}, dependencies []reflect.Value, sorter di.Sorter, errorHandler hero.ErrorHandler) *ControllerActivator {
 	typ := reflect.TypeOf(controller)
 
+	if prefix := pathPrefixOf(typ); prefix != "" {
+		router = router.Party(prefix)
+	}
+
 	c := &ControllerActivator{
 		// give access to the Router to the end-devs if they need it for some reason,
 		// i.e register done handlers.
@@ -133,9 +149,36 @@ func newControllerActivator(router router.Party, controller interface{}, depende
 		errorHandler: errorHandler,
 	}
 
+	c.middleware = c.declaredMiddleware()
 	return c
 }
 
+// the struct tag key of the path prefix of a controller, see `pathPrefixOf`.
+const pathPrefixTag = "mvc"
+
+// pathPrefixOf returns the path prefix of the controller's routes,
+// which is set by the struct tag of a blank field of the controller, i.e:
+//
+//	type UserController struct {
+//		_ struct{} `mvc:"/users"`
+//	}
+func pathPrefixOf(typ reflect.Type) string {
+	typ = di.IndirectType(typ)
+	if typ.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		if f := typ.Field(i); f.Name == "_" {
+			if prefix := f.Tag.Get(pathPrefixTag); prefix != "" {
+				return prefix
+			}
+		}
+	}
+
+	return ""
+}
+
 func whatReservedMethods(typ reflect.Type) map[string][]*router.Route {
 	methods := []string{"BeforeActivation", "AfterActivation"}
 	//  BeforeActivatior/AfterActivation are not routes but they are
@@ -144,6 +187,14 @@ func whatReservedMethods(typ reflect.Type) map[string][]*router.Route {
 		methods = append(methods, "BeginRequest", "EndRequest")
 	}
 
+	if isMiddlewareController(typ) {
+		methods = append(methods, "Middleware")
+	}
+
+	if isActionFilter(typ) {
+		methods = append(methods, "BeforeAction", "AfterAction")
+	}
+
 	routes := make(map[string][]*router.Route, len(methods))
 	for _, m := range methods {
 		routes[m] = []*router.Route{}
@@ -251,6 +302,50 @@ func (c *ControllerActivator) Singleton() bool {
 	return c.injector.Scope == di.Singleton
 }
 
+// Negotiate sets the content negotiation of the values which are returned by the "funcName" method
+// without a content type, i.e to render a struct as XML or through a view template
+// based on the client's "Accept" header, instead of the `hero.DefaultNegotiation`.
+//
+// Example:
+//
+//	func (c *UserController) BeforeActivation(b mvc.BeforeActivation) {
+//		b.Negotiate("GetBy", hero.Negotiation{
+//			MIMEs: []string{"application/json", "text/xml", "text/html"},
+//			View:  "user.html",
+//		})
+//	}
+func (c *ControllerActivator) Negotiate(funcName string, n hero.Negotiation) {
+	if c.negotiations == nil {
+		c.negotiations = make(map[string]hero.Negotiation)
+	}
+
+	c.negotiations[funcName] = n
+}
+
+// Filter registers an `ActionFilter` whose hooks run before and after the execution of the "funcNames" methods,
+// or of all of the controller's methods if "funcNames" is empty.
+// It should be called before the `Handle` of the methods that it applies to.
+//
+// Example:
+//
+//	func (c *UserController) BeforeActivation(b mvc.BeforeActivation) {
+//		b.Filter(new(auditFilter), "PostBy", "DeleteBy")
+//	}
+func (c *ControllerActivator) Filter(filter ActionFilter, funcNames ...string) {
+	c.filters = append(c.filters, methodFilter{filter: filter, funcNames: funcNames})
+}
+
+// filtersOf returns the registered action filters of the "funcName" method.
+func (c *ControllerActivator) filtersOf(funcName string) (filters []ActionFilter) {
+	for _, f := range c.filters {
+		if f.appliesTo(funcName) {
+			filters = append(filters, f.filter)
+		}
+	}
+
+	return
+}
+
 // checks if a method is already registered.
 func (c *ControllerActivator) isReservedMethod(name string) bool {
 	for methodName := range c.routes {
@@ -280,7 +375,7 @@ func (c *ControllerActivator) parseMethods() {
 }
 
 func (c *ControllerActivator) parseMethod(m reflect.Method) {
-	httpMethod, httpPath, err := parseMethod(*c.router.Macros(), m, c.isReservedMethod)
+	httpMethod, httpPath, err := parseMethod(*c.router.Macros(), m, c.isReservedMethod, c.naming)
 	if err != nil {
 		if err != errSkip {
 			c.addErr(fmt.Errorf("MVC: fail to parse the route path and HTTP method for '%s.%s': %v", c.fullName, m.Name, err))
@@ -356,9 +451,16 @@ func (c *ControllerActivator) handleMany(method, path, funcName string, override
 	funcDependencies.AddValues(pathParams...)
 
 	handler := c.handlerOf(m, funcDependencies)
+	if expected := hero.ExpectedParams(funcIn[1:]...); len(expected) > 0 && context.HandlerError(handler) == nil {
+		// the router checks the named path parameters against the route's path.
+		handler = context.ParamsHandler(handler, expected)
+	}
+
+	// the middleware which are declared by the controller itself run first.
+	handlers := append(c.middlewareOf(funcName), middleware...)
 
 	// register the handler now.
-	routes := c.router.HandleMany(method, path, append(middleware, handler)...)
+	routes := c.router.HandleMany(method, path, append(handlers, handler)...)
 	if routes == nil {
 		c.addErr(fmt.Errorf("MVC: unable to register a route for the path for '%s.%s'", c.fullName, funcName))
 		return nil
@@ -386,8 +488,40 @@ func (c *ControllerActivator) handleMany(method, path, funcName string, override
 
 var emptyIn = []reflect.Value{}
 
+// setNegotiation sets the negotiation of the "funcName" method, if any, see `Negotiate`.
+func (c *ControllerActivator) setNegotiation(ctx context.Context, funcName string) {
+	if n, ok := c.negotiations[funcName]; ok {
+		hero.SetNegotiation(ctx, n)
+	}
+}
+
+// unresolvedInputsError returns an error for each one of the "missing" input arguments of the "m" method,
+// except its receiver which is bound on serve-time,
+// with the method's source and the closest types of the "dependencies" as suggestions.
+func (c *ControllerActivator) unresolvedInputsError(m reflect.Method, missing []int, dependencies []reflect.Value) error {
+	rp := errors.NewReporter()
+	for _, index := range missing {
+		if index == 0 {
+			continue
+		}
+
+		pc := m.Func.Pointer()
+		fileName, lineNumber := runtime.FuncForPC(pc).FileLine(pc)
+		rp.Add("MVC Controller [%s]: method '%s' at %s:%d: %s", c.fullName, m.Name, fileName, lineNumber,
+			di.DescribeUnresolved(index, m.Type.In(index), dependencies, di.DefaultTypeChecker))
+	}
+
+	return rp.Return()
+}
+
 func (c *ControllerActivator) attachInjector() {
 	if c.injector == nil {
+		// report the missing dependencies and the dependency cycles of the fields' constructors at startup.
+		fieldTypes := di.FieldTypes(di.IndirectType(c.Type), true)
+		if err := di.Validate(c.dependencies, fieldTypes, di.DefaultHijacker, di.DefaultTypeChecker); err != nil {
+			c.router.GetReporter().Describe("MVC Controller ["+c.fullName+"]: %v", err)
+		}
+
 		c.injector = di.MakeStructInjector(
 			di.ValueOf(c.Value),
 			di.DefaultHijacker,
@@ -420,8 +554,23 @@ func (c *ControllerActivator) handlerOf(m reflect.Method, funcDependencies []ref
 
 	// fmt.Printf("for %s | values: %s\n", funcName, funcDependencies)
 
+	// the receiver, input #0, is bound on serve-time.
+	inputTypes := make([]reflect.Type, 0, m.Type.NumIn())
+	for i := 1; i < m.Type.NumIn(); i++ {
+		inputTypes = append(inputTypes, m.Type.In(i))
+	}
+
+	if err := di.Validate(funcDependencies, inputTypes, di.DefaultHijacker, di.DefaultTypeChecker); err != nil {
+		return context.InvalidHandler(fmt.Errorf("MVC Controller [%s]: method '%s': %v", c.fullName, m.Name, err))
+	}
+
 	funcInjector := di.Func(m.Func, funcDependencies...)
 	// fmt.Printf("actual injector's inputs length: %d\n", funcInjector.Length)
+	if err := c.unresolvedInputsError(m, funcInjector.Missing(), funcDependencies); err != nil {
+		// the router reports it and the application fails to build.
+		return context.InvalidHandler(err)
+	}
+
 	if funcInjector.Has {
 		golog.Debugf("MVC dependencies of method '%s.%s':\n%s", c.fullName, m.Name, funcInjector.String())
 	}
@@ -429,21 +578,27 @@ func (c *ControllerActivator) handlerOf(m reflect.Method, funcDependencies []ref
 	var (
 		implementsBase         = isBaseController(c.Type)
 		implementsErrorHandler = isErrorHandler(c.Type)
+		implementsActionFilter = isActionFilter(c.Type)
 		hasBindableFields      = c.injector.CanInject
 		hasBindableFuncInputs  = funcInjector.Has
 		funcHasErrorOut        = hasErrorOutArgs(m)
+		filters                = c.filtersOf(m.Name)
+		hasFilters             = len(filters) > 0 || implementsActionFilter
 
 		call = m.Func.Call
 	)
 
-	if !implementsBase && !hasBindableFields && !hasBindableFuncInputs && !implementsErrorHandler {
+	if !implementsBase && !hasBindableFields && !hasBindableFuncInputs && !implementsErrorHandler && !hasFilters {
 		return func(ctx context.Context) {
+			c.setNegotiation(ctx, m.Name)
 			hero.DispatchFuncResult(ctx, c.errorHandler, call(c.injector.AcquireSlice()))
 		}
 	}
 
 	n := m.Type.NumIn()
 	return func(ctx context.Context) {
+		c.setNegotiation(ctx, m.Name)
+
 		var (
 			ctrl         = c.injector.Acquire()
 			ctxValue     reflect.Value
@@ -476,6 +631,36 @@ func (c *ControllerActivator) handlerOf(m reflect.Method, funcDependencies []ref
 			errorHandler = ctrl.Interface().(hero.ErrorHandler)
 		}
 
+		if hasFilters {
+			in := []reflect.Value{ctrl}
+			if hasBindableFuncInputs {
+				if !hasBindableFields {
+					ctxValue = reflect.ValueOf(ctx)
+				}
+
+				in = make([]reflect.Value, n, n)
+				in[0] = ctrl
+				funcInjector.Inject(&in, ctxValue)
+
+				if ctx.IsStopped() {
+					return
+				}
+			}
+
+			actionFilters := filters
+			if implementsActionFilter {
+				// the controller's hooks run last, closest to the method.
+				actionFilters = append(actionFilters[0:len(actionFilters):len(actionFilters)], ctrl.Interface().(ActionFilter))
+			}
+
+			action := &Action{Context: ctx, Controller: ctrl, Method: m, In: in[1:]}
+			if runAction(action, actionFilters, call) {
+				hero.DispatchFuncResult(ctx, errorHandler, action.Out)
+			}
+
+			return
+		}
+
 		if hasBindableFuncInputs {
 			// means that ctxValue is not initialized before by the controller's struct injector.
 			if !hasBindableFields {
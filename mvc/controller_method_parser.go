@@ -17,6 +17,77 @@ const (
 	tokenWildcard = "Wildcard" // "ByWildcard".
 )
 
+// PathStyle is the style of the path segments of the static words of a controller's method name,
+// see `Naming`.
+type PathStyle uint8
+
+const (
+	// PathSegments converts each word to a path segment, i.e GetUserProfile -> /user/profile.
+	// It's the default style, a single uppercase last letter is dropped, as it always was,
+	// i.e GetUserByID -> /user/{param1:int64}/i.
+	PathSegments PathStyle = iota
+	// PathKebab joins the words, between the path parameters, with dashes, i.e GetUserProfile -> /user-profile.
+	PathKebab
+	// PathLower joins the words, between the path parameters, in lowercase, i.e GetUserProfile -> /userprofile.
+	PathLower
+)
+
+func (s PathStyle) join(words []string) string {
+	// keep the acronyms as one word, i.e HTML instead of H-T-M-L.
+	var merged []string
+	for i, w := range words {
+		if len(w) == 1 && i > 0 && len(words[i-1]) == 1 && len(merged) > 0 {
+			merged[len(merged)-1] += w
+			continue
+		}
+
+		merged = append(merged, w)
+	}
+
+	sep := ""
+	if s == PathKebab {
+		sep = "-"
+	}
+
+	return strings.ToLower(strings.Join(merged, sep))
+}
+
+// Naming is the naming convention which converts the names of a controller's methods to routes,
+// the zero value converts each word to a path segment and each "By" to a path parameter,
+// i.e GetUserBy(id int64) -> GET /user/{param1:int64}.
+//
+// See `Application.Naming` and `Application.DryRun` too.
+type Naming struct {
+	// Style is the style of the static path segments, defaults to `PathSegments`.
+	Style PathStyle
+	// Nested enables the plural-resource nested routes of the methods
+	// which are named as {HTTPMethod}{Resource}By{Parent}ID,
+	// i.e GetCommentsByPostID(postID int64) -> GET /posts/{postID:int64}/comments.
+	Nested bool
+	// Verbs are the custom verbs, i.e "Archive".
+	// A method which starts with a custom verb, after its HTTP method, and ends with a path parameter
+	// is registered as a custom method of that resource,
+	// i.e PostArchiveBy(id int64) -> POST /{param1:int64}:archive.
+	Verbs []string
+}
+
+// verbOf returns the custom verb, in lower camel case, which the "words" start with, if any,
+// and the number of its words.
+func (n Naming) verbOf(words []string) (string, int) {
+	for _, verb := range n.Verbs {
+		verbWords := newMethodLexer(strings.Title(verb)).words
+		if len(verbWords) == 0 || len(verbWords) > len(words) {
+			continue
+		}
+
+		if strings.Join(words[:len(verbWords)], "") == strings.Join(verbWords, "") {
+			return strings.ToLower(verb[:1]) + verb[1:], len(verbWords)
+		}
+	}
+
+	return "", 0
+}
+
 // word lexer, not characters.
 type methodLexer struct {
 	words []string
@@ -33,25 +104,22 @@ func (l *methodLexer) reset(s string) {
 	l.cur = -1
 	var words []string
 	if s != "" {
-		end := len(s)
 		start := -1
 
-		for i, n := 0, end; i < n; i++ {
+		for i, n := 0, len(s); i < n; i++ {
 			c := rune(s[i])
 			if unicode.IsUpper(c) {
-				// it doesn't count the last uppercase
 				if start != -1 {
-					end = i
-					words = append(words, s[start:end])
+					words = append(words, s[start:i])
 				}
 				start = i
-				continue
 			}
-			end = i + 1
 		}
 
-		if end > 0 && len(s) >= end {
-			words = append(words, s[start:end])
+		if start != -1 {
+			// including a single uppercase last word, i.e the "D" of "ID",
+			// the `PathSegments` style skips it, see `methodParser.parse`.
+			words = append(words, s[start:])
 		}
 	}
 
@@ -104,9 +172,12 @@ type methodParser struct {
 	lexer  *methodLexer
 	fn     reflect.Method
 	macros macro.Macros
+	naming Naming
+
+	words []string // the static words of the current path segment(s), see `addWords`.
 }
 
-func parseMethod(macros macro.Macros, fn reflect.Method, skipper func(string) bool) (method, path string, err error) {
+func parseMethod(macros macro.Macros, fn reflect.Method, skipper func(string) bool, naming Naming) (method, path string, err error) {
 	if skipper(fn.Name) {
 		return "", "", errSkip
 	}
@@ -115,6 +186,7 @@ func parseMethod(macros macro.Macros, fn reflect.Method, skipper func(string) bo
 		fn:     fn,
 		lexer:  newMethodLexer(fn.Name),
 		macros: macros,
+		naming: naming,
 	}
 	return p.parse()
 }
@@ -136,6 +208,26 @@ func addPathWord(path, w string) string {
 	return path
 }
 
+// addWords adds the pending static words to the "path", based on the naming's `PathStyle`.
+func (p *methodParser) addWords(path string) string {
+	if len(p.words) == 0 {
+		return path
+	}
+
+	words := p.words
+	p.words = nil
+
+	if p.naming.Style == PathSegments {
+		for _, w := range words {
+			path = addPathWord(path, w)
+		}
+
+		return path
+	}
+
+	return addPathWord(path, p.naming.Style.join(words))
+}
+
 func (p *methodParser) parse() (method, path string, err error) {
 	funcArgPos := 0
 	path = "/"
@@ -156,14 +248,31 @@ func (p *methodParser) parse() (method, path string, err error) {
 		return "", "", errSkip
 	}
 
+	verb, n := p.naming.verbOf(p.lexer.words[p.lexer.cur+1:])
+	for i := 0; i < n; i++ {
+		p.lexer.skip()
+	}
+
+	if p.naming.Nested {
+		if path, funcArgPos, err = p.parseNested(path); err != nil {
+			return "", "", err
+		}
+	}
+
 	for {
 		w := p.lexer.next()
 		if w == "" {
 			break
 		}
 
+		if p.naming.Style == PathSegments && len(w) == 1 && p.lexer.cur == len(p.lexer.words)-1 {
+			// the default style drops a single uppercase last letter, as it always did.
+			break
+		}
+
 		if w == tokenBy {
 			funcArgPos++ // starting with 1 because in typ.NumIn() the first is the struct receiver.
+			path = p.addWords(path)
 
 			// No need for these:
 			// ByBy will act like /{param:type}/{param:type} as users expected
@@ -181,11 +290,112 @@ func (p *methodParser) parse() (method, path string, err error) {
 			continue
 		}
 		// static path.
-		path = addPathWord(path, w)
+		p.words = append(p.words, w)
+	}
+
+	path = p.addWords(path)
+
+	if verb != "" {
+		if path[len(path)-1] != '}' {
+			// the custom verbs are valid after a path parameter only,
+			// parse it again without verbs, the verb is a static word.
+			p.naming.Verbs = nil
+			p.lexer.reset(p.fn.Name)
+			return p.parse()
+		}
+
+		path += ":" + verb
 	}
+
 	return
 }
 
+// parseNested parses the plural-resource nested route of the method's name, if it's one,
+// i.e GetCommentsByPostID(postID int64) -> /posts/{postID:int64}/comments.
+// It returns the "path" as it is if the method's name does not match
+// the {Resource}By{Parent}ID pattern.
+func (p *methodParser) parseNested(path string) (string, int, error) {
+	words := p.lexer.words[p.lexer.cur+1:]
+	by := -1
+	for i, w := range words {
+		if w == tokenBy {
+			by = i
+			break
+		}
+	}
+
+	// at least one word for the resource and one for the parent, followed by the "I", "D".
+	if by < 1 || len(words) < by+4 || p.fn.Type.NumIn() < 2 {
+		return path, 0, nil
+	}
+
+	parentEnd := -1
+	for i := by + 2; i < len(words)-1; i++ {
+		if words[i] == tokenBy {
+			break
+		}
+
+		if words[i] == "I" && words[i+1] == "D" {
+			parentEnd = i
+			break
+		}
+	}
+
+	if parentEnd == -1 {
+		return path, 0, nil
+	}
+
+	resource, parent := words[:by], words[by+1:parentEnd]
+
+	m, funcArgPos, err := p.macroOf(1)
+	if err != nil {
+		return "", 0, err
+	}
+
+	parentName := strings.Join(parent, "")
+	paramName := strings.ToLower(parentName[:1]) + parentName[1:] + "ID"
+
+	p.words = append(append([]string{}, parent[:len(parent)-1]...), pluralize(parent[len(parent)-1]))
+	path = p.addWords(path)
+	path += fmt.Sprintf("/{%s:%s}", paramName, m.Indent())
+
+	p.words = append([]string{}, resource...)
+	path = p.addWords(path)
+
+	// continue after the "ID".
+	p.lexer.cur += parentEnd + 2
+	return path, funcArgPos, nil
+}
+
+// macroOf returns the macro of the path parameter of the "funcArgPos" input argument,
+// or of the next one which has a valid macro, i.e the first one may be a Context,
+// and its input argument's position.
+func (p *methodParser) macroOf(funcArgPos int) (*macro.Macro, int, error) {
+	typ := p.fn.Type
+	for ; funcArgPos < typ.NumIn(); funcArgPos++ {
+		goType := typ.In(funcArgPos).Kind()
+		if m := p.macros.Get(strings.ToLower(goType.String())); m != nil {
+			return m, funcArgPos, nil
+		}
+	}
+
+	return nil, 0, fmt.Errorf("invalid syntax: there is no input argument, after the position: %d, in controller's function: %s that matches any valid macro", funcArgPos, p.fn.Name)
+}
+
+// pluralize returns the plural form of an English noun, i.e "Post" -> "Posts", "Category" -> "Categories".
+func pluralize(w string) string {
+	lower := strings.ToLower(w)
+	switch {
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return w + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return w[:len(w)-1] + "ies"
+	default:
+		return w + "s"
+	}
+}
+
 func (p *methodParser) parsePathParam(path string, w string, funcArgPos int) (string, int, error) {
 	typ := p.fn.Type
 
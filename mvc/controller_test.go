@@ -2,11 +2,16 @@
 package mvc_test
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/radiantrfid/iris"
 	"github.com/radiantrfid/iris/context"
 	"github.com/radiantrfid/iris/core/router"
+	"github.com/radiantrfid/iris/hero"
 	"github.com/radiantrfid/iris/httptest"
 
 	. "github.com/radiantrfid/iris/mvc"
@@ -526,3 +531,332 @@ func TestControllerNotCreateNewDueManuallySettingAllFields(t *testing.T) {
 	e.GET("/").Expect().Status(iris.StatusOK).
 		Body().Equal("my title")
 }
+
+type (
+	testGreeter struct {
+		prefix string
+	}
+
+	testControllerConstructors struct {
+		Greeter *testGreeter
+	}
+)
+
+func (c *testControllerConstructors) GetBy(name string) string {
+	return c.Greeter.prefix + " " + name
+}
+
+func TestControllerDependencyConstructors(t *testing.T) {
+	app := iris.New()
+
+	m := New(app)
+	// the constructor depends on the static value, registered after it.
+	m.Register(hero.Transient(func(title *testBindType) *testGreeter {
+		return &testGreeter{prefix: title.title}
+	}), &testBindType{title: "hello"})
+	m.Handle(new(testControllerConstructors))
+
+	e := httptest.New(t, app)
+	e.GET("/kataras").Expect().Status(iris.StatusOK).
+		Body().Equal("hello kataras")
+}
+
+type testControllerUnresolvedInputs struct{}
+
+func (c *testControllerUnresolvedInputs) Get(greeter testGreeter) string {
+	return greeter.prefix
+}
+
+func TestControllerUnresolvedInputs(t *testing.T) {
+	app := iris.New()
+	New(app).Register(&testGreeter{prefix: "hello"}).Handle(new(testControllerUnresolvedInputs))
+
+	err := app.Build()
+	if err == nil {
+		t.Fatalf("expected the build to fail because of the unresolved input argument")
+	}
+
+	expected := "method 'Get' at "
+	if !strings.Contains(err.Error(), expected) || !strings.Contains(err.Error(),
+		"unresolved input argument #1 of type 'mvc_test.testGreeter', did you mean '*mvc_test.testGreeter'?") {
+		t.Fatalf("expected the error to describe the input argument of the method but got: %v", err)
+	}
+}
+
+var errTestNotFound = errors.New("resource not found")
+
+type testControllerErrorMapper struct{}
+
+func (c *testControllerErrorMapper) Get() (string, error) {
+	return "", errTestNotFound
+}
+
+func TestControllerErrorMapper(t *testing.T) {
+	app := iris.New()
+	app.ErrorMapper().Map(errTestNotFound, iris.StatusNotFound)
+	New(app).Handle(new(testControllerErrorMapper))
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(iris.StatusNotFound).
+		ContentType(context.ContentJSONProblemHeaderValue, "utf-8").
+		Body().Contains(`"detail": "resource not found"`)
+}
+
+type testControllerNegotiation struct{}
+
+func (c *testControllerNegotiation) BeforeActivation(b BeforeActivation) {
+	b.Negotiate("GetXml", hero.Negotiation{MIMEs: []string{context.ContentXMLHeaderValue, context.ContentJSONHeaderValue}})
+}
+
+type testNegotiated struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func (c *testControllerNegotiation) Get() testNegotiated {
+	return testNegotiated{Name: "kataras"}
+}
+
+func (c *testControllerNegotiation) GetXml() testNegotiated {
+	return testNegotiated{Name: "kataras"}
+}
+
+func TestControllerNegotiation(t *testing.T) {
+	app := iris.New()
+	New(app).Handle(new(testControllerNegotiation))
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(iris.StatusOK).
+		JSON().Equal(iris.Map{"name": "kataras"})
+	e.GET("/").WithHeader("Accept", context.ContentXMLHeaderValue).Expect().Status(iris.StatusOK).
+		ContentType(context.ContentXMLHeaderValue, "utf-8").Body().Contains("<name>kataras</name>")
+	// the method prefers xml.
+	e.GET("/xml").Expect().Status(iris.StatusOK).
+		ContentType(context.ContentXMLHeaderValue, "utf-8").Body().Contains("<name>kataras</name>")
+	e.GET("/xml").WithHeader("Accept", "application/json").Expect().Status(iris.StatusOK).
+		JSON().Equal(iris.Map{"name": "kataras"})
+}
+
+type testControllerNaming struct {
+	_ struct{} `mvc:"/users"`
+}
+
+func (c *testControllerNaming) GetUserProfile() string {
+	return "profile"
+}
+
+func (c *testControllerNaming) GetCommentsByPostID(postID int64) string {
+	return fmt.Sprintf("comments of %d", postID)
+}
+
+func (c *testControllerNaming) PostArchiveBy(id int64) string {
+	return fmt.Sprintf("archived %d", id)
+}
+
+func (c *testControllerNaming) GetBy(id int64) string {
+	return fmt.Sprintf("user %d", id)
+}
+
+func TestControllerNaming(t *testing.T) {
+	app := iris.New()
+	m := New(app)
+	m.Naming = Naming{Style: PathKebab, Nested: true, Verbs: []string{"Archive"}}
+	m.Handle(new(testControllerNaming))
+
+	e := httptest.New(t, app)
+	e.GET("/users/user-profile").Expect().Status(iris.StatusOK).Body().Equal("profile")
+	e.GET("/users/posts/42/comments").Expect().Status(iris.StatusOK).Body().Equal("comments of 42")
+	e.POST("/users/42:archive").Expect().Status(iris.StatusOK).Body().Equal("archived 42")
+	e.GET("/users/42").Expect().Status(iris.StatusOK).Body().Equal("user 42")
+	e.GET("/users/user/profile").Expect().Status(iris.StatusNotFound)
+
+	report := m.DryRun(new(testControllerNaming)).String()
+	for _, expected := range []string{
+		"GET /users/user-profile -> mvc_test.testControllerNaming.GetUserProfile",
+		"GET /users/posts/{postID:int64}/comments -> mvc_test.testControllerNaming.GetCommentsByPostID",
+		"POST /users/{param1:int64}:archive -> mvc_test.testControllerNaming.PostArchiveBy",
+		"GET /users/{param1:int64} -> mvc_test.testControllerNaming.GetBy",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Fatalf("expected the dry-run report to contain %q but got:\n%s", expected, report)
+		}
+	}
+}
+
+type testControllerDefaultNaming struct{}
+
+func (c *testControllerDefaultNaming) GetUserByID(id int64) string { return "" }
+func (c *testControllerDefaultNaming) GetHTTP() string             { return "" }
+func (c *testControllerDefaultNaming) GetXMLFeed() string          { return "" }
+func (c *testControllerDefaultNaming) GetByX(x string) string      { return "" }
+
+func TestControllerDefaultNaming(t *testing.T) {
+	tests := []struct {
+		naming   Naming
+		expected []string
+	}{
+		// the default naming keeps the routes as they always were.
+		{Naming{}, []string{
+			"GET /user/{param1:int64}/i -> mvc_test.testControllerDefaultNaming.GetUserByID",
+			"GET /h/t/t -> mvc_test.testControllerDefaultNaming.GetHTTP",
+			"GET /x/m/l/feed -> mvc_test.testControllerDefaultNaming.GetXMLFeed",
+			"GET /{param1:string} -> mvc_test.testControllerDefaultNaming.GetByX",
+		}},
+		// the rest styles keep the acronyms as one word.
+		{Naming{Style: PathKebab}, []string{
+			"GET /user/{param1:int64}/id -> mvc_test.testControllerDefaultNaming.GetUserByID",
+			"GET /http -> mvc_test.testControllerDefaultNaming.GetHTTP",
+			"GET /xml-feed -> mvc_test.testControllerDefaultNaming.GetXMLFeed",
+			"GET /{param1:string}/x -> mvc_test.testControllerDefaultNaming.GetByX",
+		}},
+	}
+
+	for i, tt := range tests {
+		m := New(iris.New())
+		m.Naming = tt.naming
+		report := m.DryRun(new(testControllerDefaultNaming)).String()
+		for _, expected := range tt.expected {
+			if !strings.Contains(report, expected) {
+				t.Fatalf("[%d] expected the dry-run report to contain %q but got:\n%s", i, expected, report)
+			}
+		}
+	}
+}
+
+type testControllerFilters struct{}
+
+func (c *testControllerFilters) Middleware() map[string][]context.Handler {
+	return map[string][]context.Handler{
+		"*": {func(ctx context.Context) {
+			ctx.Header("X-Controller", "filters")
+			ctx.Next()
+		}},
+		"DeleteBy": {func(ctx context.Context) {
+			ctx.StatusCode(iris.StatusForbidden)
+		}},
+	}
+}
+
+func (c *testControllerFilters) BeforeActivation(b BeforeActivation) {
+	b.Filter(testInputsFilter{}, "GetBy")
+}
+
+func (c *testControllerFilters) BeforeAction(a *Action) {
+	if a.Method.Name == "GetSecret" {
+		a.Context.StatusCode(iris.StatusUnauthorized)
+		a.Context.StopExecution()
+	}
+}
+
+func (c *testControllerFilters) AfterAction(a *Action) {
+	if a.Method.Name == "Get" {
+		a.Out[0] = reflect.ValueOf(a.Out[0].String() + " after action")
+	}
+}
+
+func (c *testControllerFilters) Get() string {
+	return "index"
+}
+
+func (c *testControllerFilters) GetBy(id int64) string {
+	return fmt.Sprintf("item %d", id)
+}
+
+func (c *testControllerFilters) GetSecret() string {
+	return "secret"
+}
+
+func (c *testControllerFilters) DeleteBy(id int64) string {
+	return "deleted"
+}
+
+// testInputsFilter doubles the input argument of the method.
+type testInputsFilter struct{}
+
+func (testInputsFilter) BeforeAction(a *Action) {
+	a.In[0] = reflect.ValueOf(a.In[0].Int() * 2)
+}
+
+func (testInputsFilter) AfterAction(a *Action) {
+	a.Context.Header("X-Result", a.Out[0].String())
+}
+
+func TestControllerFilters(t *testing.T) {
+	app := iris.New()
+	New(app).Handle(new(testControllerFilters))
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(iris.StatusOK).
+		Header("X-Controller").Equal("filters")
+	e.GET("/").Expect().Body().Equal("index after action")
+	e.GET("/21").Expect().Status(iris.StatusOK).
+		Header("X-Result").Equal("item 42")
+	e.GET("/21").Expect().Body().Equal("item 42")
+	e.GET("/secret").Expect().Status(iris.StatusUnauthorized).Body().NotContains("secret")
+	e.DELETE("/42").Expect().Status(iris.StatusForbidden).
+		Header("X-Controller").Equal("filters")
+}
+
+type testControllerMiddlewareTypo struct {
+	calls *int
+}
+
+func (c *testControllerMiddlewareTypo) Middleware() map[string][]context.Handler {
+	*c.calls++
+	return map[string][]context.Handler{
+		"Get":       {func(ctx context.Context) { ctx.Next() }},
+		"DeleteBy ": {func(ctx context.Context) { ctx.StatusCode(iris.StatusForbidden) }},
+	}
+}
+
+func (c *testControllerMiddlewareTypo) Get() string            { return "index" }
+func (c *testControllerMiddlewareTypo) GetBy(id int64) int64   { return id }
+func (c *testControllerMiddlewareTypo) DeleteBy(id int64) bool { return true }
+
+func TestControllerMiddlewareUnknownMethod(t *testing.T) {
+	app := iris.New()
+	calls := 0
+	New(app).Handle(&testControllerMiddlewareTypo{calls: &calls})
+
+	if calls != 1 {
+		t.Fatalf("expected the Middleware to be called once, on activation, but got: %d calls", calls)
+	}
+
+	err := app.Build()
+	if err == nil || !strings.Contains(err.Error(), "middleware declared for the unknown method 'DeleteBy '") {
+		t.Fatalf("expected the build to fail because of the unknown method but got: %v", err)
+	}
+}
+
+type testMemberParams struct {
+	OrgID  int64 `param:"org"`
+	UserID int64 `param:"user"`
+}
+
+type testControllerNamedParams struct{}
+
+func (c *testControllerNamedParams) BeforeActivation(b BeforeActivation) {
+	b.Handle("GET", "/orgs/{org:int64}/users/{user:int64}", "Member")
+	b.Handle("GET", "/users/{user:int64}", "InvalidMember")
+}
+
+func (c *testControllerNamedParams) Member(ctx iris.Context, p testMemberParams) string {
+	return fmt.Sprintf("org: %d, user: %d", p.OrgID, p.UserID)
+}
+
+func (c *testControllerNamedParams) InvalidMember(p testMemberParams) string {
+	return ""
+}
+
+func TestControllerNamedParams(t *testing.T) {
+	app := iris.New()
+	New(app).Handle(new(testControllerNamedParams))
+
+	err := app.Build()
+	expected := "route GET /users/{user:int64}: field 'mvc_test.testMemberParams.OrgID' expects the path parameter 'org' which is not declared by the path"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected the build to fail with:\n%s\nbut got:\n%v", expected, err)
+	}
+
+	e := httptest.New(t, app)
+	e.GET("/orgs/1/users/2").Expect().Status(iris.StatusOK).Body().Equal("org: 1, user: 2")
+}
@@ -0,0 +1,131 @@
+package mvc
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/radiantrfid/iris/context"
+)
+
+// MiddlewareController is the optional controller interface
+// which declares the middleware of the controller's methods, per method name.
+// The "*" key registers middleware to all of the controller's methods,
+// they run before the middleware of the method and the ones given to the `Handle`.
+//
+// The `Middleware` is called once, on the controller's activation.
+//
+// Example:
+//
+//	func (c *UserController) Middleware() map[string][]iris.Handler {
+//		return map[string][]iris.Handler{
+//			"*":        {logRequest},
+//			"DeleteBy": {requireAdmin},
+//		}
+//	}
+type MiddlewareController interface {
+	Middleware() map[string][]context.Handler
+}
+
+// the key of the middleware of all methods, see `MiddlewareController`.
+const allMethodsMiddlewareKey = "*"
+
+// Action describes the execution of a controller's method, it's the input argument of the `ActionFilter` hooks.
+type Action struct {
+	Context context.Context
+	// Controller is the controller's instance of the current request, a pointer to the controller's struct.
+	Controller reflect.Value
+	// Method is the controller's method, i.e "GetBy".
+	Method reflect.Method
+	// In are the resolved input arguments of the method, without its receiver.
+	// They can be replaced by the `BeforeAction`.
+	In []reflect.Value
+	// Out are the return values of the method, they are available on the `AfterAction`
+	// and they can be replaced before their dispatch.
+	Out []reflect.Value
+}
+
+// ActionFilter is the optional controller interface, and the `BeforeActivation#Filter` one,
+// whose hooks run before and after the execution of the controller's methods,
+// with access to the resolved input arguments and the return values of the method, see `Action`.
+//
+// The `BeforeAction` can stop the execution, i.e on a failed authorization, through the `Context.StopExecution`,
+// then the method, and the rest of the hooks, are not executed.
+// The `AfterAction` runs before the return values are written to the client.
+//
+// The hooks of the `BeforeActivation#Filter` run first, in the order they are registered,
+// and the ones of the controller itself last, closest to the method;
+// the `AfterAction` hooks run in the reverse order.
+type ActionFilter interface {
+	BeforeAction(*Action)
+	AfterAction(*Action)
+}
+
+// methodFilter is an `ActionFilter` which is registered to some of the controller's methods.
+type methodFilter struct {
+	filter    ActionFilter
+	funcNames []string // all methods if empty.
+}
+
+func (f methodFilter) appliesTo(funcName string) bool {
+	if len(f.funcNames) == 0 {
+		return true
+	}
+
+	for _, name := range f.funcNames {
+		if name == funcName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// declaredMiddleware returns the middleware which the controller declares per method name,
+// see `MiddlewareController`. The names which match no method of the controller,
+// i.e a typo, are reported and the application fails to build.
+func (c *ControllerActivator) declaredMiddleware() map[string][]context.Handler {
+	ctrl, ok := c.Value.Interface().(MiddlewareController)
+	if !ok {
+		return nil
+	}
+
+	declared := ctrl.Middleware()
+	for funcName := range declared {
+		if funcName == allMethodsMiddlewareKey {
+			continue
+		}
+
+		if _, ok := c.Type.MethodByName(funcName); !ok {
+			c.addErr(fmt.Errorf("MVC Controller [%s]: middleware declared for the unknown method '%s'", c.fullName, funcName))
+		}
+	}
+
+	return declared
+}
+
+// middlewareOf returns the middleware which the controller declares for the "funcName" method.
+func (c *ControllerActivator) middlewareOf(funcName string) (middleware context.Handlers) {
+	middleware = append(middleware, c.middleware[allMethodsMiddlewareKey]...)
+	return append(middleware, c.middleware[funcName]...)
+}
+
+// runAction executes the method of the "action" between the hooks of the "filters",
+// it reports false if a `BeforeAction` stopped the execution.
+func runAction(action *Action, filters []ActionFilter, call func([]reflect.Value) []reflect.Value) bool {
+	for _, f := range filters {
+		f.BeforeAction(action)
+		if action.Context.IsStopped() {
+			return false
+		}
+	}
+
+	in := make([]reflect.Value, 0, len(action.In)+1)
+	in = append(in, action.Controller)
+	action.Out = call(append(in, action.In...))
+
+	for i := len(filters) - 1; i >= 0; i-- {
+		filters[i].AfterAction(action)
+	}
+
+	return true
+}
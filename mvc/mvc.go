@@ -1,6 +1,7 @@
 package mvc
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 
@@ -49,6 +50,10 @@ type Application struct {
 	Controllers          []*ControllerActivator
 	websocketControllers []websocket.ConnHandler
 	ErrorHandler         hero.ErrorHandler
+	// Naming is the naming convention of the controllers' methods to routes,
+	// i.e kebab-case paths, nested resources and custom verbs.
+	// Defaults to the zero `Naming`, each word of a method's name is a path segment.
+	Naming Naming
 }
 
 func newApp(subRouter router.Party, values di.Values) *Application {
@@ -114,6 +119,10 @@ func (app *Application) Configure(configurators ...func(*Application)) *Applicat
 // via controller's `BeforeActivation` and `AfterActivation` methods,
 // look the `Handle` method for more.
 //
+// A function can be registered with an explicit lifetime,
+// see the `hero.Singleton`, `hero.RequestScoped` and `hero.Transient`,
+// a function with other input arguments is a constructor only when registered with one of them.
+//
 // It returns this Application.
 //
 // Example: `.Register(loggerService{prefix: "dev"}, func(ctx iris.Context) User {...})`.
@@ -192,6 +201,13 @@ func (app *Application) Handle(controller interface{}) *Application {
 	return app
 }
 
+// HandleResource registers the RESTful routes of the "resource" to the application's router,
+// through a `ResourceController`.
+// To override some of its methods, embed the `ResourceController` to a controller and `Handle` that instead.
+func (app *Application) HandleResource(resource *Resource) *Application {
+	return app.Handle(&ResourceController{Resource: resource})
+}
+
 // HandleWebsocket handles a websocket specific controller.
 // Its exported methods are the events.
 // If a "Namespace" field or method exists then namespace is set, otherwise empty namespace.
@@ -233,6 +249,7 @@ func (app *Application) GetNamespaces() websocket.Namespaces {
 func (app *Application) handle(controller interface{}) *ControllerActivator {
 	// initialize the controller's activator, nothing too magical so far.
 	c := newControllerActivator(app.Router, controller, app.Dependencies, app.Sorter, app.ErrorHandler)
+	c.naming = app.Naming
 
 	// check the controller's "BeforeActivation" or/and "AfterActivation" method(s) between the `activate`
 	// call, which is simply parses the controller's methods, end-dev can register custom controller's methods
@@ -255,6 +272,80 @@ func (app *Application) handle(controller interface{}) *ControllerActivator {
 	return c
 }
 
+// DerivedRoute is a route which is derived from the name of a controller's method,
+// see `Application.DryRun`.
+type DerivedRoute struct {
+	Method   string
+	Path     string
+	FuncName string // i.e "user.Controller.GetBy".
+	// Err is the error of the parsing of the method's name, the route is not registered if it's not nil.
+	Err error
+}
+
+// String returns the route's description, i.e "GET /user/{param1:int64} -> user.Controller.GetBy".
+func (r DerivedRoute) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%s: %v", r.FuncName, r.Err)
+	}
+
+	return fmt.Sprintf("%s %s -> %s", r.Method, r.Path, r.FuncName)
+}
+
+// DryRunReport is the list of the routes which are derived from the methods of a controller.
+type DryRunReport []DerivedRoute
+
+// String returns the routes of the report, one per line.
+func (report DryRunReport) String() string {
+	lines := make([]string, len(report))
+	for i, r := range report {
+		lines[i] = r.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// DryRun returns the routes that the methods of the "controller" would be registered to by the `Handle`,
+// based on the application's `Naming` and the controller's path prefix, without registering them.
+// The routes which are registered manually, through the `BeforeActivation`, are not included.
+//
+// Example: `fmt.Println(mvc.New(app.Party("/api")).DryRun(new(PostsController)))`.
+func (app *Application) DryRun(controller interface{}) DryRunReport {
+	typ := reflect.TypeOf(controller)
+	fullName := NameOf(controller)
+	reserved := whatReservedMethods(typ)
+	isReserved := func(name string) bool {
+		_, ok := reserved[name]
+		return ok
+	}
+
+	prefix := pathPrefixOf(typ)
+	if relPath := app.Router.GetRelPath(); strings.HasPrefix(relPath, "/") {
+		prefix = strings.TrimSuffix(relPath, "/") + prefix
+	}
+
+	var report DryRunReport
+	for i := 0; i < typ.NumMethod(); i++ {
+		m := typ.Method(i)
+		method, path, err := parseMethod(*app.Router.Macros(), m, isReserved, app.Naming)
+		if err == errSkip {
+			continue
+		}
+
+		if path == "/" && prefix != "" {
+			path = ""
+		}
+
+		report = append(report, DerivedRoute{
+			Method:   method,
+			Path:     prefix + path,
+			FuncName: fullName + "." + m.Name,
+			Err:      err,
+		})
+	}
+
+	return report
+}
+
 // HandleError registers a `hero.ErrorHandlerFunc` which will be fired when
 // application's controllers' functions returns an non-nil error.
 // Each controller can override it by implementing the `hero.ErrorHandler`.
@@ -264,12 +355,13 @@ func (app *Application) HandleError(errHandler hero.ErrorHandlerFunc) *Applicati
 }
 
 // Clone returns a new mvc Application which has the dependencies
-// of the current mvc Application's `Dependencies` and its `ErrorHandler`.
+// of the current mvc Application's `Dependencies`, its `ErrorHandler` and its `Naming`.
 //
 // Example: `.Clone(app.Party("/path")).Handle(new(TodoSubController))`.
 func (app *Application) Clone(party router.Party) *Application {
 	cloned := newApp(party, app.Dependencies.Clone())
 	cloned.ErrorHandler = app.ErrorHandler
+	cloned.Naming = app.Naming
 	return cloned
 }
 
@@ -9,6 +9,8 @@ import (
 var (
 	baseControllerTyp = reflect.TypeOf((*BaseController)(nil)).Elem()
 	errorHandlerTyp   = reflect.TypeOf((*hero.ErrorHandler)(nil)).Elem()
+	middlewareCtrlTyp = reflect.TypeOf((*MiddlewareController)(nil)).Elem()
+	actionFilterTyp   = reflect.TypeOf((*ActionFilter)(nil)).Elem()
 	errorTyp          = reflect.TypeOf((*error)(nil)).Elem()
 )
 
@@ -20,6 +22,14 @@ func isErrorHandler(ctrlTyp reflect.Type) bool {
 	return ctrlTyp.Implements(errorHandlerTyp)
 }
 
+func isMiddlewareController(ctrlTyp reflect.Type) bool {
+	return ctrlTyp.Implements(middlewareCtrlTyp)
+}
+
+func isActionFilter(ctrlTyp reflect.Type) bool {
+	return ctrlTyp.Implements(actionFilterTyp)
+}
+
 func hasErrorOutArgs(fn reflect.Method) bool {
 	n := fn.Type.NumOut()
 	if n == 0 {
@@ -0,0 +1,517 @@
+package mvc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/hero"
+	"github.com/radiantrfid/iris/hero/di"
+)
+
+// ErrResourceNotFound should be returned by the `Repository` when a resource does not exist,
+// the `Resource` responds with a 404 Not Found Problem.
+var ErrResourceNotFound = errors.New("resource not found")
+
+// ContentMergePatchJSONHeaderValue is the content type of a JSON Merge Patch (RFC 7386) request body.
+const ContentMergePatchJSONHeaderValue = "application/merge-patch+json"
+
+// The query parameters of the `Resource#List`.
+const (
+	// PageQueryParam is the number of the page, starting from 1.
+	PageQueryParam = "page"
+	// PerPageQueryParam is the number of the resources per page.
+	PerPageQueryParam = "per_page"
+	// SortQueryParam is the comma separated list of the sort fields, a "-" prefix sorts in descending order,
+	// i.e "?sort=-created,name".
+	SortQueryParam = "sort"
+)
+
+// Filter is the filter of the resources of a `Repository#List`,
+// a key is a whitelisted query parameter, see `Resource.Filters`.
+type Filter map[string]string
+
+// SortField is a field of the `Page.Sort`.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Page is the requested page of the resources of a `Repository#List`.
+type Page struct {
+	// Number is the number of the page, starting from 1.
+	Number int
+	// Size is the maximum number of the resources of the page.
+	Size int
+	// Sort are the whitelisted sort fields, in order, see `Resource.Sorts`.
+	Sort []SortField
+}
+
+// Offset returns the number of the resources before the page.
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Size
+}
+
+// Repository is the data access of the resources of a `Resource`.
+// The values that it accepts are pointers to new values of the resource's model, i.e *User,
+// the values that it returns are written to the client as they are, i.e a *User and a []User.
+//
+// The `Find`, `Update` and `Delete` should return the `ErrResourceNotFound` for a missing resource.
+type Repository interface {
+	Find(id string) (interface{}, error)
+	List(filter Filter, page Page) (items interface{}, total int, err error)
+	Insert(v interface{}) (interface{}, error)
+	Update(id string, v interface{}) (interface{}, error)
+	Delete(id string) error
+}
+
+// Resource describes a RESTful resource of a model which is stored to a `Repository`,
+// it's served by the `ResourceController`.
+//
+// The failures are written as Problems: 400 for an invalid request, 404 for the `ErrResourceNotFound`,
+// 415 for an unsupported patch, 422 for a validation error and 500 for any other repository error.
+// The errors which are mapped by the `Application.ErrorMapper` are written based on their mapping.
+type Resource struct {
+	Repository Repository
+	// Model is the type of the resource's values, i.e User.
+	Model reflect.Type
+
+	// Filters are the query parameters which are passed to the `Repository#List` as its `Filter`,
+	// the rest are ignored.
+	Filters []string
+	// Sorts are the fields that the list can be sorted by, a request with any other field fails.
+	Sorts []string
+	// PerPage is the default size of a page, defaults to 20.
+	PerPage int
+	// MaxPerPage is the maximum size of a page that a client can request, defaults to 100.
+	MaxPerPage int
+
+	// Validate validates a value before its insert or update, its error is written as a 422 Problem.
+	// A value which implements the `Validate() error` is validated before that too.
+	Validate func(ctx context.Context, v interface{}) error
+}
+
+// NewResource returns a new `Resource` of the "model" values, i.e User{}, which are stored to the "repo".
+//
+// Example:
+//
+//	users := mvc.NewResource(repo, User{})
+//	users.Filters = []string{"role"}
+//	users.Sorts = []string{"name", "created"}
+//	mvc.New(app.Party("/users")).HandleResource(users)
+func NewResource(repo Repository, model interface{}) *Resource {
+	return &Resource{
+		Repository: repo,
+		Model:      di.IndirectType(reflect.TypeOf(model)),
+		PerPage:    20,
+		MaxPerPage: 100,
+	}
+}
+
+// newValue returns a pointer to a new value of the model.
+func (r *Resource) newValue() interface{} {
+	return reflect.New(r.Model).Interface()
+}
+
+// fail writes the Problem of the "err" and stops the execution of the handlers chain.
+// The errors of the 5xx status codes are logged, their text is not written to the client,
+// i.e the SQL errors of the repository.
+func (r *Resource) fail(ctx context.Context, status int, err error) {
+	if ctx.Application().ErrorMapper().Dispatch(ctx, err) {
+		return
+	}
+
+	if isResourceNotFound(err) {
+		status = http.StatusNotFound
+	}
+
+	detail := err.Error()
+	if status >= http.StatusInternalServerError {
+		ctx.Application().Logger().Errorf("mvc: resource: %s %s: %v", ctx.Method(), ctx.Path(), err)
+		detail = http.StatusText(status)
+	}
+
+	ctx.Problem(context.NewProblem().Status(status).Detail(detail))
+	ctx.StopExecution()
+}
+
+// isResourceNotFound reports whether the "err", or an error which it wraps through its `Unwrap() error`,
+// is the `ErrResourceNotFound`, or reports true on its `Is(error) bool`.
+func isResourceNotFound(err error) bool {
+	for err != nil {
+		if err == ErrResourceNotFound {
+			return true
+		}
+
+		if is, ok := err.(interface{ Is(error) bool }); ok && is.Is(ErrResourceNotFound) {
+			return true
+		}
+
+		wrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+
+		err = wrapper.Unwrap()
+	}
+
+	return false
+}
+
+// write writes the "v" value based on the negotiation of the request, see `hero.DispatchNegotiated`.
+func (r *Resource) write(ctx context.Context, v interface{}) {
+	if err := hero.DispatchNegotiated(ctx, v); err != nil {
+		r.fail(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+func (r *Resource) validate(ctx context.Context, v interface{}) error {
+	if validator, ok := v.(interface{ Validate() error }); ok {
+		if err := validator.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if r.Validate != nil {
+		return r.Validate(ctx, v)
+	}
+
+	return nil
+}
+
+// List writes the page of the resources that the query parameters request,
+// with the "Link" (first, prev, next and last) and the "X-Total-Count" headers.
+func (r *Resource) List(ctx context.Context) {
+	page, err := r.pageOf(ctx)
+	if err != nil {
+		r.fail(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	filter := make(Filter)
+	for _, name := range r.Filters {
+		if ctx.URLParamExists(name) {
+			filter[name] = ctx.URLParam(name)
+		}
+	}
+
+	items, total, err := r.Repository.List(filter, page)
+	if err != nil {
+		r.fail(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Header("X-Total-Count", strconv.Itoa(total))
+	ctx.Header("Link", pageLinks(ctx, page, total))
+
+	if items == nil {
+		items = []interface{}{}
+	} else if v := reflect.ValueOf(items); v.Kind() == reflect.Slice && v.IsNil() {
+		items = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+
+	r.write(ctx, items)
+}
+
+// pageOf returns the requested page, the sort fields should be whitelisted by the `Sorts`.
+func (r *Resource) pageOf(ctx context.Context) (Page, error) {
+	page := Page{Number: 1, Size: r.PerPage}
+	if page.Size <= 0 {
+		page.Size = 20
+	}
+
+	if ctx.URLParamExists(PageQueryParam) {
+		n, err := strconv.Atoi(ctx.URLParam(PageQueryParam))
+		if err != nil || n < 1 {
+			return page, fmt.Errorf("invalid %s query parameter, it should be a positive number", PageQueryParam)
+		}
+		page.Number = n
+	}
+
+	if ctx.URLParamExists(PerPageQueryParam) {
+		n, err := strconv.Atoi(ctx.URLParam(PerPageQueryParam))
+		if err != nil || n < 1 {
+			return page, fmt.Errorf("invalid %s query parameter, it should be a positive number", PerPageQueryParam)
+		}
+		page.Size = n
+	}
+
+	if r.MaxPerPage > 0 && page.Size > r.MaxPerPage {
+		page.Size = r.MaxPerPage
+	}
+
+	if sort := ctx.URLParamTrim(SortQueryParam); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			f := SortField{Field: strings.TrimSpace(field)}
+			if strings.HasPrefix(f.Field, "-") {
+				f.Field, f.Desc = f.Field[1:], true
+			}
+
+			if !containsString(r.Sorts, f.Field) {
+				return page, fmt.Errorf("the resources can not be sorted by '%s'", f.Field)
+			}
+
+			page.Sort = append(page.Sort, f)
+		}
+	}
+
+	return page, nil
+}
+
+// pageLinks returns the "Link" header value of the pages around the "page".
+func pageLinks(ctx context.Context, page Page, total int) string {
+	last := (total + page.Size - 1) / page.Size
+	if last < 1 {
+		last = 1
+	}
+
+	link := func(number int, rel string) string {
+		u := *ctx.Request().URL
+		query := u.Query()
+		query.Set(PageQueryParam, strconv.Itoa(number))
+		query.Set(PerPageQueryParam, strconv.Itoa(page.Size))
+		u.RawQuery = query.Encode()
+		return fmt.Sprintf("<%s>; rel=\"%s\"", u.RequestURI(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if page.Number > 1 {
+		links = append(links, link(page.Number-1, "prev"))
+	}
+
+	if page.Number < last {
+		links = append(links, link(page.Number+1, "next"))
+	}
+
+	links = append(links, link(last, "last"))
+	return strings.Join(links, ", ")
+}
+
+// Read writes the "id" resource.
+func (r *Resource) Read(ctx context.Context, id string) {
+	v, err := r.Repository.Find(id)
+	if err != nil {
+		r.fail(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	r.write(ctx, v)
+}
+
+// Create inserts the resource of the request's JSON body and writes it, as it's inserted,
+// with the 201 Created status code.
+func (r *Resource) Create(ctx context.Context) {
+	v := r.newValue()
+	if err := ctx.ReadJSON(v); err != nil {
+		r.fail(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := r.validate(ctx, v); err != nil {
+		r.fail(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	inserted, err := r.Repository.Insert(v)
+	if err != nil {
+		r.fail(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.StatusCode(http.StatusCreated)
+	r.write(ctx, inserted)
+}
+
+// Replace replaces the "id" resource with the resource of the request's JSON body and writes it.
+func (r *Resource) Replace(ctx context.Context, id string) {
+	v := r.newValue()
+	if err := ctx.ReadJSON(v); err != nil {
+		r.fail(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	r.update(ctx, id, v)
+}
+
+// Patch applies the JSON Merge Patch (RFC 7386) of the request's body to the "id" resource and writes it.
+// The request's content type should be the `ContentMergePatchJSONHeaderValue` or the JSON one.
+func (r *Resource) Patch(ctx context.Context, id string) {
+	contentType := strings.TrimSpace(strings.Split(ctx.GetHeader(context.ContentTypeHeaderKey), ";")[0])
+	if contentType != ContentMergePatchJSONHeaderValue && contentType != context.ContentJSONHeaderValue {
+		r.fail(ctx, http.StatusUnsupportedMediaType,
+			fmt.Errorf("the patch content type should be %s", ContentMergePatchJSONHeaderValue))
+		return
+	}
+
+	body, err := ctx.GetBody()
+	if err != nil {
+		r.fail(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	var patch interface{}
+	if err = json.Unmarshal(body, &patch); err != nil {
+		r.fail(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, ok := patch.(map[string]interface{}); !ok {
+		r.fail(ctx, http.StatusBadRequest, errors.New("the patch should be a JSON object"))
+		return
+	}
+
+	current, err := r.Repository.Find(id)
+	if err != nil {
+		r.fail(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	// apply the patch to the JSON document of the current value.
+	var doc interface{}
+	b, err := json.Marshal(current)
+	if err == nil {
+		err = json.Unmarshal(b, &doc)
+	}
+
+	if err == nil {
+		b, err = json.Marshal(mergePatch(doc, patch))
+	}
+
+	v := r.newValue()
+	if err == nil {
+		err = json.Unmarshal(b, v)
+	}
+
+	if err != nil {
+		r.fail(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	r.update(ctx, id, v)
+}
+
+func (r *Resource) update(ctx context.Context, id string, v interface{}) {
+	if err := r.validate(ctx, v); err != nil {
+		r.fail(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	updated, err := r.Repository.Update(id, v)
+	if err != nil {
+		r.fail(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	r.write(ctx, updated)
+}
+
+// Delete deletes the "id" resource and responds with the 204 No Content status code.
+func (r *Resource) Delete(ctx context.Context, id string) {
+	if err := r.Repository.Delete(id); err != nil {
+		r.fail(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.StatusCode(http.StatusNoContent)
+}
+
+// mergePatch returns the "target" JSON document, as it's decoded, after the "patch" is applied, see RFC 7386.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResourceController is the controller of a `Resource`, it registers the RESTful routes:
+//
+//	GET    /      -> Resource#List
+//	GET    /{id}  -> Resource#Read
+//	POST   /      -> Resource#Create
+//	PUT    /{id}  -> Resource#Replace
+//	PATCH  /{id}  -> Resource#Patch
+//	DELETE /{id}  -> Resource#Delete
+//
+// Its methods can be overridden by a controller which embeds it by value,
+// and they can still call the `Resource` ones, i.e:
+//
+//	type UserController struct {
+//		mvc.ResourceController
+//	}
+//
+//	func (c *UserController) DeleteBy(ctx iris.Context, id string) {
+//		if !isAdmin(ctx) {
+//			ctx.StatusCode(iris.StatusForbidden)
+//			return
+//		}
+//
+//		c.Resource.Delete(ctx, id)
+//	}
+//
+//	mvc.New(app.Party("/users")).Handle(&UserController{mvc.ResourceController{Resource: users}})
+//
+// See `Application.HandleResource` too.
+type ResourceController struct {
+	Resource *Resource
+}
+
+// Get serves the `Resource#List`.
+func (c *ResourceController) Get(ctx context.Context) {
+	c.Resource.List(ctx)
+}
+
+// GetBy serves the `Resource#Read`.
+func (c *ResourceController) GetBy(ctx context.Context, id string) {
+	c.Resource.Read(ctx, id)
+}
+
+// Post serves the `Resource#Create`.
+func (c *ResourceController) Post(ctx context.Context) {
+	c.Resource.Create(ctx)
+}
+
+// PutBy serves the `Resource#Replace`.
+func (c *ResourceController) PutBy(ctx context.Context, id string) {
+	c.Resource.Replace(ctx, id)
+}
+
+// PatchBy serves the `Resource#Patch`.
+func (c *ResourceController) PatchBy(ctx context.Context, id string) {
+	c.Resource.Patch(ctx, id)
+}
+
+// DeleteBy serves the `Resource#Delete`.
+func (c *ResourceController) DeleteBy(ctx context.Context, id string) {
+	c.Resource.Delete(ctx, id)
+}
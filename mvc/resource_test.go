@@ -0,0 +1,262 @@
+package mvc_test
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/radiantrfid/iris"
+	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/httptest"
+
+	. "github.com/radiantrfid/iris/mvc"
+)
+
+type testBook struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Year   int    `json:"year,omitempty"`
+}
+
+func (b *testBook) Validate() error {
+	if b.Title == "" {
+		return errors.New("title is required")
+	}
+
+	return nil
+}
+
+type testBookRepository struct {
+	mu    sync.Mutex
+	books map[string]testBook
+	next  int
+}
+
+func newTestBookRepository(books ...testBook) *testBookRepository {
+	r := &testBookRepository{books: make(map[string]testBook)}
+	for _, b := range books {
+		r.Insert(&b)
+	}
+
+	return r
+}
+
+func (r *testBookRepository) Find(id string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.books[id]
+	if !ok {
+		return nil, ErrResourceNotFound
+	}
+
+	return b, nil
+}
+
+func (r *testBookRepository) List(filter Filter, page Page) (interface{}, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var books []testBook
+	for _, b := range r.books {
+		if author, ok := filter["author"]; ok && b.Author != author {
+			continue
+		}
+
+		books = append(books, b)
+	}
+
+	sort.Slice(books, func(i, j int) bool {
+		for _, s := range page.Sort {
+			if s.Field == "title" && books[i].Title != books[j].Title {
+				return (books[i].Title < books[j].Title) != s.Desc
+			}
+		}
+
+		return books[i].ID < books[j].ID
+	})
+
+	total := len(books)
+	start, end := page.Offset(), page.Offset()+page.Size
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+
+	return books[start:end], total, nil
+}
+
+func (r *testBookRepository) Insert(v interface{}) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := *v.(*testBook)
+	r.next++
+	b.ID = strconv.Itoa(r.next)
+	r.books[b.ID] = b
+	return b, nil
+}
+
+func (r *testBookRepository) Update(id string, v interface{}) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.books[id]; !ok {
+		return nil, ErrResourceNotFound
+	}
+
+	b := *v.(*testBook)
+	b.ID = id
+	r.books[id] = b
+	return b, nil
+}
+
+func (r *testBookRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.books[id]; !ok {
+		return ErrResourceNotFound
+	}
+
+	delete(r.books, id)
+	return nil
+}
+
+func newTestBookResource() *Resource {
+	books := NewResource(newTestBookRepository(
+		testBook{Title: "Dune", Author: "Herbert"},
+		testBook{Title: "Children of Dune", Author: "Herbert"},
+		testBook{Title: "Foundation", Author: "Asimov"},
+	), testBook{})
+	books.Filters = []string{"author"}
+	books.Sorts = []string{"title"}
+	return books
+}
+
+func TestResourceController(t *testing.T) {
+	app := iris.New()
+	New(app.Party("/books")).HandleResource(newTestBookResource())
+
+	e := httptest.New(t, app)
+
+	// list, filter, sort and paginate.
+	r := e.GET("/books").WithQuery("author", "Herbert").WithQuery("sort", "title").WithQuery("per_page", 1).
+		Expect().Status(iris.StatusOK)
+	r.Header("X-Total-Count").Equal("2")
+	r.Header("Link").Equal(`</books?author=Herbert&page=1&per_page=1&sort=title>; rel="first", ` +
+		`</books?author=Herbert&page=2&per_page=1&sort=title>; rel="next", ` +
+		`</books?author=Herbert&page=2&per_page=1&sort=title>; rel="last"`)
+	r.JSON().Array().Equal([]iris.Map{{"id": "2", "title": "Children of Dune", "author": "Herbert"}})
+
+	e.GET("/books").WithQuery("page", 2).WithQuery("per_page", 2).WithQuery("sort", "-title").
+		Expect().Status(iris.StatusOK).JSON().Array().Equal([]iris.Map{{"id": "2", "title": "Children of Dune", "author": "Herbert"}})
+	e.GET("/books").WithQuery("page", 5).Expect().Status(iris.StatusOK).JSON().Array().Empty()
+	e.GET("/books").WithQuery("sort", "author").Expect().Status(iris.StatusBadRequest).
+		ContentType(context.ContentJSONProblemHeaderValue, "utf-8").Body().Contains("can not be sorted by 'author'")
+	e.GET("/books").WithQuery("page", "first").Expect().Status(iris.StatusBadRequest)
+
+	// read.
+	e.GET("/books/3").Expect().Status(iris.StatusOK).
+		JSON().Equal(iris.Map{"id": "3", "title": "Foundation", "author": "Asimov"})
+	e.GET("/books/42").Expect().Status(iris.StatusNotFound).
+		ContentType(context.ContentJSONProblemHeaderValue, "utf-8").Body().Contains(`"detail": "resource not found"`)
+
+	// create and validate.
+	e.POST("/books").WithJSON(iris.Map{"title": "Hyperion", "author": "Simmons"}).Expect().Status(iris.StatusCreated).
+		JSON().Equal(iris.Map{"id": "4", "title": "Hyperion", "author": "Simmons"})
+	e.POST("/books").WithJSON(iris.Map{"author": "Simmons"}).Expect().Status(iris.StatusUnprocessableEntity).
+		Body().Contains(`"detail": "title is required"`)
+	e.POST("/books").WithText("{").Expect().Status(iris.StatusBadRequest)
+
+	// replace.
+	e.PUT("/books/4").WithJSON(iris.Map{"title": "Hyperion", "author": "Dan Simmons", "year": 1989}).Expect().Status(iris.StatusOK).
+		JSON().Equal(iris.Map{"id": "4", "title": "Hyperion", "author": "Dan Simmons", "year": 1989})
+
+	// patch, the null removes the field.
+	e.PATCH("/books/4").WithBytes([]byte(`{"title": "The Fall of Hyperion", "year": null}`)).
+		WithHeader("Content-Type", ContentMergePatchJSONHeaderValue).
+		Expect().Status(iris.StatusOK).
+		JSON().Equal(iris.Map{"id": "4", "title": "The Fall of Hyperion", "author": "Dan Simmons"})
+	e.PATCH("/books/4").WithBytes([]byte(`{"title": ""}`)).
+		WithHeader("Content-Type", ContentMergePatchJSONHeaderValue).
+		Expect().Status(iris.StatusUnprocessableEntity)
+	e.PATCH("/books/4").WithText("title=x").Expect().Status(iris.StatusUnsupportedMediaType)
+	e.PATCH("/books/42").WithJSON(iris.Map{"title": "x"}).Expect().Status(iris.StatusNotFound)
+
+	// delete.
+	e.DELETE("/books/4").Expect().Status(iris.StatusNoContent)
+	e.DELETE("/books/4").Expect().Status(iris.StatusNotFound)
+}
+
+var errTestReadOnly = errors.New("books are read-only")
+
+type testReadOnlyBookController struct {
+	ResourceController
+}
+
+func (c *testReadOnlyBookController) DeleteBy(ctx context.Context, id string) error {
+	return errTestReadOnly
+}
+
+func TestResourceControllerOverride(t *testing.T) {
+	app := iris.New()
+	app.ErrorMapper().Map(errTestReadOnly, iris.StatusMethodNotAllowed)
+	New(app.Party("/books")).Handle(&testReadOnlyBookController{ResourceController{Resource: newTestBookResource()}})
+
+	e := httptest.New(t, app)
+	e.DELETE("/books/1").Expect().Status(iris.StatusMethodNotAllowed).
+		Body().Contains(`"detail": "books are read-only"`)
+
+	var book testBook
+	if err := json.Unmarshal([]byte(e.GET("/books/1").Expect().Status(iris.StatusOK).Body().Raw()), &book); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(book.Title, "Dune") {
+		t.Fatalf("expected the book to not be deleted but got: %#v", book)
+	}
+}
+
+type (
+	testResourceErr struct {
+		err error
+	}
+
+	testBrokenBookRepository struct {
+		*testBookRepository
+	}
+)
+
+func (e testResourceErr) Error() string { return "book 42: " + e.err.Error() }
+func (e testResourceErr) Unwrap() error { return e.err }
+
+func (r testBrokenBookRepository) Find(id string) (interface{}, error) {
+	if id == "42" {
+		return nil, testResourceErr{ErrResourceNotFound}
+	}
+
+	return nil, errors.New("pq: relation \"books\" does not exist")
+}
+
+func TestResourceControllerErrors(t *testing.T) {
+	app := iris.New()
+	New(app.Party("/books")).HandleResource(NewResource(testBrokenBookRepository{newTestBookRepository()}, testBook{}))
+
+	e := httptest.New(t, app)
+	// the wrapped not found error.
+	e.GET("/books/42").Expect().Status(iris.StatusNotFound).
+		Body().Contains(`"detail": "book 42: resource not found"`)
+	// the internal errors are not written to the client.
+	body := e.GET("/books/1").Expect().Status(iris.StatusInternalServerError).
+		ContentType(context.ContentJSONProblemHeaderValue, "utf-8").Body()
+	body.Contains(`"detail": "Internal Server Error"`)
+	body.NotContains("relation")
+}
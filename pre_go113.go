@@ -0,0 +1,9 @@
+// +build !go1.13
+
+package iris
+
+import "net/http"
+
+// registerProxyConnContext does nothing, the `http.Server.ConnContext` is available on go1.13+ only,
+// the `netutil.GetProxyInfo` always reports false then.
+func registerProxyConnContext(srv *http.Server) {}
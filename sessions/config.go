@@ -115,6 +115,35 @@ type (
 		//
 		// Defaults to false.
 		DisableSubdomainPersistence bool
+
+		// RegenerateEvery if greater than zero then the session id is regenerated
+		// by the `Sessions.Start` when it's older than this duration, its values are kept.
+		// It limits the time that a stolen or a fixated session id can be used.
+		// Use the `Sessions.Regenerate` on privilege changes, i.e on login, as well.
+		//
+		// Note that the age of a session id is kept in memory,
+		// when a session is restored by a `Database` after a restart its age starts from zero.
+		//
+		// Defaults to zero, the session id is not regenerated automatically.
+		RegenerateEvery time.Duration
+
+		// IdleTimeout if greater than zero enables the sliding expiration,
+		// the lifetime of a session is extended to this duration by each `Sessions.Start`,
+		// so the session expires after this duration of inactivity.
+		// It's used instead of the `Expires` for the lifetime of the sessions,
+		// an `Expires` of -1 still removes the session cookie when the browser closes.
+		//
+		// Defaults to zero, the lifetime is extended only by the `ShiftExpiration` and `UpdateExpiration`.
+		IdleTimeout time.Duration
+
+		// MaxLifetime if greater than zero is the absolute lifetime of a session since its creation,
+		// it's never extended by the `IdleTimeout`, the `Sessions.Remember` or the `UpdateExpiration`.
+		//
+		// Note that like the age of a session id (see `RegenerateEvery`), the creation time is kept in memory,
+		// unless the session is bound to a user and the `Database` implements the `UserIndex`.
+		//
+		// Defaults to zero, there is no maximum lifetime.
+		MaxLifetime time.Duration
 	}
 )
 
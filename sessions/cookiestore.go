@@ -0,0 +1,615 @@
+package sessions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/core/errors"
+	"github.com/radiantrfid/iris/core/memstore"
+
+	"github.com/kataras/golog"
+)
+
+var (
+	// DefaultCookieChunkSize is the default maximum length of each of the session cookies' values,
+	// browsers limit the size of a single cookie to about 4KB.
+	DefaultCookieChunkSize = 3800
+	// DefaultCookieMaxChunks is the default maximum number of cookies that a session can be split into.
+	DefaultCookieMaxChunks = 3
+)
+
+var (
+	// ErrCookieStoreKey is returned by the `NewCookieStore` and `CookieStore.Rotate`
+	// when a key is not a valid AES-128, AES-192 or AES-256 key.
+	ErrCookieStoreKey = errors.New("cookie store requires keys of 16, 24 or 32 bytes but got a key of %d bytes")
+	// ErrCookieTooLarge is passed to the `CookieStore.OnError` when an encoded session
+	// exceeds the `CookieStore.ChunkSize` * `CookieStore.MaxChunks` limit, the session is not saved then.
+	ErrCookieTooLarge = errors.New("session cookie of %d bytes exceeds the limit of %d bytes")
+	// ErrCookieInvalid is passed to the `CookieStore.OnError` when the client's session cookie
+	// can not be decrypted or it is malformed, a new session is started then.
+	ErrCookieInvalid = errors.New("invalid session cookie")
+)
+
+// CookieStore is the stateless, cookie-only, backend of the sessions.
+// The whole session, its values, flash messages and expiration,
+// is serialized, encrypted and authenticated with AES-GCM and it is sent to the client
+// as one or more cookies, nothing is kept on the server.
+// Register it through the `Sessions.UseCookieStore`.
+//
+// Any change of a session is written to the response's cookies immediately,
+// therefore the session should be modified before the response body is written.
+//
+// Note that a stateless session can not be removed by the server before its expiration,
+// the `Sessions.DestroyByID` and `Sessions.DestroyAll` have no effect,
+// use the `Rotate` without the old keys to invalidate all of them instead.
+type CookieStore struct {
+	// ChunkSize is the maximum length of each cookie's value.
+	//
+	// Defaults to the `DefaultCookieChunkSize`.
+	ChunkSize int
+	// MaxChunks is the maximum number of cookies of a session,
+	// the changes of a session which does not fit are not saved
+	// and the `OnError` is called with an `ErrCookieTooLarge`.
+	//
+	// Defaults to the `DefaultCookieMaxChunks`.
+	MaxChunks int
+	// Transcoder serializes the session values,
+	// note that the JSON one decodes the numbers as float64,
+	// the `Session.GetInt` and the rest of the getters handle that.
+	//
+	// Defaults to the `DefaultTranscoder`.
+	Transcoder Transcoder
+	// OnError is called when a session can not be saved or
+	// when the client's session cookie is invalid.
+	//
+	// Defaults to a debug log.
+	OnError func(ctx context.Context, err error)
+
+	mu    sync.RWMutex
+	aeads []cipher.AEAD
+}
+
+// NewCookieStore returns a new `CookieStore` which encrypts the sessions with the first of the "keys",
+// the rest of them are used to decrypt sessions that were encrypted before a key rotation.
+// Each key should be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+func NewCookieStore(keys ...[]byte) (*CookieStore, error) {
+	st := &CookieStore{
+		ChunkSize:  DefaultCookieChunkSize,
+		MaxChunks:  DefaultCookieMaxChunks,
+		Transcoder: DefaultTranscoder,
+	}
+
+	if err := st.Rotate(keys...); err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// Rotate replaces the keys of the store, the first one encrypts the sessions
+// and the rest of them are only used to decrypt the existing ones.
+// A session which is decrypted by an old key is re-encrypted with the first one
+// on the same request, so the old keys can be dropped after the sessions' lifetime.
+func (st *CookieStore) Rotate(keys ...[]byte) error {
+	if len(keys) == 0 {
+		return ErrCookieStoreKey.Format(0)
+	}
+
+	aeads := make([]cipher.AEAD, 0, len(keys))
+	for _, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return ErrCookieStoreKey.Format(len(key))
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return err
+		}
+
+		aeads = append(aeads, aead)
+	}
+
+	st.mu.Lock()
+	st.aeads = aeads
+	st.mu.Unlock()
+	return nil
+}
+
+// encrypt seals the "plaintext" with the current key,
+// the cookie name is authenticated too so a value can not be moved to another cookie.
+func (st *CookieStore) encrypt(name string, plaintext []byte) (string, error) {
+	st.mu.RLock()
+	aead := st.aeads[0]
+	st.mu.RUnlock()
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	b := aead.Seal(nonce, nonce, plaintext, []byte(name))
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decrypt opens the "value" with any of the keys,
+// rotated reports whether it was encrypted by an old key.
+func (st *CookieStore) decrypt(name, value string) (plaintext []byte, rotated bool, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, false, ErrCookieInvalid
+	}
+
+	st.mu.RLock()
+	aeads := st.aeads
+	st.mu.RUnlock()
+
+	for i, aead := range aeads {
+		n := aead.NonceSize()
+		if len(b) < n+aead.Overhead() {
+			break
+		}
+
+		if plaintext, err = aead.Open(nil, b[:n], b[n:], []byte(name)); err == nil {
+			return plaintext, i > 0, nil
+		}
+	}
+
+	return nil, false, ErrCookieInvalid
+}
+
+func (st *CookieStore) fail(ctx context.Context, err error) {
+	if st.OnError != nil {
+		st.OnError(ctx, err)
+		return
+	}
+
+	golog.Debugf("sessions: %v", err)
+}
+
+type (
+	// cookiePayload is the serialized form of a stateless session.
+	cookiePayload struct {
+		ID             string        `json:"id"`
+		Expires        time.Time     `json:"exp"`
+		BrowserSession bool          `json:"bs,omitempty"`
+		Regenerated    time.Time     `json:"rat"`
+		Created        time.Time     `json:"cat"`
+		User           string        `json:"u,omitempty"`
+		Remember       time.Duration `json:"rem,omitempty"`
+		Values         []cookieEntry `json:"v,omitempty"`
+		Flashes        []cookieFlash `json:"f,omitempty"`
+	}
+
+	cookieEntry struct {
+		Key       string      `json:"k"`
+		Value     interface{} `json:"v"`
+		Immutable bool        `json:"i,omitempty"`
+	}
+
+	cookieFlash struct {
+		Key    string      `json:"k"`
+		Value  interface{} `json:"v"`
+		Remove bool        `json:"r,omitempty"`
+	}
+)
+
+// cookieSession links a stateless session with its request.
+type cookieSession struct {
+	manager *Sessions
+	ctx     context.Context
+	options []context.CookieOption
+	// chunks is the number of the session cookies that the client has.
+	chunks int
+	// browserSession is true if the cookies should be removed when the browser closes.
+	browserSession bool
+}
+
+const contextStatelessDestroyedKey = "_iris_session_destroyed"
+
+func chunkName(name string, i int) string {
+	if i == 0 {
+		return name
+	}
+
+	return name + "." + strconv.Itoa(i)
+}
+
+// UseCookieStore makes the sessions stateless, the sessions are stored to the clients' cookies
+// by the "store" instead of a server-side `Database`, see `CookieStore` for more.
+//
+// The `Config.Encode` and `Config.Decode` are not used
+// because the session cookies are already encrypted and authenticated.
+func (s *Sessions) UseCookieStore(store *CookieStore) {
+	s.cookieStore = store
+}
+
+// startStateless returns the session of the request, decoded by its cookies.
+// A new session is started if they are missing, invalid or expired
+// and it's saved if "save" is true.
+func (s *Sessions) startStateless(ctx context.Context, save bool, cookieOptions ...context.CookieOption) *Session {
+	if sess := Get(ctx); sess != nil && sess.cookie != nil {
+		return sess
+	}
+
+	c := &cookieSession{
+		manager: s,
+		ctx:     ctx,
+		options: cookieOptions,
+	}
+
+	var payload *cookiePayload
+	rotated := false
+	if destroyed, _ := ctx.Values().GetBool(contextStatelessDestroyedKey); !destroyed {
+		payload, rotated = c.load()
+	}
+
+	if payload != nil {
+		now := time.Now()
+		if (!payload.Expires.IsZero() && payload.Expires.Before(now)) ||
+			(s.config.MaxLifetime > 0 && now.Sub(payload.Created) >= s.config.MaxLifetime) {
+			s.provider.fire(EventExpire, payload.ID)
+			payload, rotated = nil, false
+		}
+	}
+
+	isNew := payload == nil
+	if isNew {
+		payload = &cookiePayload{
+			ID:             s.config.SessionIDGenerator(ctx),
+			BrowserSession: s.config.Expires < 0,
+			Regenerated:    time.Now(),
+			Created:        time.Now(),
+		}
+
+		if expires := s.initialExpires(); expires > 0 {
+			payload.Expires = time.Now().Add(expires)
+		}
+	}
+
+	c.browserSession = payload.BrowserSession
+
+	db := newCookieDB()
+	for _, entry := range payload.Values {
+		db.Set(payload.ID, LifeTime{}, entry.Key, entry.Value, entry.Immutable)
+	}
+
+	sess := &Session{
+		sid:           payload.ID,
+		isNew:         isNew,
+		flashes:       make(map[string]*flashMessage, len(payload.Flashes)),
+		Lifetime:      LifeTime{Time: payload.Expires},
+		regeneratedAt: payload.Regenerated,
+		info:          SessionInfo{ID: payload.ID, User: payload.User, CreatedAt: payload.Created, Remember: payload.Remember},
+		cookie:        c,
+	}
+
+	for _, flash := range payload.Flashes {
+		// the flash messages GC of the stateless sessions.
+		if !flash.Remove {
+			sess.flashes[flash.Key] = &flashMessage{value: flash.Value}
+		}
+	}
+
+	sess.provider = &provider{
+		sessions:         map[string]*Session{sess.sid: sess},
+		db:               db,
+		destroyListeners: s.provider.destroyListeners,
+		listeners:        s.provider.listeners,
+	}
+
+	ctx.Values().Set(contextSessionKey, sess)
+
+	if isNew {
+		s.fireStart(ctx, EventCreate, sess.sid)
+	} else {
+		s.fireStart(ctx, EventRead, sess.sid)
+	}
+
+	if (isNew && save) || rotated {
+		c.save(sess)
+	}
+
+	return sess
+}
+
+// load decodes the client's session cookies, it returns nil if they are missing or invalid.
+// The rotated is true if they were encrypted by an old key.
+func (c *cookieSession) load() (payload *cookiePayload, rotated bool) {
+	var (
+		name  = c.manager.config.Cookie
+		store = c.manager.cookieStore
+	)
+
+	value := GetCookie(c.ctx, name)
+	if value == "" {
+		return nil, false
+	}
+
+	dotIdx := strings.IndexByte(value, '.')
+	if dotIdx <= 0 {
+		store.fail(c.ctx, ErrCookieInvalid)
+		return nil, false
+	}
+
+	n, err := strconv.Atoi(value[:dotIdx])
+	if err != nil || n <= 0 || n > store.MaxChunks {
+		store.fail(c.ctx, ErrCookieInvalid)
+		return nil, false
+	}
+
+	c.chunks = n
+
+	var b strings.Builder
+	b.WriteString(value[dotIdx+1:])
+	for i := 1; i < n; i++ {
+		chunk := GetCookie(c.ctx, chunkName(name, i))
+		if chunk == "" {
+			store.fail(c.ctx, ErrCookieInvalid)
+			return nil, false
+		}
+		b.WriteString(chunk)
+	}
+
+	plaintext, rotated, err := store.decrypt(name, b.String())
+	if err != nil {
+		store.fail(c.ctx, err)
+		return nil, false
+	}
+
+	payload = new(cookiePayload)
+	if err = store.Transcoder.Unmarshal(plaintext, payload); err != nil || payload.ID == "" {
+		store.fail(c.ctx, ErrCookieInvalid)
+		return nil, false
+	}
+
+	return payload, rotated
+}
+
+// save encodes the "sess" and writes it to the response's cookies,
+// if it fails the client keeps its previous session cookies.
+func (c *cookieSession) save(sess *Session) {
+	var (
+		name  = c.manager.config.Cookie
+		store = c.manager.cookieStore
+	)
+
+	sess.mu.RLock()
+	payload := cookiePayload{
+		ID:             sess.sid,
+		Expires:        sess.Lifetime.Time,
+		BrowserSession: c.browserSession,
+		Regenerated:    sess.regeneratedAt,
+		Created:        sess.info.CreatedAt,
+		User:           sess.info.User,
+		Remember:       sess.info.Remember,
+	}
+	sess.mu.RUnlock()
+
+	db := sess.provider.db.(*cookieDB)
+	db.mu.RLock()
+	db.values.Visit(func(key string, value interface{}) {
+		_, immutable := db.immutable[key]
+		payload.Values = append(payload.Values, cookieEntry{Key: key, Value: value, Immutable: immutable})
+	})
+	db.mu.RUnlock()
+
+	sess.mu.RLock()
+	for key, flash := range sess.flashes {
+		payload.Flashes = append(payload.Flashes, cookieFlash{Key: key, Value: flash.value, Remove: flash.shouldRemove})
+	}
+	sess.mu.RUnlock()
+
+	plaintext, err := store.Transcoder.Marshal(payload)
+	if err != nil {
+		store.fail(c.ctx, err)
+		return
+	}
+
+	value, err := store.encrypt(name, plaintext)
+	if err != nil {
+		store.fail(c.ctx, err)
+		return
+	}
+
+	chunkSize := store.ChunkSize
+	n := (len(value) + chunkSize - 1) / chunkSize
+	if n > store.MaxChunks {
+		store.fail(c.ctx, ErrCookieTooLarge.Format(len(value), chunkSize*store.MaxChunks))
+		return
+	}
+
+	expires := time.Duration(0) // unlimited.
+	if c.browserSession {
+		expires = -1
+	} else if !sess.Lifetime.IsZero() {
+		if expires = sess.Lifetime.DurationUntilExpiration(); expires <= 0 {
+			c.remove()
+			return
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(value) {
+			end = len(value)
+		}
+
+		chunk := value[i*chunkSize : end]
+		if i == 0 {
+			chunk = strconv.Itoa(n) + "." + chunk
+		}
+
+		c.setCookie(c.manager.newCookie(c.ctx, chunkName(name, i), chunk, expires, c.options))
+	}
+
+	c.removeChunks(n)
+	c.chunks = n
+}
+
+// remove removes all the session cookies of the client.
+func (c *cookieSession) remove() {
+	c.removeChunks(0)
+	c.chunks = 0
+
+	if c.manager.config.AllowReclaim {
+		c.ctx.Request().Header.Set("Cookie", "")
+	}
+}
+
+// removeChunks removes the client's session cookies starting from the "from" index.
+func (c *cookieSession) removeChunks(from int) {
+	for i := from; i < c.chunks; i++ {
+		cookie := c.manager.newCookie(c.ctx, chunkName(c.manager.config.Cookie, i), "", -1, c.options)
+		cookie.Expires = CookieExpireDelete
+		// MaxAge<0 means delete cookie now, equivalently 'Max-Age: 0'
+		cookie.MaxAge = -1
+		c.setCookie(cookie)
+	}
+}
+
+// setCookie adds the "cookie" to the response,
+// it replaces any previous cookie of the same name which is written by the same request.
+func (c *cookieSession) setCookie(cookie *http.Cookie) {
+	header := c.ctx.ResponseWriter().Header()
+	if existing := header["Set-Cookie"]; len(existing) > 0 {
+		prefix := cookie.Name + "="
+		values := make([]string, 0, len(existing))
+		for _, v := range existing {
+			if !strings.HasPrefix(v, prefix) {
+				values = append(values, v)
+			}
+		}
+		header["Set-Cookie"] = values
+	}
+
+	c.ctx.SetCookie(cookie)
+}
+
+// destroy removes the client's session cookies and
+// prevents the rest of the request handlers from reading them.
+func (c *cookieSession) destroy() {
+	c.remove()
+	c.ctx.Values().Remove(contextSessionKey)
+	c.ctx.Values().Set(contextStatelessDestroyedKey, true)
+}
+
+func (s *Sessions) updateStatelessExpiration(ctx context.Context, expires time.Duration, cookieOptions ...context.CookieOption) error {
+	sess := Get(ctx)
+	if sess == nil || sess.cookie == nil {
+		if GetCookie(ctx, s.config.Cookie) == "" {
+			return ErrNotFound
+		}
+
+		sess = s.startStateless(ctx, false, cookieOptions...)
+	}
+
+	if len(cookieOptions) > 0 {
+		sess.cookie.options = cookieOptions
+	}
+
+	sess.cookie.browserSession = expires < 0
+	sess.Lifetime = LifeTime{}
+	if expires >= 0 {
+		// the lifetime can not exceed the maximum one.
+		if expires = s.limitExpires(sess, expires); expires > 0 {
+			sess.Lifetime.Time = time.Now().Add(expires)
+		}
+	}
+
+	sess.cookie.save(sess)
+	return nil
+}
+
+func (s *Sessions) destroyStateless(ctx context.Context) {
+	sess := Get(ctx)
+	if sess == nil || sess.cookie == nil {
+		if GetCookie(ctx, s.config.Cookie) == "" { // nothing to destroy
+			return
+		}
+
+		sess = s.startStateless(ctx, false)
+	}
+
+	sess.Destroy()
+}
+
+// cookieDB is the `Database` of a single stateless session,
+// unlike the memory one it keeps which of the values are immutable in order to serialize them.
+type cookieDB struct {
+	values    memstore.Store
+	immutable map[string]struct{}
+	mu        sync.RWMutex
+}
+
+var _ Database = (*cookieDB)(nil)
+
+func newCookieDB() *cookieDB { return &cookieDB{immutable: make(map[string]struct{})} }
+
+func (db *cookieDB) Acquire(string, time.Duration) LifeTime { return LifeTime{} }
+
+func (db *cookieDB) OnUpdateExpiration(string, time.Duration) error { return nil }
+
+func (db *cookieDB) Set(sid string, lifetime LifeTime, key string, value interface{}, immutable bool) {
+	db.mu.Lock()
+	if _, wasImmutable := db.immutable[key]; !wasImmutable || immutable {
+		// an immutable entry can be only changed by another `SetImmutable`.
+		db.values.Save(key, value, immutable)
+		if immutable {
+			db.immutable[key] = struct{}{}
+		}
+	}
+	db.mu.Unlock()
+}
+
+func (db *cookieDB) Get(sid string, key string) interface{} {
+	db.mu.RLock()
+	v := db.values.Get(key)
+	db.mu.RUnlock()
+	return v
+}
+
+func (db *cookieDB) Visit(sid string, cb func(key string, value interface{})) {
+	// visit a copy, the "cb" may modify the session.
+	db.mu.RLock()
+	values := make(memstore.Store, len(db.values))
+	copy(values, db.values)
+	db.mu.RUnlock()
+
+	values.Visit(cb)
+}
+
+func (db *cookieDB) Len(sid string) int {
+	db.mu.RLock()
+	n := db.values.Len()
+	db.mu.RUnlock()
+	return n
+}
+
+func (db *cookieDB) Delete(sid string, key string) (deleted bool) {
+	db.mu.Lock()
+	deleted = db.values.Remove(key)
+	delete(db.immutable, key)
+	db.mu.Unlock()
+	return
+}
+
+func (db *cookieDB) Clear(sid string) {
+	db.mu.Lock()
+	db.values.Reset()
+	db.immutable = make(map[string]struct{})
+	db.mu.Unlock()
+}
+
+func (db *cookieDB) Release(sid string) {
+	db.Clear(sid)
+}
@@ -0,0 +1,245 @@
+package sessions_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/radiantrfid/iris"
+	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/httptest"
+	"github.com/radiantrfid/iris/sessions"
+)
+
+var (
+	testKey    = bytes.Repeat([]byte("k"), 32)
+	testNewKey = bytes.Repeat([]byte("n"), 32)
+)
+
+func newStatelessSessions(t *testing.T, keys ...[]byte) (*sessions.Sessions, *sessions.CookieStore) {
+	store, err := sessions.NewCookieStore(keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess := sessions.New(sessions.Config{Cookie: "mycustomsessionid"})
+	sess.UseCookieStore(store)
+	return sess, store
+}
+
+func TestCookieStore(t *testing.T) {
+	sess, _ := newStatelessSessions(t, testKey)
+	testSessions(t, sess, iris.New())
+}
+
+func TestCookieStoreFlashMessages(t *testing.T) {
+	sess, _ := newStatelessSessions(t, testKey)
+	testFlashMessages(t, sess, iris.New())
+}
+
+func TestCookieStoreKeys(t *testing.T) {
+	if _, err := sessions.NewCookieStore([]byte("short")); !sessions.ErrCookieStoreKey.Equal(err) {
+		t.Fatalf("expected an ErrCookieStoreKey but got: %v", err)
+	}
+
+	if _, err := sessions.NewCookieStore(); err == nil {
+		t.Fatalf("expected an error when no keys are given")
+	}
+}
+
+func TestCookieStoreValues(t *testing.T) {
+	app := iris.New()
+	sess, store := newStatelessSessions(t, testKey)
+	app.Use(sess.Handler())
+
+	app.Get("/increment", func(ctx context.Context) {
+		ctx.Writef("%d", sessions.Get(ctx).Increment("counter", 1))
+	})
+
+	app.Get("/immutable", func(ctx context.Context) {
+		s := sessions.Get(ctx)
+		s.SetImmutable("role", "admin")
+		s.Set("role", "guest") // should be ignored.
+	})
+
+	app.Get("/get", func(ctx context.Context) {
+		ctx.WriteString(sessions.Get(ctx).GetString("role"))
+	})
+
+	app.Get("/large", func(ctx context.Context) {
+		sessions.Get(ctx).Set("large", strings.Repeat("a", ctx.URLParamIntDefault("n", 0)))
+	})
+
+	app.Get("/large/len", func(ctx context.Context) {
+		ctx.Writef("%d", len(sessions.Get(ctx).GetString("large")))
+	})
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+
+	e.GET("/increment").Expect().Status(iris.StatusOK).Body().Equal("1")
+	e.GET("/increment").Expect().Status(iris.StatusOK).Body().Equal("2")
+	e.GET("/increment").Expect().Status(iris.StatusOK).Body().Equal("3")
+
+	e.GET("/immutable").Expect().Status(iris.StatusOK)
+	e.GET("/get").Expect().Status(iris.StatusOK).Body().Equal("admin")
+
+	// split into more than one cookie.
+	r := e.GET("/large").WithQuery("n", 5000).Expect().Status(iris.StatusOK)
+	r.Cookie("mycustomsessionid.1").Value().NotEmpty()
+	e.GET("/large/len").Expect().Status(iris.StatusOK).Body().Equal("5000")
+	e.GET("/increment").Expect().Status(iris.StatusOK).Body().Equal("4")
+
+	// fits in one cookie again, the rest are removed.
+	for _, cookie := range e.GET("/large").WithQuery("n", 10).Expect().Status(iris.StatusOK).Raw().Cookies() {
+		if cookie.Name == "mycustomsessionid.1" && cookie.MaxAge >= 0 {
+			t.Fatalf("expected the second cookie to be removed but got: %v", cookie)
+		}
+	}
+	e.GET("/large/len").Expect().Status(iris.StatusOK).Body().Equal("10")
+
+	// exceeds the limit, the previous session is kept.
+	var failure error
+	store.OnError = func(ctx context.Context, err error) {
+		failure = err
+	}
+
+	e.GET("/large").WithQuery("n", 20000).Expect().Status(iris.StatusOK)
+	if !sessions.ErrCookieTooLarge.Equal(failure) {
+		t.Fatalf("expected an ErrCookieTooLarge but got: %v", failure)
+	}
+	e.GET("/large/len").Expect().Status(iris.StatusOK).Body().Equal("10")
+	e.GET("/get").Expect().Status(iris.StatusOK).Body().Equal("admin")
+}
+
+func TestCookieStoreRotate(t *testing.T) {
+	app := iris.New()
+	sess, store := newStatelessSessions(t, testKey)
+
+	app.Get("/set", func(ctx context.Context) {
+		sess.Start(ctx).Set("name", "iris")
+	})
+
+	app.Get("/get", func(ctx context.Context) {
+		ctx.WriteString(sess.Start(ctx).GetString("name"))
+	})
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	e.GET("/set").Expect().Status(iris.StatusOK)
+
+	// decrypted by the old key and re-encrypted by the new one.
+	if err := store.Rotate(testNewKey, testKey); err != nil {
+		t.Fatal(err)
+	}
+	r := e.GET("/get").Expect().Status(iris.StatusOK)
+	r.Body().Equal("iris")
+	r.Cookie("mycustomsessionid").Value().NotEmpty()
+
+	// the old key is not required anymore.
+	if err := store.Rotate(testNewKey); err != nil {
+		t.Fatal(err)
+	}
+	e.GET("/get").Expect().Status(iris.StatusOK).Body().Equal("iris")
+
+	// the session can't be decrypted, a new one is started.
+	if err := store.Rotate(testKey); err != nil {
+		t.Fatal(err)
+	}
+	e.GET("/get").Expect().Status(iris.StatusOK).Body().Empty()
+
+	// tampered cookies start a new session too.
+	e.GET("/set").Expect().Status(iris.StatusOK)
+	e.GET("/get").WithCookie("mycustomsessionid", "1.invalid").Expect().Status(iris.StatusOK).Body().Empty()
+	e.GET("/get").WithCookie("mycustomsessionid", "2.abc").Expect().Status(iris.StatusOK).Body().Empty()
+}
+
+func TestCookieStoreExpiration(t *testing.T) {
+	app := iris.New()
+	sess, _ := newStatelessSessions(t, testKey)
+
+	app.Get("/set", func(ctx context.Context) {
+		sess.Start(ctx).Set("name", "iris")
+	})
+
+	app.Get("/browser", func(ctx context.Context) {
+		if err := sess.UpdateExpiration(ctx, -1); err != nil {
+			ctx.StatusCode(iris.StatusNotFound)
+		}
+	})
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	e.GET("/browser").Expect().Status(iris.StatusNotFound)
+
+	cookies := e.GET("/set").Expect().Status(iris.StatusOK).Raw().Cookies()
+	if len(cookies) != 1 || cookies[0].MaxAge <= 0 {
+		t.Fatalf("expected a persistent session cookie but got: %v", cookies)
+	}
+
+	cookies = e.GET("/browser").Expect().Status(iris.StatusOK).Raw().Cookies()
+	if len(cookies) != 1 || cookies[0].MaxAge != 0 || !cookies[0].Expires.IsZero() {
+		t.Fatalf("expected a session cookie which is removed when the browser closes but got: %v", cookies)
+	}
+
+	// keeps being a browser session cookie on the next saves.
+	cookies = e.GET("/set").Expect().Status(iris.StatusOK).Raw().Cookies()
+	if len(cookies) != 1 || cookies[0].MaxAge != 0 || cookies[0].Name != "mycustomsessionid" {
+		t.Fatalf("expected a session cookie which is removed when the browser closes but got: %v", cookies)
+	}
+}
+
+func TestCookieStoreRegenerate(t *testing.T) {
+	sess, _ := newStatelessSessions(t, testKey)
+	testRegenerate(t, sess, iris.New(), false)
+}
+
+func TestCookieStoreRegenerateEvery(t *testing.T) {
+	store, err := sessions.NewCookieStore(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess := sessions.New(sessions.Config{Cookie: "mycustomsessionid", RegenerateEvery: 50 * time.Millisecond})
+	sess.UseCookieStore(store)
+	testRegenerateEvery(t, sess, iris.New())
+}
+
+func TestCookieStoreUsers(t *testing.T) {
+	app := iris.New()
+	sess, _ := newStatelessSessions(t, testKey)
+
+	app.Get("/login", func(ctx context.Context) {
+		sess.Start(ctx).SetUser("kataras")
+	})
+
+	app.Get("/user", func(ctx context.Context) {
+		ctx.WriteString(sess.Start(ctx).User())
+	})
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	e.GET("/login").Expect().Status(iris.StatusOK)
+	e.GET("/user").Expect().Status(iris.StatusOK).Body().Equal("kataras")
+
+	if _, err := sess.ListByUser("kataras"); !sessions.ErrNotImplemented.Equal(err) {
+		t.Fatalf("expected an ErrNotImplemented but got: %v", err)
+	}
+}
+
+func TestCookieStoreEvents(t *testing.T) {
+	sess, _ := newStatelessSessions(t, testKey)
+	testEvents(t, sess, iris.New())
+}
+
+func TestCookieStoreIdleTimeout(t *testing.T) {
+	store, err := sessions.NewCookieStore(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess := sessions.New(sessions.Config{
+		Cookie:      "mycustomsessionid",
+		IdleTimeout: 200 * time.Millisecond,
+		MaxLifetime: 600 * time.Millisecond,
+	})
+	sess.UseCookieStore(store)
+	testIdleTimeout(t, sess, iris.New())
+}
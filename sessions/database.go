@@ -13,8 +13,7 @@ import (
 var ErrNotImplemented = errors.New("not implemented yet")
 
 // Database is the interface which all session databases should implement
-// By design it doesn't support any type of cookie session like other frameworks.
-// I want to protect you, believe me.
+// The stateless, cookie-only, sessions are not a `Database`, see `CookieStore` and `Sessions.UseCookieStore` instead.
 // The scope of the database is to store somewhere the sessions in order to
 // keep them after restarting the server, nothing more.
 //
@@ -52,14 +51,57 @@ type Database interface {
 	Release(sid string)
 }
 
+// Regenerator is an optional interface which a `Database` can implement
+// in order to move the values of a session to a new session id atomically, see `Sessions.Regenerate`.
+// If it returns an `ErrNotImplemented` or the database does not implement it
+// then the values are copied to the new session id and the old one is released instead.
+//
+// All the builtin databases implement it.
+type Regenerator interface {
+	Regenerate(oldSid, newSid string) error
+}
+
+// EntryVisitor is an optional interface which a `Database` can implement
+// in order to visit the session's entries along with their immutable flag,
+// it is used to keep the immutable values when a session is regenerated without a `Regenerator`.
+type EntryVisitor interface {
+	VisitEntries(sid string, cb func(entry memstore.Entry))
+}
+
+// ExpireNotifier is an optional interface which a `Database` can implement
+// in order to notify the session manager for the sessions which expired inside the database,
+// i.e by a key's ttl or by a periodic cleanup, so the `EventExpire` listeners are fired
+// even for sessions which are not loaded by the current process.
+// If the "OnExpire" succeeds then the session manager does not use in-process timers
+// to expire the sessions, an expired session is still never served though.
+//
+// The boltdb, redis and sqldb databases implement it.
+type ExpireNotifier interface {
+	// OnExpire registers the "listener" which should be called with the id of each expired session.
+	OnExpire(listener func(sid string)) error
+}
+
 type mem struct {
 	values map[string]*memstore.Store
+	infos  map[string]SessionInfo
+	users  map[string]map[string]struct{} // user:sids.
 	mu     sync.RWMutex
 }
 
-var _ Database = (*mem)(nil)
+var (
+	_ Database     = (*mem)(nil)
+	_ Regenerator  = (*mem)(nil)
+	_ EntryVisitor = (*mem)(nil)
+	_ UserIndex    = (*mem)(nil)
+)
 
-func newMemDB() Database { return &mem{values: make(map[string]*memstore.Store)} }
+func newMemDB() Database {
+	return &mem{
+		values: make(map[string]*memstore.Store),
+		infos:  make(map[string]SessionInfo),
+		users:  make(map[string]map[string]struct{}),
+	}
+}
 
 func (s *mem) Acquire(sid string, expires time.Duration) LifeTime {
 	s.mu.Lock()
@@ -90,6 +132,21 @@ func (s *mem) Visit(sid string, cb func(key string, value interface{})) {
 	s.values[sid].Visit(cb)
 }
 
+func (s *mem) VisitEntries(sid string, cb func(entry memstore.Entry)) {
+	// visit a copy, the "cb" may modify the session.
+	s.mu.RLock()
+	var entries memstore.Store
+	if values, ok := s.values[sid]; ok {
+		entries = make(memstore.Store, len(*values))
+		copy(entries, *values)
+	}
+	s.mu.RUnlock()
+
+	for _, entry := range entries {
+		cb(entry)
+	}
+}
+
 func (s *mem) Len(sid string) int {
 	s.mu.RLock()
 	n := s.values[sid].Len()
@@ -114,5 +171,76 @@ func (s *mem) Clear(sid string) {
 func (s *mem) Release(sid string) {
 	s.mu.Lock()
 	delete(s.values, sid)
+	s.deleteInfo(sid)
+	s.mu.Unlock()
+}
+
+func (s *mem) Regenerate(oldSid, newSid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values, ok := s.values[oldSid]
+	if !ok {
+		return ErrNotFound
+	}
+
+	s.values[newSid] = values
+	delete(s.values, oldSid)
+	return nil
+}
+
+func (s *mem) SetInfo(info SessionInfo) error {
+	s.mu.Lock()
+	s.deleteInfo(info.ID)
+	s.infos[info.ID] = info
+
+	sids, ok := s.users[info.User]
+	if !ok {
+		sids = make(map[string]struct{})
+		s.users[info.User] = sids
+	}
+	sids[info.ID] = struct{}{}
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *mem) GetInfo(sid string) (SessionInfo, bool) {
+	s.mu.RLock()
+	info, ok := s.infos[sid]
+	s.mu.RUnlock()
+	return info, ok
+}
+
+func (s *mem) DeleteInfo(sid string) error {
+	s.mu.Lock()
+	s.deleteInfo(sid)
 	s.mu.Unlock()
+	return nil
+}
+
+func (s *mem) deleteInfo(sid string) {
+	info, ok := s.infos[sid]
+	if !ok {
+		return
+	}
+
+	delete(s.infos, sid)
+	if sids, ok := s.users[info.User]; ok {
+		delete(sids, sid)
+		if len(sids) == 0 {
+			delete(s.users, info.User)
+		}
+	}
+}
+
+func (s *mem) ListByUser(user string) ([]SessionInfo, error) {
+	s.mu.RLock()
+	infos := make([]SessionInfo, 0, len(s.users[user]))
+	for sid := range s.users[user] {
+		infos = append(infos, s.infos[sid])
+	}
+	s.mu.RUnlock()
+
+	return infos, nil
 }
@@ -0,0 +1,86 @@
+package sessions
+
+import (
+	"github.com/radiantrfid/iris/context"
+)
+
+// Event is a lifecycle event of a session, see `Sessions.OnEvent`.
+type Event uint8
+
+const (
+	// EventCreate is fired when a new session is started by a request without a (valid) session cookie.
+	EventCreate Event = iota + 1
+	// EventRead is fired when a session is started by the session cookie of a request.
+	EventRead
+	// EventUpdate is fired when the values of a session are changed by `Set`, `SetImmutable`, `Delete` or `Clear`.
+	EventUpdate
+	// EventExpire is fired when a session is removed because its lifetime ended,
+	// it's fired by the in-process timers or by the session database, see `ExpireNotifier`.
+	EventExpire
+	// EventDestroy is fired when a session is removed by a `Destroy`, `DestroyByID`, `DestroyByUser` or `DestroyAll`.
+	EventDestroy
+)
+
+var eventNames = map[Event]string{
+	EventCreate:  "create",
+	EventRead:    "read",
+	EventUpdate:  "update",
+	EventExpire:  "expire",
+	EventDestroy: "destroy",
+}
+
+// String returns the name of the event, i.e "create".
+func (e Event) String() string {
+	return eventNames[e]
+}
+
+// EventListener is the form of a session lifecycle event listener.
+// Look `OnEvent` for more.
+type EventListener func(evt Event, sid string)
+
+type eventListener struct {
+	listener EventListener
+	events   []Event
+}
+
+func (l eventListener) accepts(evt Event) bool {
+	if len(l.events) == 0 {
+		return true
+	}
+
+	for _, e := range l.events {
+		if e == evt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OnEvent registers a listener of the "events" of all sessions,
+// all events are listened if "events" is empty.
+// Like the destroy listeners, if a listener is blocking then the session manager will delay respectfully,
+// use a goroutine inside the listener to avoid that behavior.
+//
+// Example:
+//
+//	sess.OnEvent(func(evt sessions.Event, sid string) {
+//		log.Printf("session %s: %s", sid, evt)
+//	}, sessions.EventCreate, sessions.EventExpire)
+func (s *Sessions) OnEvent(listener EventListener, events ...Event) {
+	s.provider.registerListener(listener, events)
+}
+
+// contextSessionEventKey keeps the id of the session which is started by the request,
+// so the EventCreate or EventRead is fired once per request.
+const contextSessionEventKey = "_iris_session_event"
+
+// fireStart fires the "evt" of the session of the "sid" once per request.
+func (s *Sessions) fireStart(ctx context.Context, evt Event, sid string) {
+	if ctx.Values().GetString(contextSessionEventKey) == sid {
+		return
+	}
+
+	ctx.Values().Set(contextSessionEventKey, sid)
+	s.provider.fire(evt, sid)
+}
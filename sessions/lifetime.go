@@ -17,13 +17,16 @@ type LifeTime struct {
 
 // Begin will begin the life based on the time.Now().Add(d).
 // Use `Continue` to continue from a stored time(database-based session does that).
+// A nil "onExpire" means that the expiration is handled by the database, no timer is started.
 func (lt *LifeTime) Begin(d time.Duration, onExpire func()) {
 	if d <= 0 {
 		return
 	}
 
 	lt.Time = time.Now().Add(d)
-	lt.timer = time.AfterFunc(d, onExpire)
+	if onExpire != nil {
+		lt.timer = time.AfterFunc(d, onExpire)
+	}
 }
 
 // Revive will continue the life based on the stored Time.
@@ -34,17 +37,25 @@ func (lt *LifeTime) Revive(onExpire func()) {
 	}
 
 	now := time.Now()
-	if lt.Time.After(now) {
+	if lt.Time.After(now) && onExpire != nil {
 		d := lt.Time.Sub(now)
 		lt.timer = time.AfterFunc(d, onExpire)
 	}
 }
 
 // Shift resets the lifetime based on "d".
+// It does nothing on an unlimited lifetime.
 func (lt *LifeTime) Shift(d time.Duration) {
-	if d > 0 && lt.timer != nil {
+	if d <= 0 {
+		return
+	}
+
+	if lt.timer != nil {
 		lt.Time = time.Now().Add(d)
 		lt.timer.Reset(d)
+	} else if !lt.Time.IsZero() {
+		// the expiration is handled by the database.
+		lt.Time = time.Now().Add(d)
 	}
 }
 
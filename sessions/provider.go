@@ -5,6 +5,9 @@ import (
 	"time"
 
 	"github.com/radiantrfid/iris/core/errors"
+	"github.com/radiantrfid/iris/core/memstore"
+
+	"github.com/kataras/golog"
 )
 
 type (
@@ -18,6 +21,10 @@ type (
 		sessions         map[string]*Session
 		db               Database
 		destroyListeners []DestroyListener
+		listeners        []eventListener
+		// notified is true when the database fires the expiration of the sessions,
+		// see `ExpireNotifier`, so no in-process timers are used.
+		notified bool
 	}
 )
 
@@ -31,15 +38,51 @@ func newProvider() *provider {
 
 // RegisterDatabase sets a session database.
 func (p *provider) RegisterDatabase(db Database) {
+	notified := false
+	if notifier, ok := db.(ExpireNotifier); ok {
+		if err := notifier.OnExpire(p.expire); err != nil {
+			golog.Debugf("sessions: the database can not notify the expired sessions, in-process timers are used instead: %v", err)
+		} else {
+			notified = true
+		}
+	}
+
 	p.mu.Lock() // for any case
 	p.db = db
+	p.notified = notified
 	p.mu.Unlock()
 }
 
 // newSession returns a new session from sessionid
 func (p *provider) newSession(sid string, expires time.Duration) *Session {
+	now := time.Now()
+	sess := &Session{
+		sid:           sid,
+		provider:      p,
+		flashes:       make(map[string]*flashMessage),
+		regeneratedAt: now,
+		info:          SessionInfo{ID: sid, CreatedAt: now},
+	}
+
+	if idx, ok := p.db.(UserIndex); ok {
+		// restore the metadata of a session bound to a user, i.e after a restart.
+		if info, found := idx.GetInfo(sid); found {
+			sess.info = info
+		}
+	}
+
+	// the session id may change by a `Regenerate`, so destroy the session itself.
 	onExpire := func() {
-		p.Destroy(sid)
+		p.mu.Lock()
+		if p.sessions[sess.sid] == sess {
+			p.deleteSession(sess, EventExpire)
+		}
+		p.mu.Unlock()
+	}
+
+	if p.notified {
+		// the database fires the expiration, see `expire`.
+		onExpire = nil
 	}
 
 	lifetime := p.db.Acquire(sid, expires)
@@ -59,13 +102,7 @@ func (p *provider) newSession(sid string, expires time.Duration) *Session {
 		lifetime.Begin(expires, onExpire)
 	}
 
-	sess := &Session{
-		sid:      sid,
-		provider: p,
-		flashes:  make(map[string]*flashMessage),
-		Lifetime: lifetime,
-	}
-
+	sess.Lifetime = lifetime
 	return sess
 }
 
@@ -103,23 +140,180 @@ func (p *provider) UpdateExpiration(sid string, expires time.Duration) error {
 	}
 
 	sess.Lifetime.Shift(expires)
-	return p.db.OnUpdateExpiration(sid, expires)
+	if err := p.db.OnUpdateExpiration(sid, expires); err != nil {
+		return err
+	}
+
+	sess.mu.Lock()
+	sess.info.Expires = sess.Lifetime.Time
+	info := sess.info
+	sess.mu.Unlock()
+
+	if info.User != "" {
+		p.saveInfo(info)
+	}
+
+	return nil
 }
 
 // Read returns the store which sid parameter belongs
 func (p *provider) Read(sid string, expires time.Duration) *Session {
 	p.mu.Lock()
 	if sess, found := p.sessions[sid]; found {
-		sess.runFlashGC() // run the flash messages GC, new request here of existing session
-		p.mu.Unlock()
+		if !sess.Lifetime.HasExpired() {
+			sess.runFlashGC() // run the flash messages GC, new request here of existing session
+			p.mu.Unlock()
 
-		return sess
+			return sess
+		}
+
+		// expired but its expiration is not fired yet, i.e by a periodic database cleanup.
+		p.deleteSession(sess, EventExpire)
 	}
 	p.mu.Unlock()
 
 	return p.Init(sid, expires) // if not found create new
 }
 
+// find returns the session of the "sid" if it's loaded by this process.
+func (p *provider) find(sid string) (*Session, bool) {
+	p.mu.Lock()
+	sess, found := p.sessions[sid]
+	p.mu.Unlock()
+	return sess, found
+}
+
+// Regenerate moves the "sess" and its values from the "oldSid" to the "newSid".
+// If the session has already been moved to another id, i.e by a concurrent request, it's not moved again.
+// It returns the current id of the session.
+func (p *provider) Regenerate(sess *Session, oldSid, newSid string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if sess.sid != oldSid {
+		return sess.sid, nil
+	}
+
+	var err error = ErrNotImplemented
+	if regenerator, ok := p.db.(Regenerator); ok {
+		err = regenerator.Regenerate(oldSid, newSid)
+	}
+
+	if err != nil {
+		if !ErrNotImplemented.Equal(err) {
+			return oldSid, err
+		}
+
+		// copy the values and release the old entry.
+		var expires time.Duration
+		if !sess.Lifetime.IsZero() {
+			expires = sess.Lifetime.DurationUntilExpiration()
+		}
+
+		p.db.Acquire(newSid, expires)
+		if visitor, ok := p.db.(EntryVisitor); ok {
+			visitor.VisitEntries(oldSid, func(entry memstore.Entry) {
+				p.db.Set(newSid, sess.Lifetime, entry.Key, entry.ValueRaw, entry.Immutable())
+			})
+		} else {
+			p.db.Visit(oldSid, func(key string, value interface{}) {
+				p.db.Set(newSid, sess.Lifetime, key, value, false)
+			})
+		}
+		p.db.Release(oldSid)
+	}
+
+	delete(p.sessions, oldSid)
+	sess.mu.Lock()
+	sess.sid = newSid
+	sess.regeneratedAt = time.Now()
+	sess.info.ID = newSid
+	info := sess.info
+	sess.mu.Unlock()
+	p.sessions[newSid] = sess
+
+	if idx, ok := p.db.(UserIndex); ok && info.User != "" {
+		if err := idx.DeleteInfo(oldSid); err != nil {
+			golog.Debugf("sessions: unable to remove the metadata of '%s': %v", oldSid, err)
+		}
+		p.saveInfo(info)
+	}
+
+	return newSid, nil
+}
+
+// saveInfo saves the metadata of a session to the database, if it's a `UserIndex`.
+// An empty "info.User" removes it.
+func (p *provider) saveInfo(info SessionInfo) {
+	idx, ok := p.db.(UserIndex)
+	if !ok {
+		return
+	}
+
+	var err error
+	if info.User == "" {
+		err = idx.DeleteInfo(info.ID)
+	} else {
+		err = idx.SetInfo(info)
+	}
+
+	if err != nil {
+		golog.Debugf("sessions: unable to save the metadata of '%s': %v", info.ID, err)
+	}
+}
+
+// ListByUser returns the metadata of the sessions of the "user",
+// the sessions of this process are preferred because their metadata are up to date.
+func (p *provider) ListByUser(user string) ([]SessionInfo, error) {
+	var infos []SessionInfo
+
+	if idx, ok := p.db.(UserIndex); ok {
+		stored, err := idx.ListByUser(user)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		p.mu.Lock()
+		for _, info := range stored {
+			if sess, found := p.sessions[info.ID]; found {
+				info = sess.Info()
+			}
+
+			if info.User != user || (!info.Expires.IsZero() && info.Expires.Before(now)) {
+				continue
+			}
+
+			infos = append(infos, info)
+		}
+		p.mu.Unlock()
+
+		return infos, nil
+	}
+
+	p.mu.Lock()
+	for _, sess := range p.sessions {
+		if info := sess.Info(); info.User == user {
+			infos = append(infos, info)
+		}
+	}
+	p.mu.Unlock()
+
+	return infos, nil
+}
+
+// DestroyByID removes the session of the "sid" even if it's not loaded by this process.
+func (p *provider) DestroyByID(sid string) {
+	p.mu.Lock()
+	if sess, found := p.sessions[sid]; found {
+		p.deleteSession(sess, EventDestroy)
+	} else {
+		p.db.Release(sid)
+		p.fire(EventDestroy, sid)
+	}
+	p.mu.Unlock()
+}
+
 func (p *provider) registerDestroyListener(ln DestroyListener) {
 	if ln == nil {
 		return
@@ -127,10 +321,40 @@ func (p *provider) registerDestroyListener(ln DestroyListener) {
 	p.destroyListeners = append(p.destroyListeners, ln)
 }
 
-func (p *provider) fireDestroy(sid string) {
-	for _, ln := range p.destroyListeners {
-		ln(sid)
+func (p *provider) registerListener(listener EventListener, events []Event) {
+	if listener == nil {
+		return
 	}
+	p.listeners = append(p.listeners, eventListener{listener: listener, events: events})
+}
+
+// fire calls the listeners of the "evt",
+// the destroy listeners are called on both EventExpire and EventDestroy.
+func (p *provider) fire(evt Event, sid string) {
+	for _, ln := range p.listeners {
+		if ln.accepts(evt) {
+			ln.listener(evt, sid)
+		}
+	}
+
+	if evt == EventExpire || evt == EventDestroy {
+		for _, ln := range p.destroyListeners {
+			ln(sid)
+		}
+	}
+}
+
+// expire removes the session of the "sid" because its lifetime ended,
+// it's called by the `ExpireNotifier` databases,
+// even if the session is not loaded by this process.
+func (p *provider) expire(sid string) {
+	p.mu.Lock()
+	if sess, found := p.sessions[sid]; found {
+		p.deleteSession(sess, EventExpire)
+	} else {
+		p.fire(EventExpire, sid)
+	}
+	p.mu.Unlock()
 }
 
 // Destroy destroys the session, removes all sessions and flash values,
@@ -139,7 +363,7 @@ func (p *provider) fireDestroy(sid string) {
 func (p *provider) Destroy(sid string) {
 	p.mu.Lock()
 	if sess, found := p.sessions[sid]; found {
-		p.deleteSession(sess)
+		p.deleteSession(sess, EventDestroy)
 	}
 	p.mu.Unlock()
 }
@@ -150,15 +374,15 @@ func (p *provider) Destroy(sid string) {
 func (p *provider) DestroyAll() {
 	p.mu.Lock()
 	for _, sess := range p.sessions {
-		p.deleteSession(sess)
+		p.deleteSession(sess, EventDestroy)
 	}
 	p.mu.Unlock()
 }
 
-func (p *provider) deleteSession(sess *Session) {
+func (p *provider) deleteSession(sess *Session, evt Event) {
 	sid := sess.sid
 
 	delete(p.sessions, sid)
 	p.db.Release(sid)
-	p.fireDestroy(sid)
+	p.fire(evt, sid)
 }
@@ -3,6 +3,7 @@ package sessions
 import (
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/radiantrfid/iris/core/errors"
 )
@@ -20,6 +21,12 @@ type (
 		mu       sync.RWMutex // for flashes.
 		Lifetime LifeTime
 		provider *provider
+		// regeneratedAt is the time that the session id was generated, see `Config.RegenerateEvery`.
+		regeneratedAt time.Time
+		// info is the metadata of the session, see `SetUser`.
+		info SessionInfo
+		// cookie is not nil if the session is a stateless one, see `CookieStore`.
+		cookie *cookieSession
 	}
 
 	flashMessage struct {
@@ -38,12 +45,25 @@ type (
 //
 // Use the session's manager `Destroy(ctx)` in order to remove the cookie as well.
 func (s *Session) Destroy() {
-	s.provider.deleteSession(s)
+	s.provider.deleteSession(s, EventDestroy)
+	if s.cookie != nil {
+		s.cookie.destroy()
+	}
+}
+
+// save writes the changes of a stateless session to the client's cookies.
+func (s *Session) save() {
+	if s.cookie != nil {
+		s.cookie.save(s)
+	}
 }
 
 // ID returns the session's ID.
 func (s *Session) ID() string {
-	return s.sid
+	s.mu.RLock()
+	sid := s.sid
+	s.mu.RUnlock()
+	return sid
 }
 
 // IsNew returns true if this session is
@@ -91,6 +111,7 @@ func (s *Session) GetFlash(key string) interface{} {
 		return nil
 	}
 	fv.shouldRemove = true
+	s.save()
 	return fv.value
 }
 
@@ -403,6 +424,11 @@ func (s *Session) GetFlashes() map[string]interface{} {
 		v.shouldRemove = true
 	}
 	s.mu.Unlock()
+
+	if len(flashes) > 0 {
+		s.save()
+	}
+
 	return flashes
 }
 
@@ -422,6 +448,9 @@ func (s *Session) set(key string, value interface{}, immutable bool) {
 	s.mu.Lock()
 	s.isNew = false
 	s.mu.Unlock()
+
+	s.save()
+	s.provider.fire(EventUpdate, s.ID())
 }
 
 // Set fills the session with an entry "value", based on its "key".
@@ -463,6 +492,8 @@ func (s *Session) SetFlash(key string, value interface{}) {
 	s.mu.Lock()
 	s.flashes[key] = &flashMessage{value: value}
 	s.mu.Unlock()
+
+	s.save()
 }
 
 // Delete removes an entry by its key,
@@ -473,6 +504,9 @@ func (s *Session) Delete(key string) bool {
 		s.mu.Lock()
 		s.isNew = false
 		s.mu.Unlock()
+
+		s.save()
+		s.provider.fire(EventUpdate, s.ID())
 	}
 
 	return removed
@@ -483,6 +517,8 @@ func (s *Session) DeleteFlash(key string) {
 	s.mu.Lock()
 	delete(s.flashes, key)
 	s.mu.Unlock()
+
+	s.save()
 }
 
 // Clear removes all entries.
@@ -491,6 +527,9 @@ func (s *Session) Clear() {
 	s.provider.db.Clear(s.sid)
 	s.isNew = false
 	s.mu.Unlock()
+
+	s.save()
+	s.provider.fire(EventUpdate, s.ID())
 }
 
 // ClearFlashes removes all flash messages.
@@ -500,4 +539,6 @@ func (s *Session) ClearFlashes() {
 		delete(s.flashes, key)
 	}
 	s.mu.Unlock()
+
+	s.save()
 }
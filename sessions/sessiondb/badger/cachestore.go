@@ -0,0 +1,77 @@
+package badger
+
+import (
+	"time"
+
+	"github.com/radiantrfid/iris/cache/store"
+
+	"github.com/dgraph-io/badger"
+	"github.com/kataras/golog"
+)
+
+// CacheStore is a `store.Store` of the cache handler which stores the cached responses
+// on the session database's badger connection, their keys are prefixed by "cache:".
+// The entries are expired by badger itself.
+// Use the `Database.CacheStore` to create one.
+type CacheStore struct {
+	service *badger.DB
+}
+
+var _ store.Store = (*CacheStore)(nil)
+
+// CacheStore returns a new `store.Store` which shares the badger connection of the sessions database.
+func (db *Database) CacheStore() *CacheStore {
+	return &CacheStore{service: db.Service}
+}
+
+func makeCacheKey(key string) []byte {
+	return []byte("cache:" + key)
+}
+
+// Get returns the value of the "key", if it's not expired.
+func (s *CacheStore) Get(key string) (value []byte, found bool) {
+	err := s.service.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(makeCacheKey(key))
+		if err != nil {
+			return err
+		}
+
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+
+	if err != nil {
+		if err != badger.ErrKeyNotFound {
+			golog.Debugf("unable to get the cache entry of '%s': %v", key, err)
+		}
+		return nil, false
+	}
+
+	return value, true
+}
+
+// Set stores the "value" under the "key" for "lifetime".
+func (s *CacheStore) Set(key string, value []byte, lifetime time.Duration) {
+	err := s.service.Update(func(txn *badger.Txn) error {
+		e := badger.NewEntry(makeCacheKey(key), value)
+		if lifetime > 0 {
+			e = e.WithTTL(lifetime)
+		}
+		return txn.SetEntry(e)
+	})
+
+	if err != nil {
+		golog.Debugf("unable to set the cache entry of '%s': %v", key, err)
+	}
+}
+
+// Delete removes the "key".
+func (s *CacheStore) Delete(key string) {
+	err := s.service.Update(func(txn *badger.Txn) error {
+		return txn.Delete(makeCacheKey(key))
+	})
+
+	if err != nil {
+		golog.Debugf("unable to delete the cache entry of '%s': %v", key, err)
+	}
+}
@@ -0,0 +1,60 @@
+package badger
+
+import (
+	"context"
+
+	"github.com/radiantrfid/iris/core/acme"
+
+	"github.com/dgraph-io/badger"
+)
+
+// CertCache is an `acme.Cache` which stores the ACME account key and the certificates
+// on the session database's badger connection, their keys are prefixed by "acme:".
+// Use the `Database.CertCache` to create one.
+type CertCache struct {
+	service *badger.DB
+}
+
+var _ acme.Cache = (*CertCache)(nil)
+
+// CertCache returns a new `acme.Cache` which shares the badger connection of the sessions database.
+func (db *Database) CertCache() *CertCache {
+	return &CertCache{service: db.Service}
+}
+
+func makeCertKey(key string) []byte {
+	return []byte("acme:" + key)
+}
+
+// Get returns the data of the "key" or `acme.ErrCacheMiss` if it does not exist.
+func (c *CertCache) Get(ctx context.Context, key string) (data []byte, err error) {
+	err = c.service.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(makeCertKey(key))
+		if err != nil {
+			return err
+		}
+
+		data, err = item.ValueCopy(nil)
+		return err
+	})
+
+	if err == badger.ErrKeyNotFound {
+		err = acme.ErrCacheMiss
+	}
+
+	return
+}
+
+// Put stores the "data" under the "key".
+func (c *CertCache) Put(ctx context.Context, key string, data []byte) error {
+	return c.service.Update(func(txn *badger.Txn) error {
+		return txn.Set(makeCertKey(key), data)
+	})
+}
+
+// Delete removes the "key".
+func (c *CertCache) Delete(ctx context.Context, key string) error {
+	return c.service.Update(func(txn *badger.Txn) error {
+		return txn.Delete(makeCertKey(key))
+	})
+}
@@ -37,7 +37,11 @@ type Database struct {
 	closed uint32 // if 1 is closed.
 }
 
-var _ sessions.Database = (*Database)(nil)
+var (
+	_ sessions.Database    = (*Database)(nil)
+	_ sessions.Regenerator = (*Database)(nil)
+	_ sessions.UserIndex   = (*Database)(nil)
+)
 
 // New creates and returns a new badger(key-value file-based) storage
 // instance based on the "directoryPath".
@@ -257,6 +261,175 @@ func (db *Database) Release(sid string) {
 	txn := db.Service.NewTransaction(true)
 	txn.Delete([]byte(sid))
 	txn.Commit()
+	// and its metadata, if bound to a user.
+	db.DeleteInfo(sid)
+}
+
+// Regenerate moves the values of the "oldSid" session to the "newSid" one,
+// with their expiration, in a single transaction.
+func (db *Database) Regenerate(oldSid, newSid string) error {
+	oldPrefix, newPrefix := makePrefix(oldSid), makePrefix(newSid)
+
+	return db.Service.Update(func(txn *badger.Txn) error {
+		var (
+			entries []*badger.Entry
+			oldKeys [][]byte
+		)
+
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		for iter.Seek(oldPrefix); iter.ValidForPrefix(oldPrefix); iter.Next() {
+			item := iter.Item()
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				iter.Close()
+				return err
+			}
+
+			key := append(append([]byte{}, newPrefix...), bytes.TrimPrefix(item.Key(), oldPrefix)...)
+			if bytes.Equal(key, newPrefix) {
+				value = newPrefix // the session entry, see `Acquire`.
+			}
+
+			entry := badger.NewEntry(key, value)
+			entry.ExpiresAt = item.ExpiresAt()
+			entries = append(entries, entry)
+			oldKeys = append(oldKeys, item.KeyCopy(nil))
+		}
+		iter.Close()
+
+		if len(oldKeys) == 0 {
+			return sessions.ErrNotFound
+		}
+
+		for _, entry := range entries {
+			if err := txn.SetEntry(entry); err != nil {
+				return err
+			}
+		}
+
+		for _, key := range oldKeys {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// the metadata of a session which is bound to a user is stored as $infoPrefix$sid
+// and its user index as $usersPrefix$user_$sid, both expire with the session.
+var (
+	infoPrefix  = []byte("_iris_session_info_")
+	usersPrefix = []byte("_iris_session_user_")
+)
+
+func makeInfoKey(sid string) []byte {
+	return append(append([]byte{}, infoPrefix...), sid...)
+}
+
+func makeUserPrefix(user string) []byte {
+	return append(append(append([]byte{}, usersPrefix...), user...), delim)
+}
+
+// SetInfo saves the metadata of the "info.ID" session and indexes it by the "info.User".
+func (db *Database) SetInfo(info sessions.SessionInfo) error {
+	infoBytes, err := sessions.DefaultTranscoder.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	return db.Service.Update(func(txn *badger.Txn) error {
+		if err := deleteInfo(txn, info.ID); err != nil {
+			return err
+		}
+
+		infoEntry := badger.NewEntry(makeInfoKey(info.ID), infoBytes)
+		userEntry := badger.NewEntry(append(makeUserPrefix(info.User), info.ID...), nil)
+		if !info.Expires.IsZero() {
+			ttl := time.Until(info.Expires)
+			infoEntry, userEntry = infoEntry.WithTTL(ttl), userEntry.WithTTL(ttl)
+		}
+
+		if err := txn.SetEntry(infoEntry); err != nil {
+			return err
+		}
+
+		return txn.SetEntry(userEntry)
+	})
+}
+
+// GetInfo returns the metadata of the session of the "sid", if any.
+func (db *Database) GetInfo(sid string) (info sessions.SessionInfo, found bool) {
+	db.Service.View(func(txn *badger.Txn) error {
+		var err error
+		info, found, err = getInfo(txn, sid)
+		return err
+	})
+
+	return
+}
+
+func getInfo(txn *badger.Txn, sid string) (info sessions.SessionInfo, found bool, err error) {
+	item, err := txn.Get(makeInfoKey(sid))
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			err = nil
+		}
+		return
+	}
+
+	err = item.Value(func(infoBytes []byte) error {
+		return sessions.DefaultTranscoder.Unmarshal(infoBytes, &info)
+	})
+
+	return info, err == nil, err
+}
+
+// DeleteInfo removes the metadata of the session of the "sid" and its user index.
+func (db *Database) DeleteInfo(sid string) error {
+	return db.Service.Update(func(txn *badger.Txn) error {
+		return deleteInfo(txn, sid)
+	})
+}
+
+func deleteInfo(txn *badger.Txn, sid string) error {
+	info, found, err := getInfo(txn, sid)
+	if err != nil || !found {
+		return err
+	}
+
+	if err = txn.Delete(append(makeUserPrefix(info.User), sid...)); err != nil {
+		return err
+	}
+
+	return txn.Delete(makeInfoKey(sid))
+}
+
+// ListByUser returns the metadata of the sessions of the "user".
+func (db *Database) ListByUser(user string) (infos []sessions.SessionInfo, err error) {
+	prefix := makeUserPrefix(user)
+
+	err = db.Service.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(iterOptionsNoValues)
+		defer iter.Close()
+
+		for iter.Seek(prefix); iter.ValidForPrefix(prefix); iter.Next() {
+			sid := string(bytes.TrimPrefix(iter.Item().Key(), prefix))
+			info, found, err := getInfo(txn, sid)
+			if err != nil {
+				return err
+			}
+
+			if found {
+				infos = append(infos, info)
+			}
+		}
+
+		return nil
+	})
+
+	return
 }
 
 // Close shutdowns the badger connection.
@@ -0,0 +1,102 @@
+package badger_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/radiantrfid/iris/sessions"
+	"github.com/radiantrfid/iris/sessions/sessiondb/badger"
+)
+
+func newDatabase(t *testing.T) (*badger.Database, func()) {
+	dir, err := ioutil.TempDir("", "iris-badger")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := badger.New(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDatabaseRegenerate(t *testing.T) {
+	db, closeDB := newDatabase(t)
+	defer closeDB()
+
+	db.Acquire("old", time.Hour)
+	db.Set("old", sessions.LifeTime{Time: time.Now().Add(time.Hour)}, "name", "iris", false)
+
+	if err := db.Regenerate("old", "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := db.Get("new", "name"); got != "iris" {
+		t.Fatalf("expected the value to be moved to the new session but got: %v", got)
+	}
+
+	if got := db.Len("old"); got != 0 {
+		t.Fatalf("expected the old session to be empty but got %d values", got)
+	}
+
+	// the expiration is moved too.
+	if lifetime := db.Acquire("new", time.Hour); time.Until(lifetime.Time) <= 0 {
+		t.Fatalf("expected the expiration to be moved to the new session but got: %v", lifetime)
+	}
+
+	if err := db.Regenerate("missing", "other"); !sessions.ErrNotFound.Equal(err) {
+		t.Fatalf("expected an ErrNotFound but got: %v", err)
+	}
+}
+
+func TestDatabaseUsers(t *testing.T) {
+	db, closeDB := newDatabase(t)
+	defer closeDB()
+
+	expires := time.Now().Add(time.Hour)
+	for _, info := range []sessions.SessionInfo{
+		{ID: "desktop", User: "kataras", UserAgent: "desktop", Expires: expires},
+		{ID: "mobile", User: "kataras", UserAgent: "mobile"},
+		{ID: "other", User: "makis"},
+		{ID: "expired", User: "kataras", Expires: time.Now().Add(-time.Minute)},
+	} {
+		db.Acquire(info.ID, time.Hour)
+		if err := db.SetInfo(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	infos, err := db.ListByUser("kataras")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 sessions but got: %v", infos)
+	}
+
+	// updates the same session.
+	db.SetInfo(sessions.SessionInfo{ID: "mobile", User: "makis"})
+	if info, found := db.GetInfo("mobile"); !found || info.User != "makis" {
+		t.Fatalf("expected the updated info but got: %v", info)
+	}
+
+	if err = db.DeleteInfo("other"); err != nil {
+		t.Fatal(err)
+	}
+	if infos, _ = db.ListByUser("makis"); len(infos) != 1 || infos[0].ID != "mobile" {
+		t.Fatalf("expected the 'mobile' session only but got: %v", infos)
+	}
+
+	db.Release("desktop")
+	if infos, _ = db.ListByUser("kataras"); len(infos) != 0 {
+		t.Fatalf("expected no sessions but got: %v", infos)
+	}
+}
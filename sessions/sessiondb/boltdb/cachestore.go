@@ -0,0 +1,128 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/radiantrfid/iris/cache/store"
+
+	bolt "github.com/etcd-io/bbolt"
+	"github.com/kataras/golog"
+)
+
+// CacheStore is a `store.Store` of the cache handler which stores the cached responses
+// on their own bucket of the session database's BoltDB connection.
+// BoltDB can't expire the entries on its own, use the `store.Sweep` to remove them.
+// Use the `Database.CacheStore` to create one.
+type CacheStore struct {
+	bucket  []byte
+	service *bolt.DB
+}
+
+var (
+	_ store.Store   = (*CacheStore)(nil)
+	_ store.Sweeper = (*CacheStore)(nil)
+)
+
+// CacheStore returns a new `store.Store` which shares the BoltDB connection of the sessions database,
+// the cached responses are stored on the "cache" bucket.
+func (db *Database) CacheStore() *CacheStore {
+	bucket := []byte("cache")
+
+	db.Service.Update(func(tx *bolt.Tx) (err error) {
+		_, err = tx.CreateBucketIfNotExists(bucket)
+		return
+	})
+
+	return &CacheStore{bucket: bucket, service: db.Service}
+}
+
+// each value is prefixed by its expiration time, unix nanoseconds, zero for no expiration.
+func decodeCacheValue(v []byte) (expiresAt time.Time, value []byte, ok bool) {
+	if len(v) < 8 {
+		return
+	}
+
+	if nsec := int64(binary.BigEndian.Uint64(v)); nsec > 0 {
+		expiresAt = time.Unix(0, nsec)
+	}
+
+	return expiresAt, v[8:], true
+}
+
+// Get returns the value of the "key", if it's not expired.
+func (s *CacheStore) Get(key string) (value []byte, found bool) {
+	err := s.service.View(func(tx *bolt.Tx) error {
+		expiresAt, v, ok := decodeCacheValue(tx.Bucket(s.bucket).Get([]byte(key)))
+		if !ok || store.Expired(expiresAt) {
+			return nil
+		}
+
+		// the value is only valid for the life of the transaction.
+		value = append([]byte(nil), v...)
+		found = true
+		return nil
+	})
+
+	if err != nil {
+		golog.Debugf("unable to get the cache entry of '%s': %v", key, err)
+	}
+
+	return
+}
+
+// Set stores the "value" under the "key" for "lifetime".
+func (s *CacheStore) Set(key string, value []byte, lifetime time.Duration) {
+	v := make([]byte, 8, 8+len(value))
+	if expiresAt := store.Expiration(lifetime); !expiresAt.IsZero() {
+		binary.BigEndian.PutUint64(v, uint64(expiresAt.UnixNano()))
+	}
+	v = append(v, value...)
+
+	err := s.service.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Put([]byte(key), v)
+	})
+
+	if err != nil {
+		golog.Debugf("unable to set the cache entry of '%s': %v", key, err)
+	}
+}
+
+// Delete removes the "key".
+func (s *CacheStore) Delete(key string) {
+	err := s.service.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Delete([]byte(key))
+	})
+
+	if err != nil {
+		golog.Debugf("unable to delete the cache entry of '%s': %v", key, err)
+	}
+}
+
+// Sweep removes the expired entries.
+func (s *CacheStore) Sweep() {
+	err := s.service.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+
+		// collect the keys first, deletes inside the cursor's loop may skip keys.
+		var expired [][]byte
+		b.ForEach(func(k, v []byte) error {
+			if expiresAt, _, ok := decodeCacheValue(v); !ok || store.Expired(expiresAt) {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		golog.Debugf("unable to sweep the expired cache entries: %v", err)
+	}
+}
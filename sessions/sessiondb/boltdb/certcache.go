@@ -0,0 +1,63 @@
+package boltdb
+
+import (
+	"context"
+
+	"github.com/radiantrfid/iris/core/acme"
+
+	bolt "github.com/etcd-io/bbolt"
+)
+
+// CertCache is an `acme.Cache` which stores the ACME account key and the certificates
+// on their own bucket of the session database's BoltDB connection.
+// Use the `Database.CertCache` to create one.
+type CertCache struct {
+	bucket  []byte
+	service *bolt.DB
+}
+
+var _ acme.Cache = (*CertCache)(nil)
+
+// CertCache returns a new `acme.Cache` which shares the BoltDB connection of the sessions database,
+// the certificates are stored on the "acme" bucket.
+func (db *Database) CertCache() *CertCache {
+	bucket := []byte("acme")
+
+	db.Service.Update(func(tx *bolt.Tx) (err error) {
+		_, err = tx.CreateBucketIfNotExists(bucket)
+		return
+	})
+
+	return &CertCache{bucket: bucket, service: db.Service}
+}
+
+// Get returns the data of the "key" or `acme.ErrCacheMiss` if it does not exist.
+func (c *CertCache) Get(ctx context.Context, key string) (data []byte, err error) {
+	err = c.service.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(c.bucket).Get([]byte(key)); value != nil {
+			// the value is only valid for the life of the transaction.
+			data = append([]byte(nil), value...)
+		}
+		return nil
+	})
+
+	if err == nil && data == nil {
+		err = acme.ErrCacheMiss
+	}
+
+	return
+}
+
+// Put stores the "data" under the "key".
+func (c *CertCache) Put(ctx context.Context, key string, data []byte) error {
+	return c.service.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(c.bucket).Put([]byte(key), data)
+	})
+}
+
+// Delete removes the "key".
+func (c *CertCache) Delete(ctx context.Context, key string) error {
+	return c.service.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(c.bucket).Delete([]byte(key))
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/radiantrfid/iris/core/errors"
@@ -18,6 +19,9 @@ import (
 // the session boltdb(file-based) storage.
 var (
 	DefaultFileMode = 0755
+	// DefaultCleanupInterval is the interval of removing the expired sessions,
+	// it's used when the session manager listens for them, see `OnExpire`.
+	DefaultCleanupInterval = time.Minute
 )
 
 // Database the BoltDB(file-based) session storage.
@@ -27,8 +31,20 @@ type Database struct {
 	// it's initialized at `New` or `NewFromDB`.
 	// Can be used to get stats.
 	Service *bolt.DB
+
+	mu              sync.Mutex
+	expireListeners []func(sid string)
+	stop            chan struct{}
+	closeOnce       sync.Once
 }
 
+var (
+	_ sessions.Database       = (*Database)(nil)
+	_ sessions.Regenerator    = (*Database)(nil)
+	_ sessions.UserIndex      = (*Database)(nil)
+	_ sessions.ExpireNotifier = (*Database)(nil)
+)
+
 var errPathMissing = errors.New("path is required")
 
 // New creates and returns a new BoltDB(file-based) storage
@@ -68,14 +84,22 @@ func NewFromDB(service *bolt.DB, bucketName string) (*Database, error) {
 	bucket := []byte(bucketName)
 
 	service.Update(func(tx *bolt.Tx) (err error) {
-		_, err = tx.CreateBucketIfNotExists(bucket)
+		for _, name := range [][]byte{bucket, getInfoBucketName(bucket), getUsersBucketName(bucket)} {
+			if _, err = tx.CreateBucketIfNotExists(name); err != nil {
+				return
+			}
+		}
 		return
 	})
 
-	db := &Database{table: bucket, Service: service}
+	db := &Database{table: bucket, Service: service, stop: make(chan struct{})}
 
 	runtime.SetFinalizer(db, closeDB)
-	return db, db.cleanup()
+	if err := db.cleanup(); err != nil {
+		return db, err
+	}
+
+	return db, db.cleanupInfo()
 }
 
 func (db *Database) getBucket(tx *bolt.Tx) *bolt.Bucket {
@@ -105,9 +129,17 @@ func getExpirationBucketName(bsid []byte) []byte {
 
 // Cleanup removes any invalid(have expired) session entries on initialization.
 func (db *Database) cleanup() error {
-	return db.Service.Update(func(tx *bolt.Tx) error {
+	_, err := db.removeExpired()
+	return err
+}
+
+// removeExpired removes the expired sessions and returns their ids.
+func (db *Database) removeExpired() (expired []string, err error) {
+	err = db.Service.Update(func(tx *bolt.Tx) error {
 		b := db.getBucket(tx)
 		c := b.Cursor()
+
+		var removed [][]byte
 		// loop through all buckets, find one with expiration.
 		for bsid, v := c.First(); bsid != nil; bsid, v = c.Next() {
 			if len(bsid) == 0 { // empty key, continue to the next session bucket.
@@ -129,20 +161,77 @@ func (db *Database) cleanup() error {
 				}
 
 				if expirationTime.Before(time.Now()) {
-					// expired, delete the expiration bucket.
-					if err := b.DeleteBucket(expirationName); err != nil {
-						golog.Debugf("cleanup: unable to destroy a session '%s'", bsid)
-						return err
-					}
-
-					// and the session bucket, if any.
-					return b.DeleteBucket(bsid)
+					// the keys are valid only during the transaction and the cursor can not be used after a deletion.
+					removed = append(removed, append([]byte{}, bsid...))
 				}
 			}
 		}
 
+		for _, bsid := range removed {
+			// expired, delete the expiration bucket.
+			if err := b.DeleteBucket(getExpirationBucketName(bsid)); err != nil {
+				golog.Debugf("cleanup: unable to destroy a session '%s'", bsid)
+				return err
+			}
+
+			// and the session bucket, if any.
+			if err := b.DeleteBucket(bsid); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+
+			// and its metadata, if bound to a user.
+			db.deleteInfo(tx, bsid)
+			expired = append(expired, string(bsid))
+		}
+
 		return nil
 	})
+
+	return
+}
+
+// OnExpire registers the "listener" of the expired sessions,
+// after its first call the expired sessions are removed every `DefaultCleanupInterval`.
+// The periodic cleanup keeps the database alive, the `Close` must be called to stop it.
+func (db *Database) OnExpire(listener func(sid string)) error {
+	db.mu.Lock()
+	db.expireListeners = append(db.expireListeners, listener)
+	start := len(db.expireListeners) == 1
+	db.mu.Unlock()
+
+	if start {
+		go db.cleanupEvery(DefaultCleanupInterval)
+	}
+
+	return nil
+}
+
+func (db *Database) cleanupEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stop:
+			return
+		case <-ticker.C:
+			expired, err := db.removeExpired()
+			if err != nil {
+				golog.Debugf("unable to remove the expired sessions: %v", err)
+				continue
+			}
+
+			db.mu.Lock()
+			listeners := db.expireListeners
+			db.mu.Unlock()
+
+			for _, sid := range expired {
+				for _, listener := range listeners {
+					listener(sid)
+				}
+			}
+		}
+	}
 }
 
 var expirationKey = []byte("exp") // it can be random.
@@ -363,21 +452,202 @@ func (db *Database) Release(sid string) {
 		bsid := []byte(sid)
 		// try to delete the associated expiration bucket, if exists, ignore error.
 		b.DeleteBucket(getExpirationBucketName(bsid))
+		// and its metadata, if bound to a user.
+		db.deleteInfo(tx, bsid)
 
 		return b.DeleteBucket(bsid)
 	})
 }
 
+// Regenerate moves the values of the "oldSid" session to the "newSid" one,
+// with its expiration, in a single transaction.
+func (db *Database) Regenerate(oldSid, newSid string) error {
+	return db.Service.Update(func(tx *bolt.Tx) error {
+		root := db.getBucket(tx)
+		oldName, newName := []byte(oldSid), []byte(newSid)
+		if root.Bucket(oldName) == nil {
+			return sessions.ErrNotFound
+		}
+
+		if err := moveBucket(root, getExpirationBucketName(oldName), getExpirationBucketName(newName)); err != nil {
+			return err
+		}
+
+		return moveBucket(root, oldName, newName)
+	})
+}
+
+// moveBucket copies the "from" bucket's key values to the "to" bucket and deletes the "from" one,
+// it does nothing if the "from" bucket does not exist.
+func moveBucket(root *bolt.Bucket, from, to []byte) error {
+	src := root.Bucket(from)
+	if src == nil {
+		return nil
+	}
+
+	dst, err := root.CreateBucketIfNotExists(to)
+	if err != nil {
+		return err
+	}
+
+	err = src.ForEach(func(k []byte, v []byte) error {
+		// the "k" and "v" are valid only while the "from" bucket exists.
+		return dst.Put(append([]byte{}, k...), append([]byte{}, v...))
+	})
+	if err != nil {
+		return err
+	}
+
+	return root.DeleteBucket(from)
+}
+
+// the metadata of the sessions which are bound to a user live on the $table_info bucket
+// and the $table_users bucket contains a bucket of session ids for each user.
+func getInfoBucketName(table []byte) []byte {
+	return append(append([]byte{}, table...), "_info"...)
+}
+
+func getUsersBucketName(table []byte) []byte {
+	return append(append([]byte{}, table...), "_users"...)
+}
+
+// SetInfo saves the metadata of the "info.ID" session and indexes it by the "info.User".
+func (db *Database) SetInfo(info sessions.SessionInfo) error {
+	infoBytes, err := sessions.DefaultTranscoder.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	return db.Service.Update(func(tx *bolt.Tx) error {
+		bsid := []byte(info.ID)
+		db.deleteInfo(tx, bsid)
+
+		if err := tx.Bucket(getInfoBucketName(db.table)).Put(bsid, infoBytes); err != nil {
+			return err
+		}
+
+		users, err := tx.Bucket(getUsersBucketName(db.table)).CreateBucketIfNotExists([]byte(info.User))
+		if err != nil {
+			return err
+		}
+
+		return users.Put(bsid, nil)
+	})
+}
+
+// GetInfo returns the metadata of the session of the "sid", if any.
+func (db *Database) GetInfo(sid string) (info sessions.SessionInfo, found bool) {
+	db.Service.View(func(tx *bolt.Tx) error {
+		infoBytes := tx.Bucket(getInfoBucketName(db.table)).Get([]byte(sid))
+		if infoBytes == nil {
+			return nil
+		}
+
+		if err := sessions.DefaultTranscoder.Unmarshal(infoBytes, &info); err != nil {
+			golog.Debugf("unable to retrieve the metadata of '%s': %v", sid, err)
+			return err
+		}
+
+		found = true
+		return nil
+	})
+
+	return
+}
+
+// DeleteInfo removes the metadata of the session of the "sid" and its user index.
+func (db *Database) DeleteInfo(sid string) error {
+	return db.Service.Update(func(tx *bolt.Tx) error {
+		db.deleteInfo(tx, []byte(sid))
+		return nil
+	})
+}
+
+func (db *Database) deleteInfo(tx *bolt.Tx, bsid []byte) {
+	infos := tx.Bucket(getInfoBucketName(db.table))
+	infoBytes := infos.Get(bsid)
+	if infoBytes == nil {
+		return
+	}
+
+	var info sessions.SessionInfo
+	if err := sessions.DefaultTranscoder.Unmarshal(infoBytes, &info); err == nil {
+		usersRoot := tx.Bucket(getUsersBucketName(db.table))
+		if users := usersRoot.Bucket([]byte(info.User)); users != nil {
+			users.Delete(bsid)
+			if k, _ := users.Cursor().First(); k == nil {
+				usersRoot.DeleteBucket([]byte(info.User))
+			}
+		}
+	}
+
+	infos.Delete(bsid)
+}
+
+// ListByUser returns the metadata of the sessions of the "user".
+func (db *Database) ListByUser(user string) (infos []sessions.SessionInfo, err error) {
+	err = db.Service.View(func(tx *bolt.Tx) error {
+		users := tx.Bucket(getUsersBucketName(db.table)).Bucket([]byte(user))
+		if users == nil {
+			return nil
+		}
+
+		infoBucket := tx.Bucket(getInfoBucketName(db.table))
+		return users.ForEach(func(bsid []byte, _ []byte) error {
+			infoBytes := infoBucket.Get(bsid)
+			if infoBytes == nil {
+				return nil
+			}
+
+			var info sessions.SessionInfo
+			if err := sessions.DefaultTranscoder.Unmarshal(infoBytes, &info); err != nil {
+				return err
+			}
+
+			infos = append(infos, info)
+			return nil
+		})
+	})
+
+	return
+}
+
+// cleanupInfo removes the metadata of the sessions which do not exist anymore on initialization.
+func (db *Database) cleanupInfo() error {
+	return db.Service.Update(func(tx *bolt.Tx) error {
+		root := db.getBucket(tx)
+
+		var removed [][]byte
+		tx.Bucket(getInfoBucketName(db.table)).ForEach(func(bsid []byte, _ []byte) error {
+			if root.Bucket(bsid) == nil {
+				removed = append(removed, append([]byte{}, bsid...))
+			}
+			return nil
+		})
+
+		for _, bsid := range removed {
+			db.deleteInfo(tx, bsid)
+		}
+
+		return nil
+	})
+}
+
 // Close shutdowns the BoltDB connection.
+// It's required when the `OnExpire` is used,
+// the database is not garbage collected, and closed, while its periodic cleanup runs.
 func (db *Database) Close() error {
 	return closeDB(db)
 }
 
-func closeDB(db *Database) error {
-	err := db.Service.Close()
-	if err != nil {
-		golog.Warnf("closing the BoltDB connection: %v", err)
-	}
+func closeDB(db *Database) (err error) {
+	db.closeOnce.Do(func() {
+		close(db.stop)
 
-	return err
+		if err = db.Service.Close(); err != nil {
+			golog.Warnf("closing the BoltDB connection: %v", err)
+		}
+	})
+
+	return
 }
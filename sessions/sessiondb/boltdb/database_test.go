@@ -0,0 +1,153 @@
+package boltdb_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/radiantrfid/iris/sessions"
+	"github.com/radiantrfid/iris/sessions/sessiondb/boltdb"
+)
+
+func newDatabase(t *testing.T) (*boltdb.Database, func()) {
+	dir, err := ioutil.TempDir("", "iris-boltdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := boltdb.New(filepath.Join(dir, "sessions.db"), 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDatabaseRegenerate(t *testing.T) {
+	db, closeDB := newDatabase(t)
+	defer closeDB()
+
+	db.Acquire("old", time.Hour)
+	db.Set("old", sessions.LifeTime{}, "name", "iris", false)
+
+	if err := db.Regenerate("old", "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := db.Get("new", "name"); got != "iris" {
+		t.Fatalf("expected the value to be moved to the new session but got: %v", got)
+	}
+
+	// the expiration is moved too.
+	if lifetime := db.Acquire("new", time.Hour); lifetime.IsZero() {
+		t.Fatal("expected the expiration to be moved to the new session")
+	}
+
+	if lifetime := db.Acquire("old", time.Hour); !lifetime.IsZero() {
+		t.Fatalf("expected the old session to be removed but got a lifetime of: %v", lifetime)
+	}
+
+	// a session without expiration.
+	db.Acquire("unlimited", 0)
+	db.Set("unlimited", sessions.LifeTime{}, "name", "iris", false)
+	if err := db.Regenerate("unlimited", "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := db.Get("other", "name"); got != "iris" {
+		t.Fatalf("expected the value to be moved to the new session but got: %v", got)
+	}
+
+	if err := db.Regenerate("missing", "other"); !sessions.ErrNotFound.Equal(err) {
+		t.Fatalf("expected an ErrNotFound but got: %v", err)
+	}
+}
+
+func TestDatabaseUsers(t *testing.T) {
+	db, closeDB := newDatabase(t)
+	defer closeDB()
+
+	for _, info := range []sessions.SessionInfo{
+		{ID: "desktop", User: "kataras", UserAgent: "desktop", Expires: time.Now().Add(time.Hour)},
+		{ID: "mobile", User: "kataras", UserAgent: "mobile"},
+		{ID: "other", User: "makis"},
+	} {
+		db.Acquire(info.ID, time.Hour)
+		if err := db.SetInfo(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	infos, err := db.ListByUser("kataras")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 sessions but got: %v", infos)
+	}
+
+	// updates the same session.
+	db.SetInfo(sessions.SessionInfo{ID: "mobile", User: "makis"})
+	if info, found := db.GetInfo("mobile"); !found || info.User != "makis" {
+		t.Fatalf("expected the updated info but got: %v", info)
+	}
+
+	if err = db.DeleteInfo("other"); err != nil {
+		t.Fatal(err)
+	}
+	if infos, _ = db.ListByUser("makis"); len(infos) != 1 || infos[0].ID != "mobile" {
+		t.Fatalf("expected the 'mobile' session only but got: %v", infos)
+	}
+
+	db.Release("desktop")
+	if infos, _ = db.ListByUser("kataras"); len(infos) != 0 {
+		t.Fatalf("expected no sessions but got: %v", infos)
+	}
+}
+
+func TestDatabaseOnExpire(t *testing.T) {
+	interval := boltdb.DefaultCleanupInterval
+	boltdb.DefaultCleanupInterval = 10 * time.Millisecond
+	defer func() { boltdb.DefaultCleanupInterval = interval }()
+
+	db, closeDB := newDatabase(t)
+	defer closeDB()
+
+	expired := make(chan string, 1)
+	if err := db.OnExpire(func(sid string) { expired <- sid }); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Acquire("a", 20*time.Millisecond)
+	db.Set("a", sessions.LifeTime{}, "name", "iris", false)
+	db.SetInfo(sessions.SessionInfo{ID: "a", User: "kataras"})
+	db.Acquire("alive", time.Hour)
+	db.Set("alive", sessions.LifeTime{}, "name", "iris", false)
+
+	select {
+	case sid := <-expired:
+		if sid != "a" {
+			t.Fatalf("expected the expiration of 'a' but got '%s'", sid)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the session to be expired")
+	}
+
+	if n := db.Len("a"); n != 0 {
+		t.Fatalf("expected the expired session to be removed but it has %d values", n)
+	}
+
+	if _, found := db.GetInfo("a"); found {
+		t.Fatal("expected the metadata of the expired session to be removed")
+	}
+
+	if got := db.Get("alive", "name"); got != "iris" {
+		t.Fatalf("expected the session to be kept but got: %v", got)
+	}
+}
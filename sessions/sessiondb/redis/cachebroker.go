@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"github.com/radiantrfid/iris/cache/store"
+	"github.com/radiantrfid/iris/core/errors"
+)
+
+// ErrPubSubNotSupported is returned by the `CacheBroker` when the driver does not implement the `PubSubDriver`.
+var ErrPubSubNotSupported = errors.New("redis: the driver does not support publish/subscribe")
+
+// CacheBrokerChannel is the redis channel of the `CacheBroker`, prefixed by the `Config.Prefix`.
+var CacheBrokerChannel = "cache:purge"
+
+// CacheBroker is a `store.Broker` which propagates the purges of the cached responses
+// to all the instances of an application through the redis publish/subscribe messaging.
+// Use the `Database.CacheBroker` to create one.
+type CacheBroker struct {
+	driver Driver
+}
+
+var _ store.Broker = (*CacheBroker)(nil)
+
+// CacheBroker returns a new `store.Broker` which shares the redis connection of the sessions database,
+// i.e `cache.DefaultPurger.Broker(db.CacheBroker())`.
+func (db *Database) CacheBroker() *CacheBroker {
+	return &CacheBroker{driver: db.c.Driver}
+}
+
+// Publish sends the "message" to all the subscribers of the `CacheBrokerChannel`.
+func (b *CacheBroker) Publish(message string) error {
+	ps, ok := b.driver.(PubSubDriver)
+	if !ok {
+		return ErrPubSubNotSupported
+	}
+
+	return ps.Publish(CacheBrokerChannel, message)
+}
+
+// Subscribe calls the "handler" on each message of the `CacheBrokerChannel`,
+// until the returned "unsubscribe" function is called.
+func (b *CacheBroker) Subscribe(handler func(message string)) (func(), error) {
+	ps, ok := b.driver.(PubSubDriver)
+	if !ok {
+		return nil, ErrPubSubNotSupported
+	}
+
+	return ps.Subscribe(CacheBrokerChannel, handler)
+}
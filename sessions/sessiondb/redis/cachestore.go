@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/radiantrfid/iris/cache/store"
+
+	"github.com/kataras/golog"
+)
+
+// CacheStore is a `store.Store` of the cache handler which stores the cached responses
+// on the session database's redis connection, their keys are prefixed by "cache:".
+// The entries are expired by redis itself, it can be shared across replicas.
+// Use the `Database.CacheStore` to create one.
+type CacheStore struct {
+	driver Driver
+}
+
+var _ store.Store = (*CacheStore)(nil)
+
+// CacheStore returns a new `store.Store` which shares the redis connection of the sessions database.
+func (db *Database) CacheStore() *CacheStore {
+	return &CacheStore{driver: db.c.Driver}
+}
+
+func makeCacheKey(key string) string {
+	return "cache:" + key
+}
+
+// Get returns the value of the "key", if it's not expired.
+func (s *CacheStore) Get(key string) ([]byte, bool) {
+	value, err := s.driver.Get(makeCacheKey(key))
+	if err != nil {
+		if !ErrKeyNotFound.Equal(err) {
+			golog.Debugf("unable to get the cache entry of '%s': %v", key, err)
+		}
+		return nil, false
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return v, true
+	case string:
+		return []byte(v), true
+	default:
+		return nil, false
+	}
+}
+
+// Set stores the "value" under the "key" for "lifetime",
+// redis expires the keys in seconds, the "lifetime" is rounded up.
+func (s *CacheStore) Set(key string, value []byte, lifetime time.Duration) {
+	var seconds int64
+	if lifetime > 0 {
+		seconds = int64((lifetime + time.Second - 1) / time.Second)
+	}
+
+	if err := s.driver.Set(makeCacheKey(key), value, seconds); err != nil {
+		golog.Debugf("unable to set the cache entry of '%s': %v", key, err)
+	}
+}
+
+// Delete removes the "key".
+func (s *CacheStore) Delete(key string) {
+	if err := s.driver.Delete(makeCacheKey(key)); err != nil {
+		golog.Debugf("unable to delete the cache entry of '%s': %v", key, err)
+	}
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/radiantrfid/iris/core/acme"
+)
+
+// CertCache is an `acme.Cache` which stores the ACME account key and the certificates
+// on the session database's redis connection, their keys are prefixed by "acme:".
+// Use the `Database.CertCache` to create one.
+type CertCache struct {
+	driver Driver
+}
+
+var _ acme.Cache = (*CertCache)(nil)
+
+// CertCache returns a new `acme.Cache` which shares the redis connection of the sessions database,
+// useful to share the certificates between multiple instances of the same server.
+func (db *Database) CertCache() *CertCache {
+	return &CertCache{driver: db.c.Driver}
+}
+
+func makeCertKey(key string) string {
+	return "acme:" + key
+}
+
+// Get returns the data of the "key" or `acme.ErrCacheMiss` if it does not exist.
+func (c *CertCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.driver.Get(makeCertKey(key))
+	if err != nil {
+		if ErrKeyNotFound.Equal(err) {
+			return nil, acme.ErrCacheMiss
+		}
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, acme.ErrCacheMiss
+	}
+}
+
+// Put stores the "data" under the "key", it never expires.
+func (c *CertCache) Put(ctx context.Context, key string, data []byte) error {
+	return c.driver.Set(makeCertKey(key), data, 0)
+}
+
+// Delete removes the "key".
+func (c *CertCache) Delete(ctx context.Context, key string) error {
+	return c.driver.Delete(makeCertKey(key))
+}
@@ -1,6 +1,8 @@
 package redis
 
 import (
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/radiantrfid/iris/core/errors"
@@ -70,9 +72,17 @@ func DefaultConfig() Config {
 // Database the redis back-end session database for the sessions.
 type Database struct {
 	c Config
+
+	mu           sync.Mutex
+	unsubscribes []func()
 }
 
-var _ sessions.Database = (*Database)(nil)
+var (
+	_ sessions.Database       = (*Database)(nil)
+	_ sessions.Regenerator    = (*Database)(nil)
+	_ sessions.UserIndex      = (*Database)(nil)
+	_ sessions.ExpireNotifier = (*Database)(nil)
+)
 
 // New returns a new redis database.
 func New(cfg ...Config) *Database {
@@ -131,10 +141,15 @@ func (db *Database) Acquire(sid string, expires time.Duration) sessions.LifeTime
 	if !found {
 		// fmt.Printf("db.Acquire expires: %s. Seconds: %v\n", expires, expires.Seconds())
 		// not found, create an entry with ttl and return an empty lifetime, session manager will do its job.
-		if err := db.c.Driver.Set(sid, sid, int64(expires.Seconds())); err != nil {
+		seconds := int64(expires.Seconds())
+		if err := db.c.Driver.Set(sid, sid, seconds); err != nil {
 			golog.Debug(err)
 		}
 
+		if seconds > 0 {
+			db.setExpireKey(sid, seconds)
+		}
+
 		return sessions.LifeTime{} // session manager will handle the rest.
 	}
 
@@ -148,7 +163,55 @@ func (db *Database) Acquire(sid string, expires time.Duration) sessions.LifeTime
 // OnUpdateExpiration will re-set the database's session's entry ttl.
 // https://redis.io/commands/expire#refreshing-expires
 func (db *Database) OnUpdateExpiration(sid string, newExpires time.Duration) error {
-	return db.c.Driver.UpdateTTLMany(sid, int64(newExpires.Seconds()))
+	seconds := int64(newExpires.Seconds())
+	if err := db.c.Driver.UpdateTTLMany(sid, seconds); err != nil {
+		return err
+	}
+
+	db.setExpireKey(sid, seconds)
+	return nil
+}
+
+// the _iris_session_expire-$sid key expires with the session,
+// its keyspace notification fires the expiration of the session, see `OnExpire`.
+// The $sid key itself is not used because its $sid-$key values expire at the same time
+// and they can not be told apart by the name of the key.
+const expirePrefix = "_iris_session_expire"
+
+func (db *Database) makeExpireKey(sid string) string {
+	return expirePrefix + db.c.Delim + sid
+}
+
+func (db *Database) setExpireKey(sid string, seconds int64) {
+	if err := db.c.Driver.Set(db.makeExpireKey(sid), sid, seconds); err != nil {
+		golog.Debugf("unable to set the expiration key of session '%s': %v", sid, err)
+	}
+}
+
+// OnExpire registers the "listener" of the expired sessions,
+// it uses the keyspace notifications of the expired keys.
+// It returns an `sessions.ErrNotImplemented` if the `Config.Driver` is not an `ExpireDriver`
+// or an error if the notifications can not be enabled by the "CONFIG" command.
+func (db *Database) OnExpire(listener func(sid string)) error {
+	driver, ok := db.c.Driver.(ExpireDriver)
+	if !ok {
+		return sessions.ErrNotImplemented
+	}
+
+	prefix := db.c.Prefix + db.makeExpireKey("")
+	unsubscribe, err := driver.SubscribeExpired(func(key string) {
+		if strings.HasPrefix(key, prefix) {
+			listener(key[len(prefix):])
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	db.unsubscribes = append(db.unsubscribes, unsubscribe)
+	db.mu.Unlock()
+	return nil
 }
 
 func (db *Database) makeKey(sid, key string) string {
@@ -240,6 +303,123 @@ func (db *Database) Release(sid string) {
 	db.Clear(sid)
 	// and remove the $sid.
 	db.c.Driver.Delete(sid)
+	db.c.Driver.Delete(db.makeExpireKey(sid))
+	// and its metadata, if bound to a user.
+	db.DeleteInfo(sid)
+}
+
+// Regenerate moves the values of the "oldSid" session to the "newSid" one,
+// with their expiration, in a single transaction.
+// It fails with an `ErrKeysChanged` if the session is modified during the move, nothing is moved then.
+// It returns an `sessions.ErrNotImplemented` if the `Config.Driver` is not a `RenameDriver`.
+func (db *Database) Regenerate(oldSid, newSid string) error {
+	driver, ok := db.c.Driver.(RenameDriver)
+	if !ok {
+		return sessions.ErrNotImplemented
+	}
+
+	// the expire key exists only for the sessions with expiration.
+	return driver.RenameMany(
+		Rename{Old: oldSid, New: newSid},
+		Rename{Old: db.makeExpireKey(oldSid), New: db.makeExpireKey(newSid)},
+	)
+}
+
+// the metadata of a session which is bound to a user is stored as _iris_session_info-$sid
+// and its user index as _iris_session_user-$user-$sid, both expire with the session.
+const (
+	infoPrefix  = "_iris_session_info"
+	usersPrefix = "_iris_session_user"
+)
+
+func (db *Database) makeInfoKey(sid string) string {
+	return infoPrefix + db.c.Delim + sid
+}
+
+func (db *Database) makeUserPrefix(user string) string {
+	return usersPrefix + db.c.Delim + user + db.c.Delim
+}
+
+// SetInfo saves the metadata of the "info.ID" session and indexes it by the "info.User".
+func (db *Database) SetInfo(info sessions.SessionInfo) error {
+	infoBytes, err := sessions.DefaultTranscoder.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	var seconds int64
+	if !info.Expires.IsZero() {
+		if seconds = int64(time.Until(info.Expires).Seconds()); seconds < 1 {
+			seconds = 1
+		}
+	}
+
+	if err = db.DeleteInfo(info.ID); err != nil {
+		return err
+	}
+
+	if err = db.c.Driver.Set(db.makeInfoKey(info.ID), infoBytes, seconds); err != nil {
+		return err
+	}
+
+	return db.c.Driver.Set(db.makeUserPrefix(info.User)+info.ID, info.ID, seconds)
+}
+
+// GetInfo returns the metadata of the session of the "sid", if any.
+func (db *Database) GetInfo(sid string) (info sessions.SessionInfo, found bool) {
+	data, err := db.c.Driver.Get(db.makeInfoKey(sid))
+	if err != nil {
+		// not found.
+		return
+	}
+
+	if err = sessions.DefaultTranscoder.Unmarshal(data.([]byte), &info); err != nil {
+		golog.Debugf("unable to unmarshal the info of session '%s': %v", sid, err)
+		return
+	}
+
+	return info, true
+}
+
+// DeleteInfo removes the metadata of the session of the "sid" and its user index.
+func (db *Database) DeleteInfo(sid string) error {
+	info, found := db.GetInfo(sid)
+	if !found {
+		return nil
+	}
+
+	if err := db.c.Driver.Delete(db.makeUserPrefix(info.User) + sid); err != nil {
+		return err
+	}
+
+	return db.c.Driver.Delete(db.makeInfoKey(sid))
+}
+
+// ListByUser returns the metadata of the sessions of the "user".
+func (db *Database) ListByUser(user string) ([]sessions.SessionInfo, error) {
+	prefix := db.makeUserPrefix(user)
+	keys, err := db.c.Driver.GetKeys(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]sessions.SessionInfo, 0, len(keys))
+	for _, key := range keys {
+		// the Radix driver returns the keys with the Config.Prefix.
+		if !strings.HasPrefix(key, prefix) {
+			key = strings.TrimPrefix(key, db.c.Prefix)
+		}
+
+		// the "user" may contain pattern characters, keep only the exact matches.
+		info, found := db.GetInfo(strings.TrimPrefix(key, prefix))
+		if !found || info.User != user {
+			continue
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
 }
 
 // Close terminates the redis connection.
@@ -248,6 +428,13 @@ func (db *Database) Close() error {
 }
 
 func closeDB(db *Database) error {
+	db.mu.Lock()
+	for _, unsubscribe := range db.unsubscribes {
+		unsubscribe()
+	}
+	db.unsubscribes = nil
+	db.mu.Unlock()
+
 	return db.c.Driver.CloseConnection()
 }
 
@@ -256,4 +443,7 @@ var (
 	ErrRedisClosed = errors.New("Redis is already closed")
 	// ErrKeyNotFound an error with message 'Key $thekey doesn't found'
 	ErrKeyNotFound = errors.New("Key '%s' doesn't found")
+	// ErrKeysChanged an error with message 'Keys changed during the transaction',
+	// returned by the `RenameDriver.RenameMany`.
+	ErrKeysChanged = errors.New("Keys changed during the transaction")
 )
@@ -1,5 +1,7 @@
 package redis
 
+import "strings"
+
 // Driver is the interface which each supported redis client
 // should support in order to be used in the redis session database.
 type Driver interface {
@@ -16,9 +18,96 @@ type Driver interface {
 	Delete(key string) error
 }
 
+// PubSubDriver is implemented by the drivers which support
+// the redis publish/subscribe messaging, both `Redigo()` and `Radix()` do.
+// It's used by the `CacheBroker`.
+type PubSubDriver interface {
+	// Publish posts the "message" to the "channel".
+	Publish(channel, message string) error
+	// Subscribe calls the "handler" for each message posted to the "channel",
+	// until the returned "unsubscribe" function is called.
+	Subscribe(channel string, handler func(message string)) (unsubscribe func(), err error)
+}
+
+// ExpireDriver is implemented by the drivers which can listen for the expired keys
+// through the redis keyspace notifications, both `Redigo()` and `Radix()` do.
+// It's used by the `Database.OnExpire`.
+type ExpireDriver interface {
+	// SubscribeExpired enables the keyspace notifications of the expired keys, if they are not enabled already,
+	// and calls the "handler" with the name of each expired key, including the `Config.Prefix`,
+	// until the returned "unsubscribe" function is called.
+	// It fails if the notifications can not be enabled, i.e the "CONFIG" command is disabled.
+	SubscribeExpired(handler func(key string)) (unsubscribe func(), err error)
+}
+
+// keyspaceFlags returns the "notify-keyspace-events" "flags" with the keyevent notifications
+// of the expired keys enabled, the rest of the flags are kept.
+func keyspaceFlags(flags string) string {
+	if !strings.ContainsRune(flags, 'E') {
+		flags += "E"
+	}
+
+	// "A" is an alias of all events, including the "x" expired ones.
+	if !strings.ContainsAny(flags, "xA") {
+		flags += "x"
+	}
+
+	return flags
+}
+
+// expiredChannel returns the keyevent channel of the expired keys of the "database".
+func expiredChannel(database string) string {
+	if database == "" {
+		database = "0"
+	}
+
+	return "__keyevent@" + database + "__:expired"
+}
+
+// RenameDriver is implemented by the drivers which can rename
+// groups of keys in a single transaction, both `Redigo()` and `Radix()` do.
+// It's used by the `Database.Regenerate`.
+type RenameDriver interface {
+	// RenameMany renames all keys starting with the old prefix of each one of the "renames"
+	// to start with its new prefix instead, their expiration is kept.
+	// A prefix without keys is skipped, it returns an `ErrKeyNotFound` if there are no keys at all.
+	RenameMany(renames ...Rename) error
+}
+
+// Rename is an input argument of the `RenameDriver.RenameMany`,
+// all keys starting with the "Old" prefix are renamed to start with the "New" one instead.
+type Rename struct {
+	Old string
+	New string
+}
+
+// keysChanged reports whether the "keys" of a rescan differ from the ones of the "renamed" map.
+func keysChanged(keys []string, renamed map[string]string) bool {
+	if len(keys) != len(renamed) {
+		return true
+	}
+
+	for _, key := range keys {
+		if _, ok := renamed[key]; !ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 var (
 	_ Driver = (*RedigoDriver)(nil)
 	_ Driver = (*RadixDriver)(nil)
+
+	_ PubSubDriver = (*RedigoDriver)(nil)
+	_ PubSubDriver = (*RadixDriver)(nil)
+
+	_ RenameDriver = (*RedigoDriver)(nil)
+	_ RenameDriver = (*RadixDriver)(nil)
+
+	_ ExpireDriver = (*RedigoDriver)(nil)
+	_ ExpireDriver = (*RadixDriver)(nil)
 )
 
 // Redigo returns the driver for the redigo go redis client.
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math/rand"
 	"strconv"
+	"sync"
 
 	"github.com/mediocregopher/radix/v3"
 	"github.com/mediocregopher/radix/v3/resp/resp2"
@@ -19,6 +20,8 @@ type RadixDriver struct {
 	// Config the read-only redis database config.
 	Config Config
 	pool   *radix.Pool
+	// connFunc dials the connections of the pool and the subscriptions.
+	connFunc radix.ConnFunc
 }
 
 // Connect connects to the redis, called only once
@@ -92,6 +95,7 @@ func (r *RadixDriver) Connect(c Config) error {
 
 	r.Connected = true
 	r.pool = pool
+	r.connFunc = connFunc
 	r.Config = c
 	return nil
 }
@@ -253,15 +257,19 @@ func (s *scanResult) UnmarshalRESP(br *bufio.Reader) error {
 }
 
 func (r *RadixDriver) getKeys(cursor, prefix string) ([]string, error) {
+	return r.getKeysConn(r.pool, cursor, prefix)
+}
+
+func (r *RadixDriver) getKeysConn(c radix.Client, cursor, prefix string) ([]string, error) {
 	var res scanResult
-	err := r.pool.Do(radix.Cmd(&res, "SCAN", cursor, "MATCH", r.Config.Prefix+prefix+"*", "COUNT", "300000"))
+	err := c.Do(radix.Cmd(&res, "SCAN", cursor, "MATCH", r.Config.Prefix+prefix+"*", "COUNT", "300000"))
 	if err != nil {
 		return nil, err
 	}
 
 	keys := res.keys[0:]
 	if res.cur != "0" {
-		moreKeys, err := r.getKeys(res.cur, prefix)
+		moreKeys, err := r.getKeysConn(c, res.cur, prefix)
 		if err != nil {
 			return nil, err
 		}
@@ -298,3 +306,136 @@ func (r *RadixDriver) Delete(key string) error {
 	err := r.pool.Do(radix.Cmd(nil, "DEL", r.Config.Prefix+key))
 	return err
 }
+
+// RenameMany renames all keys starting with the old prefix of each one of the "renames" to start with its new prefix instead,
+// in a single "MULTI" transaction, the "RENAME" command keeps their expiration.
+// The renamed keys are watched, the transaction fails with `ErrKeysChanged` if they are modified,
+// or if new keys with the same prefixes appear, during the rename.
+func (r *RadixDriver) RenameMany(renames ...Rename) error {
+	return r.pool.Do(radix.WithConn("", func(c radix.Conn) error {
+		scan := func() (keys []string, to map[string]string, err error) {
+			to = make(map[string]string)
+			for _, rename := range renames {
+				prefixed, err := r.getKeysConn(c, "0", rename.Old)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				for _, key := range prefixed {
+					// the scanned keys contain the `Config.Prefix`.
+					to[key] = r.Config.Prefix + rename.New + key[len(r.Config.Prefix)+len(rename.Old):]
+					keys = append(keys, key)
+				}
+			}
+
+			return
+		}
+
+		keys, to, err := scan()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) == 0 {
+			return ErrKeyNotFound.Format(renames[0].Old)
+		}
+
+		if err = c.Do(radix.Cmd(nil, "WATCH", keys...)); err != nil {
+			return err
+		}
+
+		// keys which appeared before the watch are not renamed, fail instead.
+		watched, _, err := scan()
+		if err != nil || keysChanged(watched, to) {
+			c.Do(radix.Cmd(nil, "UNWATCH"))
+			if err == nil {
+				err = ErrKeysChanged
+			}
+			return err
+		}
+
+		if err = c.Do(radix.Cmd(nil, "MULTI")); err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err = c.Do(radix.Cmd(nil, "RENAME", key, to[key])); err != nil {
+				c.Do(radix.Cmd(nil, "DISCARD"))
+				return err
+			}
+		}
+
+		exec := radix.MaybeNil{}
+		if err = c.Do(radix.Cmd(&exec, "EXEC")); err != nil {
+			return err
+		}
+
+		if exec.Nil {
+			// a watched key is modified.
+			return ErrKeysChanged
+		}
+
+		return nil
+	}))
+}
+
+// Publish posts the "message" to the "channel".
+func (r *RadixDriver) Publish(channel, message string) error {
+	return r.pool.Do(radix.Cmd(nil, "PUBLISH", r.Config.Prefix+channel, message))
+}
+
+// Subscribe calls the "handler" for each message posted to the "channel",
+// until the returned "unsubscribe" function is called.
+// It uses a dedicated connection which is re-established on network failures.
+func (r *RadixDriver) Subscribe(channel string, handler func(message string)) (func(), error) {
+	return r.subscribe(r.Config.Prefix+channel, handler)
+}
+
+// SubscribeExpired enables the keyspace notifications of the expired keys, if they are not enabled already,
+// and calls the "handler" with the name of each expired key, including the `Config.Prefix`,
+// until the returned "unsubscribe" function is called.
+func (r *RadixDriver) SubscribeExpired(handler func(key string)) (func(), error) {
+	var reply []string
+	if err := r.pool.Do(radix.Cmd(&reply, "CONFIG", "GET", "notify-keyspace-events")); err != nil {
+		return nil, err
+	}
+
+	if len(reply) == 2 {
+		if flags := keyspaceFlags(reply[1]); flags != reply[1] {
+			if err := r.pool.Do(radix.Cmd(nil, "CONFIG", "SET", "notify-keyspace-events", flags)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return r.subscribe(expiredChannel(r.Config.Database), handler)
+}
+
+func (r *RadixDriver) subscribe(channel string, handler func(message string)) (func(), error) {
+	ps := radix.PersistentPubSub(r.Config.Network, r.Config.Addr, r.connFunc)
+	msgCh := make(chan radix.PubSubMessage)
+	if err := ps.Subscribe(msgCh, channel); err != nil {
+		ps.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case msg := <-msgCh:
+				handler(string(msg.Message))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ps.Close()
+			close(done)
+		})
+	}, nil
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
@@ -271,6 +272,199 @@ func (r *RedigoDriver) Delete(key string) error {
 	return err
 }
 
+// RenameMany renames all keys starting with the old prefix of each one of the "renames" to start with its new prefix instead,
+// in a single "MULTI" transaction, the "RENAME" command keeps their expiration.
+// The renamed keys are watched, the transaction fails with `ErrKeysChanged` if they are modified,
+// or if new keys with the same prefixes appear, during the rename.
+func (r *RedigoDriver) RenameMany(renames ...Rename) error {
+	c := r.pool.Get()
+	defer c.Close()
+	if err := c.Err(); err != nil {
+		return err
+	}
+
+	scan := func() (keys []string, to map[string]string, err error) {
+		to = make(map[string]string)
+		for _, rename := range renames {
+			prefixed, err := r.getKeysConn(c, 0, rename.Old)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			for _, key := range prefixed {
+				to[key] = rename.New + key[len(rename.Old):]
+				keys = append(keys, key)
+			}
+		}
+
+		return
+	}
+
+	keys, to, err := scan()
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return ErrKeyNotFound.Format(renames[0].Old)
+	}
+
+	args := make([]interface{}, len(keys))
+	for i, key := range keys {
+		args[i] = r.Config.Prefix + key
+	}
+
+	if _, err = c.Do("WATCH", args...); err != nil {
+		return err
+	}
+
+	// keys which appeared before the watch are not renamed, fail instead.
+	watched, _, err := scan()
+	if err != nil || keysChanged(watched, to) {
+		c.Do("UNWATCH")
+		if err == nil {
+			err = ErrKeysChanged
+		}
+		return err
+	}
+
+	if err = c.Send("MULTI"); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err = c.Send("RENAME", r.Config.Prefix+key, r.Config.Prefix+to[key]); err != nil {
+			return err
+		}
+	}
+
+	replies, err := redis.Values(c.Do("EXEC"))
+	if err != nil {
+		if err == redis.ErrNil {
+			// a watched key is modified.
+			return ErrKeysChanged
+		}
+		return err
+	}
+
+	for _, reply := range replies {
+		if err, ok := reply.(redis.Error); ok {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Publish posts the "message" to the "channel".
+func (r *RedigoDriver) Publish(channel, message string) error {
+	c := r.pool.Get()
+	defer c.Close()
+
+	_, err := c.Do("PUBLISH", r.Config.Prefix+channel, message)
+	return err
+}
+
+// Subscribe calls the "handler" for each message posted to the "channel",
+// until the returned "unsubscribe" function is called.
+// It uses a dedicated connection which is re-established on network failures.
+func (r *RedigoDriver) Subscribe(channel string, handler func(message string)) (func(), error) {
+	return r.subscribe(r.Config.Prefix+channel, handler)
+}
+
+// SubscribeExpired enables the keyspace notifications of the expired keys, if they are not enabled already,
+// and calls the "handler" with the name of each expired key, including the `Config.Prefix`,
+// until the returned "unsubscribe" function is called.
+func (r *RedigoDriver) SubscribeExpired(handler func(key string)) (func(), error) {
+	c := r.pool.Get()
+	defer c.Close()
+
+	reply, err := redis.Strings(c.Do("CONFIG", "GET", "notify-keyspace-events"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reply) == 2 {
+		if flags := keyspaceFlags(reply[1]); flags != reply[1] {
+			if _, err = c.Do("CONFIG", "SET", "notify-keyspace-events", flags); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return r.subscribe(expiredChannel(r.Config.Database), handler)
+}
+
+func (r *RedigoDriver) subscribe(channel string, handler func(message string)) (func(), error) {
+	subscribe := func() (redis.PubSubConn, error) {
+		// no read timeout, it waits for messages.
+		c, err := dial(r.Config.Network, r.Config.Addr, r.Config.Password, 0)
+		if err != nil {
+			return redis.PubSubConn{}, err
+		}
+
+		psc := redis.PubSubConn{Conn: c}
+		if err = psc.Subscribe(channel); err != nil {
+			psc.Close()
+			return redis.PubSubConn{}, err
+		}
+
+		return psc, nil
+	}
+
+	psc, err := subscribe()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu     sync.Mutex
+		closed bool
+	)
+
+	go func() {
+		for {
+			switch v := psc.Receive().(type) {
+			case redis.Message:
+				handler(string(v.Data))
+			case error:
+				// closed by the unsubscribe or a network failure, reconnect.
+				for {
+					time.Sleep(time.Second)
+					next, err := subscribe()
+
+					mu.Lock()
+					if closed {
+						if err == nil {
+							next.Close()
+						}
+						mu.Unlock()
+						return
+					}
+					if err == nil {
+						psc.Close()
+						psc = next
+					}
+					mu.Unlock()
+
+					if err == nil {
+						break
+					}
+				}
+			}
+		}
+	}()
+
+	return func() {
+		mu.Lock()
+		if !closed {
+			closed = true
+			psc.Close()
+		}
+		mu.Unlock()
+	}, nil
+}
+
 func dial(network string, addr string, pass string, timeout time.Duration) (redis.Conn, error) {
 	if network == "" {
 		network = DefaultRedisNetwork
@@ -0,0 +1,589 @@
+package sqldb
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/radiantrfid/iris/core/errors"
+	"github.com/radiantrfid/iris/sessions"
+
+	"github.com/kataras/golog"
+)
+
+const (
+	// DefaultTable is the default name of the sessions table, "iris_sessions".
+	DefaultTable = "iris_sessions"
+	// DefaultCleanupInterval is the default interval of removing the expired sessions, 10 minutes.
+	DefaultCleanupInterval = 10 * time.Minute
+	// DefaultCleanupBatchSize is the default maximum number of the expired sessions removed per transaction, 500.
+	DefaultCleanupBatchSize = 500
+)
+
+// Config the SQL configuration used inside sessions.
+type Config struct {
+	// Table is the name of the sessions table,
+	// their values are stored at the Table+"_values" one
+	// and the metadata of the sessions which are bound to a user at the Table+"_info" one.
+	// The tables are created on `New` if they do not exist.
+	// Defaults to "iris_sessions".
+	Table string
+	// Dialect supports `SQLite()`, `Postgres()` and `MySQL()`,
+	// it should match the driver of the database connection.
+	//
+	// Defaults to `SQLite()`.
+	Dialect Dialect
+	// CleanupInterval is the interval of removing the expired sessions from the tables,
+	// the first cleanup happens on `New`. A negative value disables the periodic cleanup.
+	// Defaults to 10 minutes.
+	CleanupInterval time.Duration
+	// CleanupBatchSize is the maximum number of the expired sessions removed per transaction,
+	// so a cleanup never locks the tables for a long time.
+	// Defaults to 500.
+	CleanupBatchSize int
+}
+
+// DefaultConfig returns the default configuration for the SQL session database.
+func DefaultConfig() Config {
+	return Config{
+		Table:            DefaultTable,
+		Dialect:          SQLite(),
+		CleanupInterval:  DefaultCleanupInterval,
+		CleanupBatchSize: DefaultCleanupBatchSize,
+	}
+}
+
+// Database the `database/sql` session storage,
+// the values are encoded by the `sessions.DefaultTranscoder`.
+type Database struct {
+	c Config
+	// Service is the underline SQL database connection,
+	// it's the one passed on `New`.
+	Service *sql.DB
+
+	q               queries
+	mu              sync.Mutex
+	expireListeners []func(sid string)
+	closeOnce       sync.Once
+	stop            chan struct{}
+}
+
+var (
+	_ sessions.Database       = (*Database)(nil)
+	_ sessions.Regenerator    = (*Database)(nil)
+	_ sessions.UserIndex      = (*Database)(nil)
+	_ sessions.ExpireNotifier = (*Database)(nil)
+)
+
+var (
+	errServiceMissing  = errors.New("sql database connection is required")
+	errCleanupDisabled = errors.New("the periodic cleanup is disabled")
+)
+
+// New returns a new SQL session database based on an already opened "service" connection,
+// i.e `sql.Open("sqlite3", "./sessions.db")`.
+// It creates the necessary tables, if they do not exist, and removes any expired sessions.
+func New(service *sql.DB, cfg ...Config) (*Database, error) {
+	if service == nil {
+		golog.Error(errServiceMissing)
+		return nil, errServiceMissing
+	}
+
+	c := DefaultConfig()
+	if len(cfg) > 0 {
+		c = cfg[0]
+
+		if c.Table == "" {
+			c.Table = DefaultTable
+		}
+
+		if c.Dialect == nil {
+			c.Dialect = SQLite()
+		}
+
+		if c.CleanupInterval == 0 {
+			c.CleanupInterval = DefaultCleanupInterval
+		}
+
+		if c.CleanupBatchSize <= 0 {
+			c.CleanupBatchSize = DefaultCleanupBatchSize
+		}
+	}
+
+	for _, stmt := range c.Dialect.Schema(c.Table) {
+		if _, err := service.Exec(stmt); err != nil {
+			golog.Errorf("unable to create the session tables: %v", err)
+			return nil, err
+		}
+	}
+
+	db := &Database{
+		c:       c,
+		Service: service,
+		q:       newQueries(c.Table, c.Dialect),
+		stop:    make(chan struct{}),
+	}
+
+	if err := db.Cleanup(); err != nil {
+		return db, err
+	}
+
+	if c.CleanupInterval > 0 {
+		go db.cleanupEvery(c.CleanupInterval)
+	}
+
+	return db, nil
+}
+
+type queries struct {
+	acquire, insert, updateExpiration                       string
+	set, get, visit, count, delete, clear, release          string
+	regenerate, regenerateValues                            string
+	setInfo, getInfo, deleteInfo, listByUser, expiredInfo   string
+	expired, deleteValuesIn, deleteInfoIn, deleteSessionsIn string
+}
+
+func newQueries(table string, d Dialect) queries {
+	values, infos := table+"_values", table+"_info"
+
+	return queries{
+		acquire:          rebind(d, "SELECT expires_at FROM "+table+" WHERE sid = ?"),
+		insert:           rebind(d, "INSERT INTO "+table+" (sid, expires_at) VALUES (?, ?)"),
+		updateExpiration: rebind(d, "UPDATE "+table+" SET expires_at = ? WHERE sid = ?"),
+		set:              rebind(d, d.Upsert(values, []string{"sid", "name"}, []string{"value"})),
+		get:              rebind(d, "SELECT value FROM "+values+" WHERE sid = ? AND name = ?"),
+		visit:            rebind(d, "SELECT name, value FROM "+values+" WHERE sid = ?"),
+		count:            rebind(d, "SELECT COUNT(*) FROM "+values+" WHERE sid = ?"),
+		delete:           rebind(d, "DELETE FROM "+values+" WHERE sid = ? AND name = ?"),
+		clear:            rebind(d, "DELETE FROM "+values+" WHERE sid = ?"),
+		release:          rebind(d, "DELETE FROM "+table+" WHERE sid = ?"),
+		regenerate:       rebind(d, "UPDATE "+table+" SET sid = ? WHERE sid = ?"),
+		regenerateValues: rebind(d, "UPDATE "+values+" SET sid = ? WHERE sid = ?"),
+		setInfo:          rebind(d, d.Upsert(infos, []string{"sid"}, []string{"user_id", "info", "expires_at"})),
+		getInfo:          rebind(d, "SELECT info FROM "+infos+" WHERE sid = ?"),
+		deleteInfo:       rebind(d, "DELETE FROM "+infos+" WHERE sid = ?"),
+		listByUser:       rebind(d, "SELECT info FROM "+infos+" WHERE user_id = ? AND (expires_at = 0 OR expires_at > ?)"),
+		expiredInfo:      rebind(d, "DELETE FROM "+infos+" WHERE expires_at > 0 AND expires_at < ?"),
+		expired:          rebind(d, "SELECT sid FROM "+table+" WHERE expires_at > 0 AND expires_at < ? LIMIT ?"),
+		deleteValuesIn:   "DELETE FROM " + values + " WHERE sid IN (%s)",
+		deleteInfoIn:     "DELETE FROM " + infos + " WHERE sid IN (%s)",
+		deleteSessionsIn: "DELETE FROM " + table + " WHERE sid IN (%s)",
+	}
+}
+
+// rebind replaces the question marks of the "query" with the bind parameters of the "d" dialect.
+func rebind(d Dialect, query string) string {
+	if d.Placeholder(1) == "?" {
+		return query
+	}
+
+	var (
+		b strings.Builder
+		n int
+	)
+
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.Placeholder(n))
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// in returns the "query" with "n" bind parameters in place of its "%s".
+func (db *Database) in(query string, n int) string {
+	return rebind(db.c.Dialect, strings.Replace(query, "%s", strings.TrimSuffix(strings.Repeat("?, ", n), ", "), 1))
+}
+
+// expiresAt returns the unix nanoseconds of the "expires" from now, 0 for unlimited lifetime.
+func expiresAt(expires time.Duration) int64 {
+	if expires <= 0 {
+		return 0
+	}
+
+	return time.Now().Add(expires).UnixNano()
+}
+
+// Acquire receives a session's lifetime from the database,
+// if the return value is LifeTime{} then the session manager sets the life time based on the expiration duration lives in configuration.
+func (db *Database) Acquire(sid string, expires time.Duration) sessions.LifeTime {
+	var nanos int64
+	err := db.Service.QueryRow(db.q.acquire, sid).Scan(&nanos)
+	if err == nil {
+		if nanos == 0 {
+			return sessions.LifeTime{} // does not expire.
+		}
+
+		if expirationTime := time.Unix(0, nanos); expirationTime.After(time.Now()) {
+			return sessions.LifeTime{Time: expirationTime}
+		}
+
+		// expired but not removed by the cleanup yet.
+		db.Release(sid)
+		db.notifyExpired([]string{sid})
+	} else if err != sql.ErrNoRows {
+		golog.Debugf("unable to acquire session '%s': %v", sid, err)
+		return sessions.LifeTime{}
+	}
+
+	// not found, create a session entry and return an empty lifetime, session manager will do its job.
+	if _, err = db.Service.Exec(db.q.insert, sid, expiresAt(expires)); err != nil {
+		golog.Debugf("unable to create session '%s': %v", sid, err)
+	}
+
+	return sessions.LifeTime{}
+}
+
+// OnUpdateExpiration will re-set the database's session's entry expiration.
+func (db *Database) OnUpdateExpiration(sid string, newExpires time.Duration) error {
+	res, err := db.Service.Exec(db.q.updateExpiration, expiresAt(newExpires), sid)
+	if err != nil {
+		golog.Debugf("unable to reset the expiration value for '%s': %v", sid, err)
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sessions.ErrNotFound
+	}
+
+	return nil
+}
+
+// Set sets a key value of a specific session.
+// Ignore the "immutable".
+func (db *Database) Set(sid string, lifetime sessions.LifeTime, key string, value interface{}, immutable bool) {
+	valueBytes, err := sessions.DefaultTranscoder.Marshal(value)
+	if err != nil {
+		golog.Debug(err)
+		return
+	}
+
+	// expiration is handled by the session entry, see `Acquire` and `Cleanup`.
+	if _, err = db.Service.Exec(db.q.set, sid, key, valueBytes); err != nil {
+		golog.Debugf("unable to set session '%s' key '%s': %v", sid, key, err)
+	}
+}
+
+// Get retrieves a session value based on the key.
+func (db *Database) Get(sid string, key string) (value interface{}) {
+	var valueBytes []byte
+	if err := db.Service.QueryRow(db.q.get, sid, key).Scan(&valueBytes); err != nil {
+		if err != sql.ErrNoRows {
+			golog.Debugf("unable to get session '%s' key '%s': %v", sid, key, err)
+		}
+		return
+	}
+
+	if err := sessions.DefaultTranscoder.Unmarshal(valueBytes, &value); err != nil {
+		golog.Debugf("unable to unmarshal value of key: '%s': %v", key, err)
+	}
+
+	return
+}
+
+// Visit loops through all session keys and values.
+func (db *Database) Visit(sid string, cb func(key string, value interface{})) {
+	rows, err := db.Service.Query(db.q.visit, sid)
+	if err != nil {
+		golog.Debugf("unable to get all values of session '%s': %v", sid, err)
+		return
+	}
+
+	type entry struct {
+		key        string
+		valueBytes []byte
+	}
+
+	// read all rows before calling the "cb", it may use the database too.
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err = rows.Scan(&e.key, &e.valueBytes); err != nil {
+			golog.Debugf("unable to retrieve a value of '%s': %v", sid, err)
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+	rows.Close()
+
+	for _, e := range entries {
+		var value interface{} // new value each time, we don't know what user will do in "cb".
+		if err = sessions.DefaultTranscoder.Unmarshal(e.valueBytes, &value); err != nil {
+			golog.Debugf("unable to retrieve value of key '%s' of '%s': %v", e.key, sid, err)
+			continue
+		}
+
+		cb(e.key, value)
+	}
+}
+
+// Len returns the length of the session's entries (keys).
+func (db *Database) Len(sid string) (n int) {
+	if err := db.Service.QueryRow(db.q.count, sid).Scan(&n); err != nil {
+		golog.Debugf("unable to count the values of session '%s': %v", sid, err)
+	}
+
+	return
+}
+
+// Delete removes a session key value based on its key.
+func (db *Database) Delete(sid string, key string) (deleted bool) {
+	res, err := db.Service.Exec(db.q.delete, sid, key)
+	if err != nil {
+		golog.Debugf("unable to delete session '%s' key '%s': %v", sid, key, err)
+		return false
+	}
+
+	n, err := res.RowsAffected()
+	return err == nil && n > 0
+}
+
+// Clear removes all session key values but it keeps the session entry.
+func (db *Database) Clear(sid string) {
+	if _, err := db.Service.Exec(db.q.clear, sid); err != nil {
+		golog.Debugf("unable to clear session '%s': %v", sid, err)
+	}
+}
+
+// Release destroys the session, it clears and removes the session entry,
+// session manager will create a new session ID on the next request after this call.
+func (db *Database) Release(sid string) {
+	err := db.tx(func(tx *sql.Tx) error {
+		// clear all values, the metadata, if bound to a user, and the session entry.
+		for _, query := range []string{db.q.clear, db.q.deleteInfo, db.q.release} {
+			if _, err := tx.Exec(query, sid); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		golog.Debugf("unable to release session '%s': %v", sid, err)
+	}
+}
+
+// Regenerate moves the values of the "oldSid" session to the "newSid" one,
+// with its expiration, in a single transaction.
+func (db *Database) Regenerate(oldSid, newSid string) error {
+	return db.tx(func(tx *sql.Tx) error {
+		res, err := tx.Exec(db.q.regenerate, newSid, oldSid)
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return sessions.ErrNotFound
+		}
+
+		_, err = tx.Exec(db.q.regenerateValues, newSid, oldSid)
+		return err
+	})
+}
+
+// SetInfo saves the metadata of the "info.ID" session and indexes it by the "info.User".
+func (db *Database) SetInfo(info sessions.SessionInfo) error {
+	infoBytes, err := sessions.DefaultTranscoder.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	var nanos int64
+	if !info.Expires.IsZero() {
+		nanos = info.Expires.UnixNano()
+	}
+
+	_, err = db.Service.Exec(db.q.setInfo, info.ID, info.User, infoBytes, nanos)
+	return err
+}
+
+// GetInfo returns the metadata of the session of the "sid", if any.
+func (db *Database) GetInfo(sid string) (info sessions.SessionInfo, found bool) {
+	var infoBytes []byte
+	if err := db.Service.QueryRow(db.q.getInfo, sid).Scan(&infoBytes); err != nil {
+		return
+	}
+
+	if err := sessions.DefaultTranscoder.Unmarshal(infoBytes, &info); err != nil {
+		golog.Debugf("unable to unmarshal the info of session '%s': %v", sid, err)
+		return
+	}
+
+	return info, true
+}
+
+// DeleteInfo removes the metadata of the session of the "sid" and its user index.
+func (db *Database) DeleteInfo(sid string) error {
+	_, err := db.Service.Exec(db.q.deleteInfo, sid)
+	return err
+}
+
+// ListByUser returns the metadata of the sessions of the "user".
+func (db *Database) ListByUser(user string) ([]sessions.SessionInfo, error) {
+	rows, err := db.Service.Query(db.q.listByUser, user, time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var infos []sessions.SessionInfo
+	for rows.Next() {
+		var infoBytes []byte
+		if err = rows.Scan(&infoBytes); err != nil {
+			return nil, err
+		}
+
+		var info sessions.SessionInfo
+		if err = sessions.DefaultTranscoder.Unmarshal(infoBytes, &info); err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, rows.Err()
+}
+
+// OnExpire registers the "listener" of the sessions which are removed by the `Cleanup`.
+// It fails if the periodic cleanup is disabled, see `Config.CleanupInterval`.
+func (db *Database) OnExpire(listener func(sid string)) error {
+	if db.c.CleanupInterval < 0 {
+		return errCleanupDisabled
+	}
+
+	db.mu.Lock()
+	db.expireListeners = append(db.expireListeners, listener)
+	db.mu.Unlock()
+	return nil
+}
+
+// Cleanup removes the expired sessions, their values and their metadata,
+// in transactions of `Config.CleanupBatchSize` sessions.
+// It's called on `New` and every `Config.CleanupInterval`.
+func (db *Database) Cleanup() error {
+	now := time.Now().UnixNano()
+
+	for {
+		sids, err := db.expired(now)
+		if err != nil {
+			return err
+		}
+
+		if len(sids) == 0 {
+			break
+		}
+
+		args := make([]interface{}, len(sids))
+		for i, sid := range sids {
+			args[i] = sid
+		}
+
+		err = db.tx(func(tx *sql.Tx) error {
+			for _, query := range []string{db.q.deleteValuesIn, db.q.deleteInfoIn, db.q.deleteSessionsIn} {
+				if _, err := tx.Exec(db.in(query, len(args)), args...); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		db.notifyExpired(sids)
+
+		if len(sids) < db.c.CleanupBatchSize {
+			break
+		}
+	}
+
+	// and any metadata which outlived its session.
+	_, err := db.Service.Exec(db.q.expiredInfo, now)
+	return err
+}
+
+// notifyExpired calls the `OnExpire` listeners for each of the "sids".
+func (db *Database) notifyExpired(sids []string) {
+	db.mu.Lock()
+	listeners := db.expireListeners
+	db.mu.Unlock()
+
+	for _, sid := range sids {
+		for _, listener := range listeners {
+			listener(sid)
+		}
+	}
+}
+
+func (db *Database) expired(now int64) ([]string, error) {
+	rows, err := db.Service.Query(db.q.expired, now, db.c.CleanupBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sids []string
+	for rows.Next() {
+		var sid string
+		if err = rows.Scan(&sid); err != nil {
+			return nil, err
+		}
+
+		sids = append(sids, sid)
+	}
+
+	return sids, rows.Err()
+}
+
+func (db *Database) cleanupEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stop:
+			return
+		case <-ticker.C:
+			if err := db.Cleanup(); err != nil {
+				golog.Debugf("unable to remove the expired sessions: %v", err)
+			}
+		}
+	}
+}
+
+// tx runs the "fn" inside a transaction, it's rolled back if "fn" returns an error.
+func (db *Database) tx(fn func(tx *sql.Tx) error) error {
+	tx, err := db.Service.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Close stops the periodic cleanup and closes the SQL database connection.
+func (db *Database) Close() (err error) {
+	db.closeOnce.Do(func() {
+		close(db.stop)
+
+		if err = db.Service.Close(); err != nil {
+			golog.Warnf("closing the SQL connection: %v", err)
+		}
+	})
+
+	return
+}
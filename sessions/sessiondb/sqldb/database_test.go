@@ -0,0 +1,196 @@
+package sqldb_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/radiantrfid/iris"
+	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/httptest"
+	"github.com/radiantrfid/iris/sessions"
+	"github.com/radiantrfid/iris/sessions/sessiondb/sqldb"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newDatabase(t *testing.T, cfg ...sqldb.Config) *sqldb.Database {
+	service, err := sql.Open("sqlite3", "file:"+t.Name()+"?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a single connection keeps the in-memory database alive.
+	service.SetMaxOpenConns(1)
+
+	db, err := sqldb.New(service, cfg...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestDatabase(t *testing.T) {
+	db := newDatabase(t)
+	defer db.Close()
+
+	sess := sessions.New(sessions.Config{Cookie: "mycustomsessionid", Expires: time.Hour})
+	sess.UseDatabase(db)
+
+	app := iris.New()
+	app.Get("/set/{key}/{value}", func(ctx context.Context) {
+		sess.Start(ctx).Set(ctx.Params().Get("key"), ctx.Params().Get("value"))
+	})
+
+	app.Get("/get/{key}", func(ctx context.Context) {
+		ctx.WriteString(sess.Start(ctx).GetString(ctx.Params().Get("key")))
+	})
+
+	app.Get("/len", func(ctx context.Context) {
+		ctx.Writef("%d", sess.Start(ctx).Len())
+	})
+
+	app.Get("/delete/{key}", func(ctx context.Context) {
+		sess.Start(ctx).Delete(ctx.Params().Get("key"))
+	})
+
+	app.Get("/destroy", func(ctx context.Context) {
+		sess.Destroy(ctx)
+	})
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	e.GET("/set/name/iris").Expect().Status(iris.StatusOK)
+	e.GET("/set/name/kataras").Expect().Status(iris.StatusOK)
+	e.GET("/set/lang/go").Expect().Status(iris.StatusOK)
+	e.GET("/get/name").Expect().Status(iris.StatusOK).Body().Equal("kataras")
+	e.GET("/len").Expect().Status(iris.StatusOK).Body().Equal("2")
+
+	e.GET("/delete/lang").Expect().Status(iris.StatusOK)
+	e.GET("/len").Expect().Status(iris.StatusOK).Body().Equal("1")
+
+	e.GET("/destroy").Expect().Status(iris.StatusOK)
+	e.GET("/get/name").Expect().Status(iris.StatusOK).Body().Empty()
+}
+
+func TestDatabaseRegenerate(t *testing.T) {
+	db := newDatabase(t)
+	defer db.Close()
+
+	db.Acquire("old", time.Hour)
+	db.Set("old", sessions.LifeTime{}, "name", "iris", false)
+
+	if err := db.Regenerate("old", "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := db.Get("new", "name"); got != "iris" {
+		t.Fatalf("expected the value to be moved to the new session but got: %v", got)
+	}
+
+	if got := db.Len("old"); got != 0 {
+		t.Fatalf("expected the old session to be empty but got %d values", got)
+	}
+
+	if err := db.Regenerate("old", "other"); !sessions.ErrNotFound.Equal(err) {
+		t.Fatalf("expected an ErrNotFound but got: %v", err)
+	}
+}
+
+func TestDatabaseUsers(t *testing.T) {
+	db := newDatabase(t)
+	defer db.Close()
+
+	expires := time.Now().Add(time.Hour)
+	for _, info := range []sessions.SessionInfo{
+		{ID: "desktop", User: "kataras", UserAgent: "desktop", Expires: expires},
+		{ID: "mobile", User: "kataras", UserAgent: "mobile"},
+		{ID: "other", User: "makis"},
+		{ID: "expired", User: "kataras", Expires: time.Now().Add(-time.Minute)},
+	} {
+		db.Acquire(info.ID, time.Hour)
+		if err := db.SetInfo(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	infos, err := db.ListByUser("kataras")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 sessions but got: %v", infos)
+	}
+
+	// updates the same session.
+	db.SetInfo(sessions.SessionInfo{ID: "mobile", User: "makis"})
+	if info, found := db.GetInfo("mobile"); !found || info.User != "makis" {
+		t.Fatalf("expected the updated info but got: %v", info)
+	}
+
+	db.Release("desktop")
+	if infos, _ = db.ListByUser("kataras"); len(infos) != 0 {
+		t.Fatalf("expected no sessions but got: %v", infos)
+	}
+}
+
+func TestDatabaseCleanup(t *testing.T) {
+	db := newDatabase(t, sqldb.Config{CleanupInterval: -1, CleanupBatchSize: 2})
+	defer db.Close()
+
+	for _, sid := range []string{"a", "b", "c", "d", "e"} {
+		db.Acquire(sid, time.Millisecond)
+		db.Set(sid, sessions.LifeTime{}, "name", "iris", false)
+	}
+	db.Acquire("alive", time.Hour)
+	db.Set("alive", sessions.LifeTime{}, "name", "iris", false)
+
+	time.Sleep(5 * time.Millisecond)
+	if err := db.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sid := range []string{"a", "b", "c", "d", "e"} {
+		if n := db.Len(sid); n != 0 {
+			t.Fatalf("expected the expired session '%s' to be removed but it has %d values", sid, n)
+		}
+	}
+
+	if got := db.Get("alive", "name"); got != "iris" {
+		t.Fatalf("expected the session to be kept but got: %v", got)
+	}
+
+	// expired sessions are not acquired.
+	if lifetime := db.Acquire("a", time.Hour); !lifetime.IsZero() {
+		t.Fatalf("expected a new session but got a lifetime of: %v", lifetime)
+	}
+}
+
+func TestDatabaseOnExpire(t *testing.T) {
+	db := newDatabase(t, sqldb.Config{CleanupInterval: 10 * time.Millisecond})
+	defer db.Close()
+
+	expired := make(chan string, 1)
+	if err := db.OnExpire(func(sid string) { expired <- sid }); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Acquire("a", 20*time.Millisecond)
+	db.Acquire("alive", time.Hour)
+
+	select {
+	case sid := <-expired:
+		if sid != "a" {
+			t.Fatalf("expected the expiration of 'a' but got '%s'", sid)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the session to be expired")
+	}
+
+	// the periodic cleanup can not be notified when it's disabled.
+	disabled := newDatabase(t, sqldb.Config{CleanupInterval: -1})
+	defer disabled.Close()
+
+	if err := disabled.OnExpire(func(string) {}); err == nil {
+		t.Fatal("expected an error when the cleanup is disabled")
+	}
+}
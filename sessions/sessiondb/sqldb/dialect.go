@@ -0,0 +1,104 @@
+package sqldb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect describes the differences of the SQL servers,
+// see `SQLite`, `Postgres` and `MySQL`.
+type Dialect interface {
+	// Placeholder returns the bind parameter of the "n"th argument of a statement, starting from 1.
+	Placeholder(n int) string
+	// Schema returns the statements which create the "table",
+	// its "table_values" and "table_info" tables and their indexes, if they do not exist.
+	Schema(table string) []string
+	// Upsert returns a statement which inserts the "keys" and the "columns" to the "table"
+	// or updates the "columns" if a row with the same "keys" already exists.
+	// Its bind parameters are question marks.
+	Upsert(table string, keys, columns []string) string
+}
+
+type dialect struct {
+	numbered bool   // $1, $2... instead of ?.
+	blob     string // the binary column type.
+	// the indexes are declared inside the create table statements
+	// and the upsert is an "ON DUPLICATE KEY UPDATE" one.
+	mysql bool
+}
+
+var (
+	_ Dialect = (*dialect)(nil)
+
+	sqliteDialect   = &dialect{blob: "BLOB"}
+	postgresDialect = &dialect{numbered: true, blob: "BYTEA"}
+	mysqlDialect    = &dialect{blob: "MEDIUMBLOB", mysql: true}
+)
+
+// SQLite returns the dialect of the SQLite 3.24+ databases, it's the default one.
+func SQLite() Dialect { return sqliteDialect }
+
+// Postgres returns the dialect of the PostgreSQL 9.5+ databases.
+func Postgres() Dialect { return postgresDialect }
+
+// MySQL returns the dialect of the MySQL 5.7+ and MariaDB databases.
+func MySQL() Dialect { return mysqlDialect }
+
+func (d *dialect) Placeholder(n int) string {
+	if d.numbered {
+		return fmt.Sprintf("$%d", n)
+	}
+
+	return "?"
+}
+
+func (d *dialect) Schema(table string) []string {
+	var (
+		expiresIndex = fmt.Sprintf("%s_expires_at", table)
+		userIndex    = fmt.Sprintf("%s_info_user_id", table)
+		sessions     = "sid VARCHAR(255) NOT NULL PRIMARY KEY, expires_at BIGINT NOT NULL DEFAULT 0"
+		values       = fmt.Sprintf("sid VARCHAR(255) NOT NULL, name VARCHAR(255) NOT NULL, value %s, PRIMARY KEY (sid, name)", d.blob)
+		infos        = fmt.Sprintf("sid VARCHAR(255) NOT NULL PRIMARY KEY, user_id VARCHAR(255) NOT NULL, info %s, expires_at BIGINT NOT NULL DEFAULT 0", d.blob)
+	)
+
+	if d.mysql {
+		sessions += fmt.Sprintf(", INDEX %s (expires_at)", expiresIndex)
+		infos += fmt.Sprintf(", INDEX %s (user_id)", userIndex)
+	}
+
+	stmts := []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table, sessions),
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s_values (%s)", table, values),
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s_info (%s)", table, infos),
+	}
+
+	if !d.mysql {
+		stmts = append(stmts,
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (expires_at)", expiresIndex, table),
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s_info (user_id)", userIndex, table),
+		)
+	}
+
+	return stmts
+}
+
+func (d *dialect) Upsert(table string, keys, columns []string) string {
+	all := append(append([]string{}, keys...), columns...)
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table,
+		strings.Join(all, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(all)), ", "))
+
+	updates := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if d.mysql {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", column, column))
+		} else {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+		}
+	}
+
+	if d.mysql {
+		return fmt.Sprintf("%s ON DUPLICATE KEY UPDATE %s", insert, strings.Join(updates, ", "))
+	}
+
+	return fmt.Sprintf("%s ON CONFLICT (%s) DO UPDATE SET %s", insert, strings.Join(keys, ", "), strings.Join(updates, ", "))
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/radiantrfid/iris/context"
+
+	"github.com/kataras/golog"
 )
 
 // A Sessions manager should be responsible to Start a sesion, based
@@ -15,8 +17,9 @@ import (
 // Sessions should be responsible to Destroy a session based
 // on the Context.
 type Sessions struct {
-	config   Config
-	provider *provider
+	config      Config
+	provider    *provider
+	cookieStore *CookieStore
 }
 
 // New returns a new fast, feature-rich sessions manager
@@ -36,12 +39,19 @@ func (s *Sessions) UseDatabase(db Database) {
 
 // updateCookie gains the ability of updating the session browser cookie to any method which wants to update it
 func (s *Sessions) updateCookie(ctx context.Context, sid string, expires time.Duration, options ...context.CookieOption) {
+	// encode the session id cookie client value right before send it.
+	cookie := s.newCookie(ctx, s.config.Cookie, s.encodeCookieValue(sid), expires, options)
+	AddCookie(ctx, cookie, s.config.AllowReclaim)
+}
+
+// newCookie returns a session cookie of the "name" and "value", it's used for the stateless sessions' cookies as well.
+func (s *Sessions) newCookie(ctx context.Context, name, value string, expires time.Duration, options []context.CookieOption) *http.Cookie {
 	cookie := &http.Cookie{}
 
 	// The RFC makes no mention of encoding url value, so here I think to encode both sessionid key and the value using the safe(to put and to use as cookie) url-encoding
-	cookie.Name = s.config.Cookie
+	cookie.Name = name
 
-	cookie.Value = sid
+	cookie.Value = value
 	cookie.Path = "/"
 	cookie.Domain = formatCookieDomain(ctx, s.config.DisableSubdomainPersistence)
 	cookie.HttpOnly = true
@@ -67,35 +77,139 @@ func (s *Sessions) updateCookie(ctx context.Context, sid string, expires time.Du
 		cookie.Secure = true
 	}
 
-	// encode the session id cookie client value right before send it.
-	cookie.Value = s.encodeCookieValue(cookie.Value)
-
 	for _, opt := range options {
 		opt(cookie)
 	}
 
-	AddCookie(ctx, cookie, s.config.AllowReclaim)
+	return cookie
 }
 
 // Start creates or retrieves an existing session for the particular request.
 func (s *Sessions) Start(ctx context.Context, cookieOptions ...context.CookieOption) *Session {
-	cookieValue := s.decodeCookieValue(GetCookie(ctx, s.config.Cookie))
+	if s.cookieStore != nil {
+		sess := s.startStateless(ctx, true, cookieOptions...)
+		s.regenerateExpired(ctx, sess, cookieOptions)
+		s.slide(ctx, sess, cookieOptions)
+		s.touch(ctx, sess)
+		return sess
+	}
+
+	expires := s.initialExpires()
+
+	if cookieValue := s.sessionID(ctx); cookieValue != "" {
+		sess := s.provider.Read(cookieValue, expires)
+		if !s.exceeded(sess) {
+			s.regenerateExpired(ctx, sess, cookieOptions)
+			s.slide(ctx, sess, cookieOptions)
+			s.touch(ctx, sess)
+			s.fireStart(ctx, EventRead, sess.ID())
+			return sess
+		}
+
+		// the maximum lifetime is exceeded, start a new session.
+		s.provider.expire(sess.ID())
+		ctx.Values().Remove(contextSessionIDKey)
+	}
 
-	if cookieValue == "" { // cookie doesn't exist, let's generate a session and set a cookie.
-		sid := s.config.SessionIDGenerator(ctx)
+	// cookie doesn't exist, let's generate a session and set a cookie.
+	sid := s.config.SessionIDGenerator(ctx)
 
-		sess := s.provider.Init(sid, s.config.Expires)
-		sess.isNew = s.provider.db.Len(sid) == 0
+	sess := s.provider.Init(sid, expires)
+	sess.isNew = s.provider.db.Len(sid) == 0
+	ctx.Values().Set(contextSessionIDKey, sid)
 
-		s.updateCookie(ctx, sid, s.config.Expires, cookieOptions...)
+	s.updateCookie(ctx, sid, s.cookieExpires(sess, expires), cookieOptions...)
+	s.touch(ctx, sess)
+	s.fireStart(ctx, EventCreate, sid)
 
-		return sess
+	return sess
+}
+
+const (
+	contextSessionKey = "_iris_session"
+	// contextSessionIDKey keeps the new or the regenerated session id of a request,
+	// the request's cookie still holds none or the old one.
+	contextSessionIDKey = "_iris_session_id"
+)
+
+// sessionID returns the session id of the request, it's empty if the request has no session.
+func (s *Sessions) sessionID(ctx context.Context) string {
+	if sid := ctx.Values().GetString(contextSessionIDKey); sid != "" {
+		return sid
 	}
 
-	return s.provider.Read(cookieValue, s.config.Expires)
+	return s.decodeCookieValue(GetCookie(ctx, s.config.Cookie))
+}
+
+// Regenerate generates a new id for the session of the request and moves its values to it,
+// the old id is not valid anymore and the client's cookie is updated.
+// It starts a new session if the request has not one.
+//
+// Call it when the privileges of the session change, i.e on login or logout,
+// to protect against session fixation attacks.
+// See the `Config.RegenerateEvery` for periodic regeneration too.
+//
+// Example Code:
+//
+//	func login(ctx iris.Context) {
+//		// [authenticate the user...]
+//		sess, err := sessionsManager.Regenerate(ctx)
+//		if err != nil { ... }
+//		sess.Set("user_id", userID)
+//	}
+func (s *Sessions) Regenerate(ctx context.Context, cookieOptions ...context.CookieOption) (*Session, error) {
+	sess := s.Start(ctx, cookieOptions...)
+	return sess, s.regenerate(ctx, sess, cookieOptions)
 }
 
-const contextSessionKey = "_iris_session"
+func (s *Sessions) regenerate(ctx context.Context, sess *Session, cookieOptions []context.CookieOption) error {
+	newSid := s.config.SessionIDGenerator(ctx)
+
+	if sess.cookie != nil {
+		sess.mu.Lock()
+		sess.sid = newSid
+		sess.regeneratedAt = time.Now()
+		sess.info.ID = newSid
+		sess.mu.Unlock()
+
+		sess.save()
+		return nil
+	}
+
+	sid, err := s.provider.Regenerate(sess, sess.ID(), newSid)
+	if err != nil {
+		return err
+	}
+
+	ctx.Values().Set(contextSessionIDKey, sid)
+
+	expires := s.config.Expires
+	if !sess.Lifetime.IsZero() {
+		expires = sess.Lifetime.DurationUntilExpiration()
+	}
+
+	s.updateCookie(ctx, sid, expires, cookieOptions...)
+	return nil
+}
+
+// regenerateExpired regenerates the session id if it's older than the `Config.RegenerateEvery`.
+func (s *Sessions) regenerateExpired(ctx context.Context, sess *Session, cookieOptions []context.CookieOption) {
+	if s.config.RegenerateEvery <= 0 {
+		return
+	}
+
+	sess.mu.RLock()
+	age := time.Since(sess.regeneratedAt)
+	sess.mu.RUnlock()
+
+	if age < s.config.RegenerateEvery {
+		return
+	}
+
+	if err := s.regenerate(ctx, sess, cookieOptions); err != nil {
+		golog.Debugf("sessions: unable to regenerate the session id: %v", err)
+	}
+}
 
 // Handler returns a sessions middleware to register on application routes.
 func (s *Sessions) Handler(cookieOptions ...context.CookieOption) context.Handler {
@@ -140,20 +254,137 @@ func (s *Sessions) ShiftExpiration(ctx context.Context, cookieOptions ...context
 // It will return `ErrNotFound` when trying to update expiration on a non-existence or not valid session entry.
 // It will return `ErrNotImplemented` if a database is used and it does not support this feature, yet.
 func (s *Sessions) UpdateExpiration(ctx context.Context, expires time.Duration, cookieOptions ...context.CookieOption) error {
-	cookieValue := s.decodeCookieValue(GetCookie(ctx, s.config.Cookie))
+	if s.cookieStore != nil {
+		return s.updateStatelessExpiration(ctx, expires, cookieOptions...)
+	}
+
+	cookieValue := s.sessionID(ctx)
 	if cookieValue == "" {
 		return ErrNotFound
 	}
 
+	// the server-side lifetime can not exceed the maximum one.
+	serverExpires := expires
+	if sess, found := s.provider.find(cookieValue); found {
+		serverExpires = s.limitExpires(sess, expires)
+	}
+
 	// we should also allow it to expire when the browser closed
-	err := s.provider.UpdateExpiration(cookieValue, expires)
+	err := s.provider.UpdateExpiration(cookieValue, serverExpires)
 	if err == nil || expires == -1 {
+		if expires >= 0 {
+			expires = serverExpires
+		}
 		s.updateCookie(ctx, cookieValue, expires, cookieOptions...)
 	}
 
 	return err
 }
 
+// Remember sets a "remember me" lifetime to the session of the request, i.e on login.
+// It replaces the `Config.IdleTimeout`, or the `Config.Expires` if there is no idle timeout,
+// of that session: its lifetime is extended to "d" by each `Start`
+// and its cookie is kept even if the `Config.Expires` is -1.
+// The `Config.MaxLifetime` still applies. A zero "d" restores the configured lifetime.
+//
+// The "remember me" lifetime of a server-side session is kept by the database
+// only if the session is bound to a user and the database implements the `UserIndex`, see `Session.SetUser`.
+func (s *Sessions) Remember(ctx context.Context, d time.Duration, cookieOptions ...context.CookieOption) error {
+	sess := s.Start(ctx, cookieOptions...)
+
+	if d < 0 {
+		d = 0
+	}
+
+	sess.mu.Lock()
+	sess.info.Remember = d
+	sess.mu.Unlock()
+
+	expires := d
+	if expires == 0 {
+		if expires = s.config.IdleTimeout; expires <= 0 {
+			expires = s.config.Expires
+		}
+	}
+
+	return s.UpdateExpiration(ctx, expires, cookieOptions...)
+}
+
+// initialExpires returns the lifetime of a new session.
+func (s *Sessions) initialExpires() time.Duration {
+	expires := s.config.Expires
+	if s.config.IdleTimeout > 0 {
+		expires = s.config.IdleTimeout
+	}
+
+	if s.config.MaxLifetime > 0 && (expires <= 0 || expires > s.config.MaxLifetime) {
+		expires = s.config.MaxLifetime
+	}
+
+	return expires
+}
+
+// limitExpires returns the "expires" limited to the remaining of the `Config.MaxLifetime` of the "sess".
+func (s *Sessions) limitExpires(sess *Session, expires time.Duration) time.Duration {
+	if s.config.MaxLifetime <= 0 {
+		return expires
+	}
+
+	remaining := time.Until(sess.Info().CreatedAt.Add(s.config.MaxLifetime))
+	if expires <= 0 || expires > remaining {
+		return remaining
+	}
+
+	return expires
+}
+
+// exceeded reports whether the "sess" has exceeded the `Config.MaxLifetime`.
+func (s *Sessions) exceeded(sess *Session) bool {
+	return s.config.MaxLifetime > 0 && time.Since(sess.Info().CreatedAt) >= s.config.MaxLifetime
+}
+
+// cookieExpires returns the expiration of the cookie of the "sess" which lives for "expires".
+func (s *Sessions) cookieExpires(sess *Session, expires time.Duration) time.Duration {
+	if s.config.Expires < 0 && sess.Info().Remember <= 0 {
+		return -1 // removed when the browser closes.
+	}
+
+	return expires
+}
+
+// slide extends the lifetime of the "sess" by its idle timeout, see `Config.IdleTimeout` and `Remember`.
+func (s *Sessions) slide(ctx context.Context, sess *Session, cookieOptions []context.CookieOption) {
+	idle := sess.Info().Remember
+	if idle <= 0 {
+		idle = s.config.IdleTimeout
+	}
+
+	if idle <= 0 || sess.Lifetime.IsZero() {
+		return
+	}
+
+	expires := s.limitExpires(sess, idle)
+	// the lifetime can be shorter by the 1/10 of the idle timeout at most,
+	// so the session is not written on each request.
+	if expires-sess.Lifetime.DurationUntilExpiration() < idle/10 {
+		return
+	}
+
+	if sess.cookie != nil {
+		sess.Lifetime.Time = time.Now().Add(expires)
+		sess.save()
+		return
+	}
+
+	sid := sess.ID()
+	if err := s.provider.UpdateExpiration(sid, expires); err != nil {
+		golog.Debugf("sessions: unable to extend the lifetime of '%s': %v", sid, err)
+		return
+	}
+
+	s.updateCookie(ctx, sid, s.cookieExpires(sess, expires), cookieOptions...)
+}
+
 // DestroyListener is the form of a destroy listener.
 // Look `OnDestroy` for more.
 type DestroyListener func(sid string)
@@ -170,14 +401,19 @@ func (s *Sessions) OnDestroy(listeners ...DestroyListener) {
 
 // Destroy remove the session data and remove the associated cookie.
 func (s *Sessions) Destroy(ctx context.Context) {
-	cookieValue := GetCookie(ctx, s.config.Cookie)
+	if s.cookieStore != nil {
+		s.destroyStateless(ctx)
+		return
+	}
+
 	// decode the client's cookie value in order to find the server's session id
 	// to destroy the session data.
-	cookieValue = s.decodeCookieValue(cookieValue)
+	cookieValue := s.sessionID(ctx)
 	if cookieValue == "" { // nothing to destroy
 		return
 	}
 	RemoveCookie(ctx, s.config)
+	ctx.Values().Remove(contextSessionIDKey)
 
 	s.provider.Destroy(cookieValue)
 }
@@ -190,6 +426,8 @@ func (s *Sessions) Destroy(ctx context.Context) {
 //
 // Note: the sid should be the original one (i.e: fetched by a store )
 // it's not decoded.
+//
+// It has no effect on the stateless sessions of a `CookieStore`.
 func (s *Sessions) DestroyByID(sid string) {
 	s.provider.Destroy(sid)
 }
@@ -197,6 +435,8 @@ func (s *Sessions) DestroyByID(sid string) {
 // DestroyAll removes all sessions
 // from the server-side memory (and database if registered).
 // Client's session cookie will still exist but it will be reseted on the next request.
+//
+// It has no effect on the stateless sessions of a `CookieStore`.
 func (s *Sessions) DestroyAll() {
 	s.provider.DestroyAll()
 }
@@ -1,10 +1,15 @@
 package sessions_test
 
 import (
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/radiantrfid/iris"
 	"github.com/radiantrfid/iris/context"
+	"github.com/radiantrfid/iris/core/memstore"
 	"github.com/radiantrfid/iris/httptest"
 	"github.com/radiantrfid/iris/sessions"
 )
@@ -107,7 +112,10 @@ func TestFlashMessages(t *testing.T) {
 	app := iris.New()
 
 	sess := sessions.New(sessions.Config{Cookie: "mycustomsessionid"})
+	testFlashMessages(t, sess, app)
+}
 
+func testFlashMessages(t *testing.T, sess *sessions.Sessions, app *iris.Application) {
 	valueSingleKey := "Name"
 	valueSingleValue := "iris-sessions"
 
@@ -191,3 +199,346 @@ func TestFlashMessages(t *testing.T) {
 	e.POST("/set").WithJSON(values).Expect().Status(iris.StatusOK)
 	e.GET("/get_single").Expect().Status(iris.StatusOK).Body().Equal(valueSingleValue)
 }
+
+func TestSessionsRegenerate(t *testing.T) {
+	app := iris.New()
+
+	sess := sessions.New(sessions.Config{Cookie: "mycustomsessionid"})
+	testRegenerate(t, sess, app, true)
+}
+
+// entriesDB is a database without a `sessions.Regenerator`.
+type entriesDB struct {
+	values map[string]*memstore.Store
+	mu     sync.RWMutex
+}
+
+func (db *entriesDB) Acquire(sid string, expires time.Duration) sessions.LifeTime {
+	db.mu.Lock()
+	if _, ok := db.values[sid]; !ok {
+		db.values[sid] = new(memstore.Store)
+	}
+	db.mu.Unlock()
+	return sessions.LifeTime{}
+}
+
+func (db *entriesDB) store(sid string) *memstore.Store {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.values[sid]
+}
+
+func (db *entriesDB) OnUpdateExpiration(string, time.Duration) error { return nil }
+func (db *entriesDB) Set(sid string, _ sessions.LifeTime, key string, value interface{}, immutable bool) {
+	db.store(sid).Save(key, value, immutable)
+}
+func (db *entriesDB) Get(sid string, key string) interface{} { return db.store(sid).Get(key) }
+func (db *entriesDB) Visit(sid string, cb func(key string, value interface{})) {
+	db.store(sid).Visit(cb)
+}
+func (db *entriesDB) VisitEntries(sid string, cb func(entry memstore.Entry)) {
+	for _, entry := range *db.store(sid) {
+		cb(entry)
+	}
+}
+func (db *entriesDB) Len(sid string) int                 { return db.store(sid).Len() }
+func (db *entriesDB) Delete(sid string, key string) bool { return db.store(sid).Remove(key) }
+func (db *entriesDB) Clear(sid string)                   { db.store(sid).Reset() }
+func (db *entriesDB) Release(sid string) {
+	db.mu.Lock()
+	delete(db.values, sid)
+	db.mu.Unlock()
+}
+
+func TestSessionsRegenerateKeepsImmutable(t *testing.T) {
+	app := iris.New()
+
+	sess := sessions.New(sessions.Config{Cookie: "mycustomsessionid"})
+	sess.UseDatabase(&entriesDB{values: make(map[string]*memstore.Store)})
+
+	app.Get("/", func(ctx context.Context) {
+		sess.Start(ctx).SetImmutable("role", "admin")
+		s, err := sess.Regenerate(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s.Set("role", "guest")
+		ctx.WriteString(s.GetString("role"))
+	})
+
+	e := httptest.New(t, app)
+	e.GET("/").Expect().Status(iris.StatusOK).Body().Equal("admin")
+}
+
+// oldInvalid should be false for the stateless sessions, their old cookies can not be revoked.
+func testRegenerate(t *testing.T, sess *sessions.Sessions, app *iris.Application, oldInvalid bool) {
+	app.Get("/set", func(ctx context.Context) {
+		sess.Start(ctx).Set("name", "iris")
+	})
+
+	app.Get("/login", func(ctx context.Context) {
+		oldID := sess.Start(ctx).ID()
+		s, err := sess.Regenerate(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s.ID() == oldID {
+			t.Fatalf("expected a new session id but got the same: %s", oldID)
+		}
+
+		s.Set("user", "admin")
+		ctx.Next()
+	}, func(ctx context.Context) {
+		// the next handlers see the regenerated session.
+		ctx.WriteString(sess.Start(ctx).ID())
+	})
+
+	app.Get("/get", func(ctx context.Context) {
+		s := sess.Start(ctx)
+		ctx.Writef("%s %s", s.GetString("name"), s.GetString("user"))
+	})
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	oldCookie := e.GET("/set").Expect().Status(iris.StatusOK).Cookie("mycustomsessionid").Value().Raw()
+
+	newID := e.GET("/login").Expect().Status(iris.StatusOK).Body().NotEmpty().Raw()
+	e.GET("/get").Expect().Status(iris.StatusOK).Body().Equal("iris admin")
+
+	if oldInvalid {
+		// the old session id is not valid anymore.
+		e.GET("/get").WithCookie("mycustomsessionid", oldCookie).Expect().Status(iris.StatusOK).Body().Equal(" ")
+	}
+	e.GET("/login").Expect().Status(iris.StatusOK).Body().NotEqual(newID)
+}
+
+func TestSessionsRegenerateEvery(t *testing.T) {
+	app := iris.New()
+
+	sess := sessions.New(sessions.Config{Cookie: "mycustomsessionid", RegenerateEvery: 50 * time.Millisecond})
+	testRegenerateEvery(t, sess, app)
+}
+
+func testRegenerateEvery(t *testing.T, sess *sessions.Sessions, app *iris.Application) {
+	app.Get("/", func(ctx context.Context) {
+		s := sess.Start(ctx)
+		ctx.Writef("%s %d", s.ID(), s.Increment("counter", 1))
+	})
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	first := strings.Split(e.GET("/").Expect().Status(iris.StatusOK).Body().Raw(), " ")
+	second := strings.Split(e.GET("/").Expect().Status(iris.StatusOK).Body().Raw(), " ")
+	if first[0] != second[0] || second[1] != "2" {
+		t.Fatalf("expected the same session but got: %v and %v", first, second)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	third := strings.Split(e.GET("/").Expect().Status(iris.StatusOK).Body().Raw(), " ")
+	if third[0] == second[0] || third[1] != "3" {
+		t.Fatalf("expected a regenerated session id with the same values but got: %v and %v", second, third)
+	}
+
+	fourth := strings.Split(e.GET("/").Expect().Status(iris.StatusOK).Body().Raw(), " ")
+	if fourth[0] != third[0] || fourth[1] != "4" {
+		t.Fatalf("expected the regenerated session but got: %v and %v", third, fourth)
+	}
+}
+
+func TestSessionsUsers(t *testing.T) {
+	app := iris.New()
+	sess := sessions.New(sessions.Config{Cookie: "mycustomsessionid"})
+
+	app.Get("/login", func(ctx context.Context) {
+		s := sess.Start(ctx)
+		s.SetUser("kataras")
+		ctx.WriteString(s.ID())
+	})
+
+	app.Get("/user", func(ctx context.Context) {
+		ctx.WriteString(sess.Start(ctx).User())
+	})
+
+	app.Get("/devices", func(ctx context.Context) {
+		infos, err := sess.ListByUser("kataras")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx.JSON(infos)
+	})
+
+	app.Get("/logout_everywhere", func(ctx context.Context) {
+		n, err := sess.DestroyByUser("kataras")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx.Writef("%d", n)
+	})
+
+	desktop := httptest.New(t, app, httptest.URL("http://example.com"))
+	mobile := httptest.New(t, app, httptest.URL("http://example.com"))
+
+	desktopID := desktop.GET("/login").WithHeader("User-Agent", "desktop").Expect().Status(iris.StatusOK).Body().Raw()
+	mobileID := mobile.GET("/login").WithHeader("User-Agent", "mobile").Expect().Status(iris.StatusOK).Body().Raw()
+	mobile.GET("/user").WithHeader("User-Agent", "mobile").Expect().Status(iris.StatusOK).Body().Equal("kataras")
+
+	devices := desktop.GET("/devices").Expect().Status(iris.StatusOK).JSON().Array()
+	devices.Length().Equal(2)
+	// the most recently seen first.
+	devices.Element(0).Object().ValueEqual("id", mobileID).ValueEqual("user", "kataras").ValueEqual("userAgent", "mobile")
+	devices.Element(1).Object().ValueEqual("id", desktopID).ValueEqual("userAgent", "desktop")
+	devices.Element(1).Object().Value("createdAt").String().NotEmpty()
+
+	desktop.GET("/logout_everywhere").Expect().Status(iris.StatusOK).Body().Equal("2")
+	mobile.GET("/user").Expect().Status(iris.StatusOK).Body().Empty()
+	desktop.GET("/devices").Expect().Status(iris.StatusOK).JSON().Null()
+}
+
+// eventRecorder keeps the fired session events, in order.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *eventRecorder) listen(evt sessions.Event, sid string) {
+	r.mu.Lock()
+	r.events = append(r.events, evt.String())
+	r.mu.Unlock()
+}
+
+// flush returns and resets the fired events.
+func (r *eventRecorder) flush() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := strings.Join(r.events, " ")
+	r.events = nil
+	return events
+}
+
+func TestSessionsEvents(t *testing.T) {
+	app := iris.New()
+
+	sess := sessions.New(sessions.Config{Cookie: "mycustomsessionid"})
+	testEvents(t, sess, app)
+}
+
+func testEvents(t *testing.T, sess *sessions.Sessions, app *iris.Application) {
+	var (
+		all       eventRecorder
+		destroyed eventRecorder
+	)
+	sess.OnEvent(all.listen)
+	sess.OnEvent(destroyed.listen, sessions.EventExpire, sessions.EventDestroy)
+
+	app.Get("/set", func(ctx context.Context) {
+		// the start events are fired once per request.
+		sess.Start(ctx).Set("name", "iris")
+		sess.Start(ctx).Set("lang", "go")
+	})
+
+	app.Get("/get", func(ctx context.Context) {
+		ctx.WriteString(sess.Start(ctx).GetString("name"))
+	})
+
+	app.Get("/destroy", func(ctx context.Context) {
+		sess.Start(ctx)
+		sess.Destroy(ctx)
+	})
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	e.GET("/set").Expect().Status(iris.StatusOK)
+	if got, expected := all.flush(), "create update update"; got != expected {
+		t.Fatalf("expected events '%s' but got '%s'", expected, got)
+	}
+
+	e.GET("/get").Expect().Status(iris.StatusOK).Body().Equal("iris")
+	if got, expected := all.flush(), "read"; got != expected {
+		t.Fatalf("expected events '%s' but got '%s'", expected, got)
+	}
+
+	e.GET("/destroy").Expect().Status(iris.StatusOK)
+	if got, expected := all.flush(), "read destroy"; got != expected {
+		t.Fatalf("expected events '%s' but got '%s'", expected, got)
+	}
+
+	if got, expected := destroyed.flush(), "destroy"; got != expected {
+		t.Fatalf("expected filtered events '%s' but got '%s'", expected, got)
+	}
+}
+
+func TestSessionsExpireEvent(t *testing.T) {
+	app := iris.New()
+	sess := sessions.New(sessions.Config{Cookie: "mycustomsessionid", Expires: 50 * time.Millisecond})
+
+	expired := make(chan string, 1)
+	sess.OnEvent(func(evt sessions.Event, sid string) {
+		expired <- sid
+	}, sessions.EventExpire)
+
+	app.Get("/", func(ctx context.Context) {
+		ctx.WriteString(sess.Start(ctx).ID())
+	})
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	sid := e.GET("/").Expect().Status(iris.StatusOK).Body().Raw()
+
+	select {
+	case got := <-expired:
+		if got != sid {
+			t.Fatalf("expected the expiration of '%s' but got '%s'", sid, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the session to be expired")
+	}
+}
+
+func TestSessionsIdleTimeout(t *testing.T) {
+	app := iris.New()
+
+	sess := sessions.New(sessions.Config{
+		Cookie:      "mycustomsessionid",
+		IdleTimeout: 200 * time.Millisecond,
+		MaxLifetime: 600 * time.Millisecond,
+	})
+	testIdleTimeout(t, sess, app)
+}
+
+func testIdleTimeout(t *testing.T, sess *sessions.Sessions, app *iris.Application) {
+	// the cookie expiration is rounded to seconds,
+	// keep it until the browser closes so only the server-side lifetime is tested.
+	browserSession := func(c *http.Cookie) {
+		c.Expires = time.Time{}
+		c.MaxAge = 0
+	}
+
+	app.Get("/", func(ctx context.Context) {
+		ctx.Writef("%d", sess.Start(ctx, browserSession).Increment("counter", 1))
+	})
+
+	app.Get("/remember", func(ctx context.Context) {
+		if err := sess.Remember(ctx, time.Hour, browserSession); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	e := httptest.New(t, app, httptest.URL("http://example.com"))
+	e.GET("/").Expect().Status(iris.StatusOK).Body().Equal("1")
+	// each request extends the lifetime of the session by the idle timeout.
+	for _, expected := range []string{"2", "3", "4"} {
+		time.Sleep(120 * time.Millisecond)
+		e.GET("/").Expect().Status(iris.StatusOK).Body().Equal(expected)
+	}
+
+	// but not further than the maximum lifetime.
+	time.Sleep(300 * time.Millisecond)
+	e.GET("/").Expect().Status(iris.StatusOK).Body().Equal("1")
+
+	// idle sessions expire.
+	time.Sleep(250 * time.Millisecond)
+	e.GET("/").Expect().Status(iris.StatusOK).Body().Equal("1")
+
+	// the "remember me" lifetime replaces the idle timeout.
+	e.GET("/remember").Expect().Status(iris.StatusOK)
+	time.Sleep(250 * time.Millisecond)
+	e.GET("/").Expect().Status(iris.StatusOK).Body().Equal("2")
+}
@@ -0,0 +1,149 @@
+package sessions
+
+import (
+	"sort"
+	"time"
+
+	"github.com/radiantrfid/iris/context"
+)
+
+// SessionInfo is the metadata of a session,
+// it can be used to build an "active devices" page, see `Sessions.ListByUser`.
+type SessionInfo struct {
+	// ID is the session id.
+	ID string `json:"id"`
+	// User is the identity that the session is bound to, see `Session.SetUser`.
+	User string `json:"user"`
+	// CreatedAt is the time that the session was started.
+	CreatedAt time.Time `json:"createdAt"`
+	// LastSeen is the time of the latest request of the session,
+	// it's saved to the database at most once per minute unless the IP or the User-Agent changes.
+	LastSeen time.Time `json:"lastSeen"`
+	// IP is the remote address of the latest request of the session.
+	IP string `json:"ip"`
+	// UserAgent is the User-Agent header of the latest request of the session.
+	UserAgent string `json:"userAgent"`
+	// Expires is the expiration time of the session, zero if it does not expire.
+	Expires time.Time `json:"expires"`
+	// Remember is the "remember me" lifetime of the session, see `Sessions.Remember`.
+	Remember time.Duration `json:"remember,omitempty"`
+}
+
+// UserIndex is an optional interface which a `Database` can implement
+// in order to keep the metadata of the sessions which are bound to a user
+// and index them by their user, see `Session.SetUser`.
+// A database which implements it should remove the session's metadata on `Release`
+// and expire it with the session.
+// If the database does not implement it then only the sessions of the current process are indexed.
+//
+// All the builtin databases implement it.
+type UserIndex interface {
+	// SetInfo saves the metadata of the "info.ID" session and indexes it by the "info.User".
+	SetInfo(info SessionInfo) error
+	// GetInfo returns the metadata of the session of the "sid", if any.
+	GetInfo(sid string) (SessionInfo, bool)
+	// DeleteInfo removes the metadata of the session of the "sid" and its user index.
+	DeleteInfo(sid string) error
+	// ListByUser returns the metadata of the sessions of the "user".
+	ListByUser(user string) ([]SessionInfo, error)
+}
+
+// lastSeenInterval is the minimum duration between two saves of the `SessionInfo.LastSeen`.
+var lastSeenInterval = time.Minute
+
+// SetUser binds the session to the "user" identity, i.e the user's id,
+// so it can be found by the `Sessions.ListByUser` and removed by the `Sessions.DestroyByUser`.
+// An empty "user" unbinds the session.
+//
+// Binding a session to a user is a privilege change,
+// call the `Sessions.Regenerate` before it.
+func (s *Session) SetUser(user string) {
+	s.mu.Lock()
+	s.info.User = user
+	s.info.Expires = s.Lifetime.Time
+	info := s.info
+	s.mu.Unlock()
+
+	if s.cookie != nil {
+		s.save()
+		return
+	}
+
+	s.provider.saveInfo(info)
+}
+
+// User returns the identity that the session is bound to, see `SetUser`.
+func (s *Session) User() string {
+	s.mu.RLock()
+	user := s.info.User
+	s.mu.RUnlock()
+	return user
+}
+
+// Info returns the metadata of the session.
+func (s *Session) Info() SessionInfo {
+	s.mu.RLock()
+	info := s.info
+	s.mu.RUnlock()
+	return info
+}
+
+// touch updates the metadata of the "sess" by the current request.
+func (s *Sessions) touch(ctx context.Context, sess *Session) {
+	var (
+		now = time.Now()
+		ip  = ctx.RemoteAddr()
+		ua  = ctx.GetHeader("User-Agent")
+	)
+
+	sess.mu.Lock()
+	info := &sess.info
+	changed := info.IP != ip || info.UserAgent != ua || now.Sub(info.LastSeen) >= lastSeenInterval
+	info.LastSeen, info.IP, info.UserAgent = now, ip, ua
+	info.Expires = sess.Lifetime.Time
+	snapshot := *info
+	sess.mu.Unlock()
+
+	if changed && snapshot.User != "" && sess.cookie == nil {
+		s.provider.saveInfo(snapshot)
+	}
+}
+
+// ListByUser returns the metadata of the sessions which are bound to the "user",
+// the most recently seen first. See `Session.SetUser`.
+//
+// It returns an `ErrNotImplemented` for the stateless sessions of a `CookieStore`.
+func (s *Sessions) ListByUser(user string) ([]SessionInfo, error) {
+	if s.cookieStore != nil {
+		return nil, ErrNotImplemented
+	}
+
+	infos, err := s.provider.ListByUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].LastSeen.After(infos[j].LastSeen)
+	})
+
+	return infos, nil
+}
+
+// DestroyByUser removes all the sessions which are bound to the "user",
+// i.e to "log out everywhere". It returns the number of the removed sessions.
+// Clients' session cookies will still exist but they will be reseted on their next request.
+//
+// It returns an `ErrNotImplemented` for the stateless sessions of a `CookieStore`.
+func (s *Sessions) DestroyByUser(user string) (int, error) {
+	infos, err := s.ListByUser(user)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, info := range infos {
+		s.provider.DestroyByID(info.ID)
+	}
+
+	return len(infos), nil
+}